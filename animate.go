@@ -0,0 +1,146 @@
+package ffmpeg_go
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Keyframe the value of a filter parameter at a point of time.
+//
+// Easing is the curve used when animating from the previous keyframe of the same parameter to this one.
+type Keyframe struct {
+	Time   float64 `json:"time"`
+	Param  string  `json:"param"`
+	Value  float64 `json:"value"`
+	Easing Easing  `json:"easing,omitempty"`
+}
+
+// DefaultAnimationRate the number of commands per second generated by Animate.
+var DefaultAnimationRate = 25.0
+
+// Animate animates parameters of the filter instance filterLabel with a sendcmd filter inserted on the stream.
+//
+// The target filter must be created with an instance name, e.g. “Filter(streams, "overlay@logo", nil)“ and
+// Param must be a command supported by that filter, e.g. “x“ and “y“ for overlay, “w“ and “h“ for scale.
+// The returned stream should be used as the input of the filter (any filter of the same graph works).
+// Commands are only sent when a frame passes through sendcmd, so s must keep producing frames for the whole
+// animation: animate the main video rather than a still image, or loop the image input with “loop=1“.
+//
+//	main := Animate(Input("in.mp4"), "overlay@logo", []Keyframe{
+//		{Time: 0, Param: "x", Value: 0},
+//		{Time: 2, Param: "x", Value: 200, Easing: EasingEaseInOut},
+//	})
+//	Filter([]*Stream{main, Input("logo.png")}, "overlay@logo", nil)
+func Animate(s *Stream, filterLabel string, keyframes []Keyframe) *Stream {
+	return AnimateWithRate(s, filterLabel, keyframes, DefaultAnimationRate)
+}
+
+// AnimateWithRate is the same as Animate but sends rate commands per second.
+func AnimateWithRate(s *Stream, filterLabel string, keyframes []Keyframe, rate float64) *Stream {
+	return s.Filter("sendcmd", nil, KwArgs{"c": SendCmdScript(filterLabel, keyframes, rate)})
+}
+
+// AnimateAudio is the same as Animate for audio filters, using asendcmd.
+func AnimateAudio(s *Stream, filterLabel string, keyframes []Keyframe) *Stream {
+	return s.Filter("asendcmd", nil, KwArgs{"c": SendCmdScript(filterLabel, keyframes, DefaultAnimationRate)})
+}
+
+// groupKeyframes groups keyframes by parameter, each group sorted by time.
+func groupKeyframes(keyframes []Keyframe) (params []string, groups map[string][]Keyframe) {
+	groups = map[string][]Keyframe{}
+	for _, k := range keyframes {
+		if _, ok := groups[k.Param]; !ok {
+			params = append(params, k.Param)
+		}
+		groups[k.Param] = append(groups[k.Param], k)
+	}
+	for _, p := range params {
+		sort.SliceStable(groups[p], func(i, j int) bool {
+			return groups[p][i].Time < groups[p][j].Time
+		})
+	}
+	return params, groups
+}
+
+// easeValue evaluates the easing curve in go, it must match Ease.
+func easeValue(easing Easing, p float64) float64 {
+	p = math.Max(0, math.Min(1, p))
+	switch easing {
+	case EasingEaseIn:
+		return p * p
+	case EasingEaseOut:
+		return p * (2 - p)
+	case EasingEaseInOut:
+		return p * p * (3 - 2*p)
+	case EasingLinear, "":
+		return p
+	default:
+		panic(fmt.Sprintf("unknown easing: %s", easing))
+	}
+}
+
+func formatAnimationNumber(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
+
+// SendCmdScript renders keyframes as sendcmd commands (https://ffmpeg.org/ffmpeg-filters.html#sendcmd_002c-asendcmd),
+// sampling the animation rate times per second.  The result can be written to a file for the “f“ option or
+// passed inline with the “c“ option.
+func SendCmdScript(filterLabel string, keyframes []Keyframe, rate float64) string {
+	if rate <= 0 {
+		rate = DefaultAnimationRate
+	}
+	commands := map[int64][]string{}
+	add := func(t float64, param string, v float64) {
+		// key by milliseconds so that samples of different parameters share one interval
+		key := int64(math.Round(t * 1000))
+		commands[key] = append(commands[key], fmt.Sprintf("%s %s %s", filterLabel, param, formatAnimationNumber(v)))
+	}
+	params, groups := groupKeyframes(keyframes)
+	for _, param := range params {
+		frames := groups[param]
+		add(frames[0].Time, param, frames[0].Value)
+		for i := 1; i < len(frames); i++ {
+			from, to := frames[i-1], frames[i]
+			if to.Time > from.Time {
+				for n := 1; from.Time+float64(n)/rate < to.Time-1e-6; n++ {
+					t := from.Time + float64(n)/rate
+					add(t, param, from.Value+(to.Value-from.Value)*easeValue(to.Easing, (t-from.Time)/(to.Time-from.Time)))
+				}
+			}
+			add(to.Time, param, to.Value)
+		}
+	}
+	var keys []int64
+	for k := range commands {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	var lines []string
+	for _, k := range keys {
+		lines = append(lines, fmt.Sprintf("%s %s;", formatAnimationNumber(float64(k)/1000), strings.Join(commands[k], ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// KeyframeExpr renders the keyframes of one parameter as a per-frame expression of x (usually T), for filter
+// options which are evaluated for every frame, e.g. overlay x/y or drawtext alpha.
+func KeyframeExpr(x Expr, keyframes []Keyframe) Expr {
+	if len(keyframes) == 0 {
+		panic("at least one keyframe is required")
+	}
+	frames := append([]Keyframe{}, keyframes...)
+	sort.SliceStable(frames, func(i, j int) bool { return frames[i].Time < frames[j].Time })
+	var e Expr = Num(frames[len(frames)-1].Value)
+	for i := len(frames) - 1; i > 0; i-- {
+		from, to := frames[i-1], frames[i]
+		if to.Time <= from.Time {
+			continue
+		}
+		e = If(Lt(x, to.Time), Tween(x, from.Time, to.Time, from.Value, to.Value, to.Easing), e)
+	}
+	return e
+}
@@ -0,0 +1,56 @@
+package ffmpeg_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendCmdScript(t *testing.T) {
+	script := SendCmdScript("overlay@logo", []Keyframe{
+		{Time: 1, Param: "x", Value: 100},
+		{Time: 0, Param: "x", Value: 0},
+		{Time: 0, Param: "y", Value: 10},
+		{Time: 0.5, Param: "y", Value: 20, Easing: EasingEaseIn},
+	}, 4)
+	assert.Equal(t, "0 overlay@logo x 0, overlay@logo y 10;\n"+
+		"0.25 overlay@logo x 25, overlay@logo y 12.5;\n"+
+		"0.5 overlay@logo x 50, overlay@logo y 20;\n"+
+		"0.75 overlay@logo x 75;\n"+
+		"1 overlay@logo x 100;", script)
+}
+
+func TestAnimate(t *testing.T) {
+	// sendcmd is on the main video: a still image produces a single frame and would only send the first command
+	main, logo := Input("in.mp4"), Input("logo.png")
+	animated := AnimateWithRate(main, "overlay@logo", []Keyframe{
+		{Time: 0, Param: "x", Value: 0},
+		{Time: 1, Param: "x", Value: 10},
+	}, 1)
+	args := Filter([]*Stream{animated, logo}, "overlay@logo", nil).Output("out.mp4").GetArgs()
+	assert.Equal(t, []string{
+		"-i", "in.mp4", "-i", "logo.png",
+		"-filter_complex", `[0]sendcmd=c=0 overlay@logo x 0\;
+1 overlay@logo x 10\;[s0];[s0][1]overlay@logo[s1]`,
+		"-map", "[s1]", "out.mp4",
+	}, args)
+
+	// a looped image keeps producing frames
+	looped := AnimateWithRate(Input("logo.png", KwArgs{"loop": 1}), "overlay@logo", []Keyframe{
+		{Time: 0, Param: "x", Value: 0},
+		{Time: 1, Param: "x", Value: 10},
+	}, 1)
+	args = Filter([]*Stream{Input("in.mp4"), looped}, "overlay@logo", nil, KwArgs{"shortest": 1}).Output("out.mp4").GetArgs()
+	assert.Equal(t, []string{"-i", "in.mp4", "-loop", "1", "-i", "logo.png"}, args[:6])
+	assert.Contains(t, args[7], "[1]sendcmd=")
+}
+
+func TestKeyframeExpr(t *testing.T) {
+	e := KeyframeExpr(T, []Keyframe{
+		{Time: 0, Value: 0},
+		{Time: 1, Value: 1},
+		{Time: 3, Value: 0, Easing: EasingEaseOut},
+	})
+	assert.Equal(t, "if(lt(t,1),(0+((1-0)*clip(((t-0)/(1-0)),0,1))),"+
+		"if(lt(t,3),(1+((0-1)*(clip(((t-1)/(3-1)),0,1)*(2-clip(((t-1)/(3-1)),0,1))))),0))", e.String())
+}
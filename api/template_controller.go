@@ -0,0 +1,235 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/u2takey/ffmpeg-go"
+	"github.com/u2takey/ffmpeg-go/queue"
+	"github.com/u2takey/ffmpeg-go/service"
+)
+
+// maxTemplateBatch 一次渲染请求最多的变量组数
+const maxTemplateBatch = 1000
+
+// 用于存储编辑模板的全局变量
+var globalTemplateStore *service.TemplateStore
+
+// SetTemplateStore 设置全局模板存储
+func SetTemplateStore(store *service.TemplateStore) {
+	globalTemplateStore = store
+}
+
+// RegisterVideoTemplate 注册编辑模板
+// @Summary 注册编辑模板
+// @Description 注册含 {{变量}} 的编辑模板，同名模板会被替换。注册时检查变量声明，并用默认值或示例值渲染一次检查规范
+// @Tags video
+// @Accept json
+// @Produce json
+// @Param request body ffmpeg_go.EditTemplate true "编辑模板"
+// @Success 200 {object} map[string]string "注册成功"
+// @Failure 400 {object} map[string]interface{} "请求参数错误，details 为字段错误列表"
+// @Failure 500 {object} map[string]string "内部服务器错误"
+// @Router /video/templates [post]
+func RegisterVideoTemplate(c *gin.Context) {
+	if globalTemplateStore == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Template store not initialized",
+		})
+		return
+	}
+
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+	template, err := ffmpeg_go.ParseEditTemplate(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, templateError(err))
+		return
+	}
+	if err := globalTemplateStore.Register(template); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Template registered",
+		"name":    template.Name,
+	})
+}
+
+// templateError 模板错误的响应，字段错误逐条返回路径和原因
+func templateError(err error) gin.H {
+	if errs, ok := err.(ffmpeg_go.ValidationErrors); ok {
+		return gin.H{
+			"error":   "Invalid template",
+			"details": errs,
+		}
+	}
+	return gin.H{
+		"error": err.Error(),
+	}
+}
+
+// ListVideoTemplates 列出编辑模板
+// @Summary 列出编辑模板
+// @Description 按名称列出所有已注册的编辑模板
+// @Tags video
+// @Produce json
+// @Success 200 {array} ffmpeg_go.EditTemplate "模板列表"
+// @Failure 500 {object} map[string]string "内部服务器错误"
+// @Router /video/templates [get]
+func ListVideoTemplates(c *gin.Context) {
+	if globalTemplateStore == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Template store not initialized",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, globalTemplateStore.List())
+}
+
+// GetVideoTemplate 获取编辑模板
+// @Summary 获取编辑模板
+// @Description 根据名称获取已注册的编辑模板
+// @Tags video
+// @Produce json
+// @Param name path string true "模板名称"
+// @Success 200 {object} ffmpeg_go.EditTemplate "编辑模板"
+// @Failure 404 {object} map[string]string "模板未找到"
+// @Failure 500 {object} map[string]string "内部服务器错误"
+// @Router /video/templates/{name} [get]
+func GetVideoTemplate(c *gin.Context) {
+	if globalTemplateStore == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Template store not initialized",
+		})
+		return
+	}
+
+	template, ok := globalTemplateStore.Get(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Template not found",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, template)
+}
+
+// RenderVideoTemplate 用多组变量渲染编辑模板
+// @Summary 批量渲染编辑模板
+// @Description 每组变量渲染出一个编辑规范并提交一个编辑任务。所有变量组都通过检查后才提交任务，任一组出错时返回每组的错误，不提交任何任务
+// @Tags video
+// @Accept json
+// @Produce json
+// @Param name path string true "模板名称"
+// @Param request body VideoTemplateRenderRequest true "模板渲染请求"
+// @Success 202 {object} VideoTemplateRenderResponse "已提交的任务"
+// @Failure 400 {object} map[string]interface{} "请求参数错误，details 为每组变量的错误列表"
+// @Failure 404 {object} map[string]string "模板未找到"
+// @Failure 500 {object} map[string]string "内部服务器错误"
+// @Router /video/templates/{name}/render [post]
+func RenderVideoTemplate(c *gin.Context) {
+	if globalTemplateStore == nil || globalTaskQueue == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "Template store or task queue not initialized",
+		})
+		return
+	}
+
+	template, ok := globalTemplateStore.Get(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": "Template not found",
+		})
+		return
+	}
+
+	var req VideoTemplateRenderRequest
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Variables) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+	if len(req.Variables) > maxTemplateBatch {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": fmt.Sprintf("Too many variable sets, at most %d per request", maxTemplateBatch),
+		})
+		return
+	}
+
+	// 先渲染并检查所有变量组，输出路径不能重复
+	taskIDs := make([]string, len(req.Variables))
+	specs := make([]*ffmpeg_go.EditSpec, len(req.Variables))
+	outPaths := make(map[string]int)
+	var details []gin.H
+	for i, vars := range req.Variables {
+		taskIDs[i] = uuid.New().String()
+		// 与 /video/edit 相同，填充默认尺寸和帧率后检查规范
+		spec, err := template.Render(vars)
+		if err == nil {
+			spec, err = prepareEditSpec(spec, taskIDs[i], req.Verbose)
+		}
+		if err == nil {
+			if j, exists := outPaths[spec.OutPath]; exists {
+				err = ffmpeg_go.ValidationErrors{{Path: "outPath", Message: fmt.Sprintf("与第 %d 组变量的输出路径重复: %s", j, spec.OutPath)}}
+			}
+			outPaths[spec.OutPath] = i
+		}
+		if err != nil {
+			detail := templateError(err)
+			detail["index"] = i
+			details = append(details, detail)
+			continue
+		}
+		specs[i] = spec
+	}
+	if len(details) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid template variables",
+			"details": details,
+		})
+		return
+	}
+
+	for i, spec := range specs {
+		task := &queue.Task{
+			ID: taskIDs[i],
+			Spec: map[string]interface{}{
+				"taskType":  "editly",
+				"template":  template.Name,
+				"variables": req.Variables[i],
+				"spec":      spec,
+			},
+			Priority: queue.TaskPriority(req.Priority),
+			Verbose:  req.Verbose,
+			Created:  time.Now(),
+		}
+		if err := globalTaskQueue.Push(task); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "Failed to submit task: " + err.Error(),
+				"taskIds": taskIDs[:i],
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusAccepted, VideoTemplateRenderResponse{
+		TaskIDs: taskIDs,
+		Status:  "pending",
+		Message: fmt.Sprintf("%d tasks submitted", len(taskIDs)),
+	})
+}
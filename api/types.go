@@ -1,5 +1,9 @@
 package api
 
+import (
+	"github.com/u2takey/ffmpeg-go"
+)
+
 // VideoEditRequest 视频编辑请求
 // @Description 视频编辑请求参数
 type VideoEditRequest struct {
@@ -15,6 +19,48 @@ type VideoEditRequest struct {
 	Verbose bool `json:"verbose,omitempty"`
 }
 
+// VideoPreviewRequest 视频预览请求
+// @Description 以较低的分辨率和帧率快速渲染预览视频
+type VideoPreviewRequest struct {
+	// 编辑规范，与视频编辑请求相同
+	Spec interface{} `json:"spec"`
+	// 预览参数：maxHeight、fps、keyframeInterval，outPath 由服务端决定
+	Options ffmpeg_go.PreviewOptions `json:"options"`
+	// 是否启用详细日志
+	Verbose bool `json:"verbose,omitempty"`
+}
+
+// VideoFrameRequest 单帧预览请求
+// @Description 渲染时间轴上某一时刻的合成画面
+type VideoFrameRequest struct {
+	// 编辑规范，与视频编辑请求相同
+	Spec interface{} `json:"spec"`
+	// 时间轴上的时间（秒）
+	Time float64 `json:"time"`
+}
+
+// VideoTemplateRenderRequest 模板渲染请求
+// @Description 用多组变量渲染编辑模板，每组变量提交一个编辑任务
+type VideoTemplateRenderRequest struct {
+	// 变量组，每组为变量名到值的映射
+	Variables []map[string]interface{} `json:"variables"`
+	// 任务优先级
+	Priority int `json:"priority,omitempty"`
+	// 是否启用详细日志
+	Verbose bool `json:"verbose,omitempty"`
+}
+
+// VideoTemplateRenderResponse 模板渲染响应
+// @Description 模板渲染提交的任务，顺序与变量组相同
+type VideoTemplateRenderResponse struct {
+	// 任务ID，顺序与变量组相同
+	TaskIDs []string `json:"taskIds"`
+	// 状态
+	Status string `json:"status"`
+	// 消息
+	Message string `json:"message"`
+}
+
 // VideoEditResponse 视频编辑响应
 // @Description 视频编辑任务提交响应
 type VideoEditResponse struct {
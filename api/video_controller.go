@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -42,7 +43,7 @@ func SetOSSManager(ossManager *service.OSSManager) {
 // @Produce json
 // @Param request body VideoEditRequest true "视频编辑请求"
 // @Success 200 {object} VideoEditResponse "任务处理完成"
-// @Failure 400 {object} map[string]string "请求参数错误"
+// @Failure 400 {object} map[string]interface{} "请求参数错误，details 为编辑规范的字段错误列表"
 // @Failure 500 {object} map[string]string "内部服务器错误"
 // @Router /video/edit [post]
 func SubmitVideoEdit(c *gin.Context) {
@@ -57,6 +58,13 @@ func SubmitVideoEdit(c *gin.Context) {
 	// 生成任务ID
 	taskID := uuid.New().String()
 
+	// 按 JSON Schema 严格解析并验证编辑规范
+	editSpec, err := parseVideoEditSpec(req.Spec, taskID, req.Verbose)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, editSpecError(err))
+		return
+	}
+
 	// 初始化userID为空字符串
 	userID := ""
 
@@ -72,7 +80,7 @@ func SubmitVideoEdit(c *gin.Context) {
 	}
 
 	// 直接处理任务而不是添加到队列
-	resultPath, err := processVideoEditTask(task, userID)
+	resultPath, err := processVideoEditTask(task, editSpec, userID)
 	if err != nil {
 		task.Status = "failed"
 		task.Error = err.Error()
@@ -165,8 +173,62 @@ func SubmitVideoEdit(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// parseVideoEditSpec 按 JSON Schema 严格解析请求中的编辑规范，填充服务端的默认值后验证规范和素材
+func parseVideoEditSpec(spec interface{}, taskID string, verbose bool) (*ffmpeg_go.EditSpec, error) {
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid task spec format")
+	}
+	editSpec, err := ffmpeg_go.ParseEditSpec(data)
+	if err != nil {
+		return nil, err
+	}
+	return prepareEditSpec(editSpec, taskID, verbose)
+}
+
+// prepareEditSpec 填充服务端默认的输出路径、尺寸和帧率，并检查规范
+func prepareEditSpec(editSpec *ffmpeg_go.EditSpec, taskID string, verbose bool) (*ffmpeg_go.EditSpec, error) {
+	applyEditSpecDefaults(editSpec, taskID, verbose)
+	if errs := editSpec.Validate(); len(errs) > 0 {
+		return nil, errs
+	}
+	return editSpec, nil
+}
+
+// applyEditSpecDefaults 填充服务端默认的输出路径、尺寸和帧率
+func applyEditSpecDefaults(editSpec *ffmpeg_go.EditSpec, taskID string, verbose bool) {
+	if editSpec.OutPath == "" {
+		// 如果没有指定输出路径，使用默认路径
+		editSpec.OutPath = fmt.Sprintf("./output/%s.mp4", taskID)
+	}
+	if editSpec.Width == 0 {
+		editSpec.Width = 1920 // 默认宽度
+	}
+	if editSpec.Height == 0 {
+		editSpec.Height = 1080 // 默认高度
+	}
+	if editSpec.Fps == 0 {
+		editSpec.Fps = 30 // 默认帧率
+	}
+	// 使用任务级别的verbose设置
+	editSpec.Verbose = editSpec.Verbose || verbose
+}
+
+// editSpecError 编辑规范错误的响应，字段错误逐条返回路径和原因
+func editSpecError(err error) gin.H {
+	if errs, ok := err.(ffmpeg_go.ValidationErrors); ok {
+		return gin.H{
+			"error":   "Invalid edit spec",
+			"details": errs,
+		}
+	}
+	return gin.H{
+		"error": err.Error(),
+	}
+}
+
 // processVideoEditTask 处理视频编辑任务
-func processVideoEditTask(task *queue.Task, userID string) (string, error) {
+func processVideoEditTask(task *queue.Task, editSpec *ffmpeg_go.EditSpec, userID string) (string, error) {
 	// 创建任务日志记录器
 	taskLogger, err := service.NewTaskLogger(task.ID)
 	if err != nil && task.Verbose {
@@ -180,87 +242,12 @@ func processVideoEditTask(task *queue.Task, userID string) (string, error) {
 		})
 	}
 
-	// 将任务规范转换为EditSpec
-	spec, ok := task.Spec.(map[string]interface{})
-	if !ok {
-		return "", fmt.Errorf("invalid task spec format")
-	}
-
-	// 创建EditSpec对象
-	editSpec := &ffmpeg_go.EditSpec{}
-
-	// 从map转换到EditSpec结构体
-	if outPath, ok := spec["outPath"].(string); ok {
-		editSpec.OutPath = outPath
-	} else {
-		// 如果没有指定输出路径，使用默认路径
-		editSpec.OutPath = fmt.Sprintf("./output/%s.mp4", task.ID)
-	}
-
 	// 确保输出目录存在
 	outputDir := filepath.Dir(editSpec.OutPath)
 	if err := os.MkdirAll(outputDir, 0755); err != nil {
 		return "", fmt.Errorf("创建输出目录失败: %w", err)
 	}
 
-	if width, ok := spec["width"].(float64); ok {
-		editSpec.Width = int(width)
-	} else {
-		editSpec.Width = 1920 // 默认宽度
-	}
-
-	if height, ok := spec["height"].(float64); ok {
-		editSpec.Height = int(height)
-	} else {
-		editSpec.Height = 1080 // 默认高度
-	}
-
-	if fps, ok := spec["fps"].(float64); ok {
-		editSpec.Fps = int(fps)
-	} else {
-		editSpec.Fps = 30 // 默认帧率
-	}
-
-	if verbose, ok := spec["verbose"].(bool); ok {
-		editSpec.Verbose = verbose
-	} else {
-		editSpec.Verbose = task.Verbose // 使用任务级别的verbose设置
-	}
-
-	// 处理clips
-	if clips, ok := spec["clips"].([]interface{}); ok {
-		editSpec.Clips = make([]*ffmpeg_go.Clip, len(clips))
-		for i, clip := range clips {
-			if clipMap, ok := clip.(map[string]interface{}); ok {
-				editSpec.Clips[i] = &ffmpeg_go.Clip{}
-
-				// 处理layers
-				if layers, ok := clipMap["layers"].([]interface{}); ok {
-					editSpec.Clips[i].Layers = make([]*ffmpeg_go.Layer, len(layers))
-					for j, layer := range layers {
-						if layerMap, ok := layer.(map[string]interface{}); ok {
-							editSpec.Clips[i].Layers[j] = &ffmpeg_go.Layer{}
-
-							if layerType, ok := layerMap["type"].(string); ok {
-								editSpec.Clips[i].Layers[j].Type = layerType
-							}
-
-							if path, ok := layerMap["path"].(string); ok {
-								editSpec.Clips[i].Layers[j].Path = path
-							}
-
-							if text, ok := layerMap["text"].(string); ok {
-								editSpec.Clips[i].Layers[j].Text = text
-							}
-						}
-					}
-				}
-			}
-		}
-	} else {
-		return "", fmt.Errorf("clips字段缺失或格式不正确")
-	}
-
 	// 创建Editly实例并执行编辑
 	editly := ffmpeg_go.NewEditly(editSpec)
 
@@ -428,6 +415,167 @@ func CancelVideoEdit(c *gin.Context) {
 	})
 }
 
+// ResolveVideoEdit 返回合并默认值后的编辑规范
+// @Summary 查看合并默认值后的编辑规范
+// @Description 与提交任务时相同，先填充服务端默认的尺寸（1920x1080）、帧率（30）和输出路径（未指定时为 ./output/{taskId}.mp4），
+// @Description 再将 defaults 合并到每个片段和层，返回实际渲染使用的规范，不执行渲染
+// @Tags video
+// @Accept json
+// @Produce json
+// @Param request body ffmpeg_go.EditSpec true "视频编辑规范"
+// @Success 200 {object} ffmpeg_go.EditSpec "合并默认值后的规范"
+// @Failure 400 {object} map[string]interface{} "请求参数错误，details 为字段错误列表"
+// @Router /video/edit/resolve [post]
+func ResolveVideoEdit(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+	spec, err := ffmpeg_go.ParseEditSpec(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, editSpecError(err))
+		return
+	}
+	applyEditSpecDefaults(spec, "{taskId}", false)
+
+	resolved, err := ffmpeg_go.ResolveSpec(spec)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, resolved)
+}
+
+// PreviewVideoEdit 渲染预览视频
+// @Summary 渲染预览视频
+// @Description 以较低的分辨率和帧率、ultrafast 编码和较短的关键帧间隔快速渲染，直接返回 MP4 文件
+// @Tags video
+// @Accept json
+// @Produce video/mp4
+// @Param request body VideoPreviewRequest true "视频预览请求"
+// @Success 200 {file} file "预览视频"
+// @Failure 400 {object} map[string]interface{} "请求参数错误，details 为编辑规范的字段错误列表"
+// @Failure 500 {object} map[string]string "内部服务器错误"
+// @Router /video/edit/preview [post]
+func PreviewVideoEdit(c *gin.Context) {
+	var req VideoPreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	editSpec, err := parseVideoEditSpec(req.Spec, "preview-"+uuid.New().String(), req.Verbose)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, editSpecError(err))
+		return
+	}
+
+	// 预览文件由服务端管理，返回后删除
+	req.Options.OutPath = ""
+	previewPath, err := ffmpeg_go.Preview(editSpec, req.Options)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	defer os.Remove(previewPath)
+
+	c.File(previewPath)
+}
+
+// RenderVideoEditFrame 渲染单帧预览
+// @Summary 渲染单帧预览
+// @Description 返回时间轴上指定时间的合成画面，只渲染该时刻显示的片段
+// @Tags video
+// @Accept json
+// @Produce image/png
+// @Param request body VideoFrameRequest true "单帧预览请求"
+// @Success 200 {file} file "PNG 图片"
+// @Failure 400 {object} map[string]interface{} "请求参数错误，details 为编辑规范的字段错误列表"
+// @Failure 500 {object} map[string]string "内部服务器错误"
+// @Router /video/edit/frame [post]
+func RenderVideoEditFrame(c *gin.Context) {
+	var req VideoFrameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+
+	editSpec, err := parseVideoEditSpec(req.Spec, "frame-"+uuid.New().String(), false)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, editSpecError(err))
+		return
+	}
+
+	png, err := ffmpeg_go.RenderFrame(editSpec, req.Time)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.Data(http.StatusOK, "image/png", png)
+}
+
+// GetVideoEditSchema 获取编辑规范的 JSON Schema
+// @Summary 获取编辑规范的 JSON Schema
+// @Description 返回由 EditSpec 生成的 JSON Schema，提交和解析编辑规范时按此严格校验，不允许未知字段
+// @Tags video
+// @Produce json
+// @Success 200 {object} map[string]interface{} "JSON Schema"
+// @Router /video/edit/schema [get]
+func GetVideoEditSchema(c *gin.Context) {
+	c.JSON(http.StatusOK, ffmpeg_go.EditSpecSchema())
+}
+
+// ImportEditlyVideoEdit 导入原版 editly 的 JSON5 规范
+// @Summary 导入 editly 规范
+// @Description 把原版 editly（Node.js）的 JSON5 规范转换为编辑规范，不支持或近似处理的功能按路径列在 warnings 中，不执行渲染
+// @Tags video
+// @Accept plain
+// @Produce json
+// @Param request body string true "editly 的 JSON5 规范"
+// @Success 200 {object} map[string]interface{} "spec 为转换后的编辑规范，warnings 为警告列表"
+// @Failure 400 {object} map[string]string "请求参数错误"
+// @Router /video/edit/import [post]
+func ImportEditlyVideoEdit(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "Invalid request format",
+		})
+		return
+	}
+	spec, warnings, err := ffmpeg_go.ImportEditlySpec(data)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid editly spec",
+			"details": err.Error(),
+		})
+		return
+	}
+	if warnings == nil {
+		warnings = []*ffmpeg_go.ImportWarning{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"spec":     spec,
+		"warnings": warnings,
+	})
+}
+
 // GetWorkerPoolStatus 获取工作池状态
 // @Summary 获取工作池状态
 // @Description 获取当前工作池的状态信息
@@ -0,0 +1,63 @@
+package ffmpeg_go
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+var filterCapabilities = struct {
+	sync.Mutex
+	m map[string]map[string]bool
+}{m: map[string]map[string]bool{}}
+
+// parseFilterList parses the output of “ffmpeg -filters“, lines look like " TSC psnr  VV->V  Calculate the PSNR...".
+func parseFilterList(out []byte) map[string]bool {
+	filters := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	started := false
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if !started {
+			// the legend ends with a separator line
+			started = len(fields) == 1 && strings.HasPrefix(fields[0], "---")
+			continue
+		}
+		if len(fields) >= 3 {
+			filters[fields[1]] = true
+		}
+	}
+	return filters
+}
+
+// AvailableFilters returns the filters compiled into the ffmpeg binary, the result is cached per binary.
+func AvailableFilters(ffmpegPath string) (map[string]bool, error) {
+	if ffmpegPath == "" {
+		ffmpegPath = "ffmpeg"
+	}
+	filterCapabilities.Lock()
+	defer filterCapabilities.Unlock()
+	if filters, ok := filterCapabilities.m[ffmpegPath]; ok {
+		return filters, nil
+	}
+	cmd := exec.Command(ffmpegPath, "-hide_banner", "-filters")
+	for _, option := range GlobalCommandOptions {
+		option(cmd)
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("list ffmpeg filters: %w", err)
+	}
+	filters := parseFilterList(out)
+	filterCapabilities.m[ffmpegPath] = filters
+	return filters, nil
+}
+
+// HasFilter reports whether the ffmpeg binary supports the filter, e.g. "libvmaf" which is an optional dependency.
+func HasFilter(ffmpegPath, name string) bool {
+	filters, err := AvailableFilters(ffmpegPath)
+	return err == nil && filters[name]
+}
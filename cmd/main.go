@@ -90,6 +90,22 @@ func main() {
 	// 设置全局任务队列
 	api.SetTaskQueue(taskQueue)
 
+	// 初始化模板存储
+	templateStore, err := service.NewTemplateStore("./data/templates")
+	if err != nil {
+		fmt.Printf("Failed to create template store: %v\n", err)
+		os.Exit(1)
+	}
+	api.SetTemplateStore(templateStore)
+
+	// 初始化语音合成，供 Editly 的 voice 层使用
+	ttsService, err := service.NewTTSServiceFromConfig(loadTTSConfig())
+	if err != nil {
+		fmt.Printf("Failed to create TTS service: %v\n", err)
+	} else {
+		service.UseTTSForEditly(ttsService)
+	}
+
 	// 初始化OSS管理器
 	ossConfig := loadOSSConfig()
 	ossManager := service.NewOSSManager(*ossConfig)
@@ -132,6 +148,15 @@ func main() {
 	v1 := router.Group("/api/v1")
 	{
 		v1.POST("/video/edit", api.SubmitVideoEdit)
+		v1.POST("/video/edit/resolve", api.ResolveVideoEdit)
+		v1.POST("/video/edit/import", api.ImportEditlyVideoEdit)
+		v1.GET("/video/edit/schema", api.GetVideoEditSchema)
+		v1.POST("/video/edit/preview", api.PreviewVideoEdit)
+		v1.POST("/video/edit/frame", api.RenderVideoEditFrame)
+		v1.POST("/video/templates", api.RegisterVideoTemplate)
+		v1.GET("/video/templates", api.ListVideoTemplates)
+		v1.GET("/video/templates/:name", api.GetVideoTemplate)
+		v1.POST("/video/templates/:name/render", api.RenderVideoTemplate)
 		v1.GET("/video/edit/:id", api.GetVideoEditStatus)
 		v1.DELETE("/video/edit/:id", api.CancelVideoEdit)
 		v1.GET("/workerpool/status", api.GetWorkerPoolStatus)
@@ -236,4 +261,22 @@ func loadOSSConfig() *service.OSSConfig {
 		TsBucketName:         config.TsBucketName,
 		VideoOutputBucketName: config.VideoOutputBucketName,
 	}
-}
\ No newline at end of file
+}
+
+// loadTTSConfig 从配置文件加载TTS配置，文件不存在时使用 espeak-ng
+func loadTTSConfig() service.TTSConfig {
+	config := service.TTSConfig{Provider: "espeak-ng", CacheDir: "./cache/tts"}
+
+	data, err := os.ReadFile("config/tts_config.json")
+	if os.IsNotExist(err) {
+		return config
+	}
+	if err != nil {
+		fmt.Printf("读取TTS配置文件失败: %v\n", err)
+		return config
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		fmt.Printf("解析TTS配置文件失败: %v\n", err)
+	}
+	return config
+}
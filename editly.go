@@ -16,16 +16,35 @@ type EditSpec struct {
 	Width          int                    `json:"width"`
 	Height         int                    `json:"height"`
 	Fps            int                    `json:"fps"`
-	Defaults       map[string]interface{} `json:"defaults,omitempty"`
+	Defaults       *Defaults              `json:"defaults,omitempty"`
 	Clips          []*Clip                `json:"clips"`
-	AudioTracks    []string               `json:"audioTracks,omitempty"`
+	AudioTracks    []*AudioTrack          `json:"audioTracks,omitempty"`
 	KeepSourceAudio bool                 `json:"keepSourceAudio,omitempty"`
+	ClipsAudioVolume *float64            `json:"clipsAudioVolume,omitempty"` // 片段原声的混音音量，为空时为 1
+	AudioNorm      *AudioNorm             `json:"audioNorm,omitempty"`        // 成片音频的动态音量均衡
+	Ducking        *AudioDucking          `json:"ducking,omitempty"`          // 原声或旁白出现时压低背景音乐
 	Verbose        bool                   `json:"verbose,omitempty"` // 添加详细日志开关
+	Encoding       string                 `json:"encoding,omitempty"` // 编码预设名称，如 web-1080p，画布超过预设的分辨率时缩小成片，默认 editly-default
+	Parallel       int                    `json:"parallel,omitempty"` // 并行渲染的进程数，大于 1 时在没有转场的片段边界分段渲染，再无损拼接
+}
+
+// Defaults 片段和层的默认值。层的字段按以下优先级取值：层自身的值、LayerType 中对应类型的值、Layer 中的值、内置默认值，
+// 零值表示未设置，JSON 中显式写出的零值（如 "loop": false）表示已设置。Resolve 返回合并后的规范
+type Defaults struct {
+	Duration   float64           `json:"duration,omitempty"`   // 没有视频层的片段时长（秒）
+	Transition *Transition       `json:"transition,omitempty"` // 转场默认值，与片段的转场按字段合并
+	Background *Layer            `json:"background,omitempty"` // 没有背景层的片段使用的背景，如 {"type": "fill-color", "color": "white"}
+	Layer      *Layer            `json:"layer,omitempty"`      // 所有层的默认值，如 {"fontPath": "msyh.ttc"}
+	LayerType  map[string]*Layer `json:"layerType,omitempty"`  // 按层类型的默认值，如 {"title": {"position": "top"}}
 }
 
 // Clip 视频片段
 type Clip struct {
-	Layers []*Layer `json:"layers"`
+	Layers     []*Layer    `json:"layers"`
+	Duration   float64     `json:"duration,omitempty"`   // 片段时长（秒），为空时取视频层时长、语音时长或默认时长
+	Transition *Transition `json:"transition,omitempty"` // 到下一个片段的转场，为空时使用默认转场
+	Subtitles  string      `json:"subtitles,omitempty"`  // auto：按旁白文本自动生成字幕并烧录到画面，样式为 subtitle 层的样式
+	Narration  string      `json:"narration,omitempty"`  // 自动字幕的旁白文本，在语音时间内或整个片段中显示；为空时使用各 voice 层的文本
 }
 
 // Layer 视频层
@@ -33,17 +52,64 @@ type Layer struct {
 	Type string `json:"type"`
 	Path string `json:"path,omitempty"`
 	Text string `json:"text,omitempty"`
+
+	// 以下字段用于视频层
+	CutFrom     float64 `json:"cutFrom,omitempty"`     // 从源视频的第几秒开始
+	CutTo       float64 `json:"cutTo,omitempty"`       // 到源视频的第几秒结束，为空时到结尾
+	SpeedFactor float64 `json:"speedFactor,omitempty"` // 播放速度，2 为两倍速，为空时为 1
+	Loop        bool    `json:"loop,omitempty"`        // 视频短于片段时循环播放，否则定格在最后一帧
+
+	// 以下字段用于视频层和图片层
+	ResizeMode    string  `json:"resizeMode,omitempty"`    // 缩放模式：contain、contain-blur、cover、stretch，默认 contain
+	ZoomDirection string  `json:"zoomDirection,omitempty"` // 图片层的 Ken Burns 效果：in、out、left、right，为空时不移动
+	ZoomAmount    float64 `json:"zoomAmount,omitempty"`    // Ken Burns 的缩放比例，默认 0.1
+
+	// 以下字段用于叠加层（overlay-video、overlay-image）和文字层
+	Position *Position `json:"position,omitempty"` // 位置：预设名称如 bottom-left，或 {x, y} 比例坐标
+
+	// 以下字段用于叠加层和文字层
+	Start float64 `json:"start,omitempty"` // 在片段中开始显示的时间（秒）
+	Stop  float64 `json:"stop,omitempty"`  // 在片段中结束显示的时间（秒），为空时到片段结束
+
+	// 以下字段用于叠加层：overlay-video、overlay-image
+	Width        float64 `json:"width,omitempty"`        // 宽度，画布宽度的比例，为空时按高度等比缩放
+	Height       float64 `json:"height,omitempty"`       // 高度，画布高度的比例，为空时按宽度等比缩放
+	Opacity      float64 `json:"opacity,omitempty"`      // 不透明度 0~1，为空时不透明
+	CornerRadius int     `json:"cornerRadius,omitempty"` // 圆角半径（像素）
+	BorderWidth  int     `json:"borderWidth,omitempty"`  // 边框宽度（像素）
+	BorderColor  string  `json:"borderColor,omitempty"`  // 边框颜色，默认 white
+
+	// 以下字段用于渐变背景层：linear-gradient、radial-gradient
+	Colors []string `json:"colors,omitempty"` // 渐变的颜色，2~8 种
+
+	// 以下字段用于文字层：title、subtitle、news-title、lower-third、slide-in-text
+	FontPath        string `json:"fontPath,omitempty"`        // 字体文件，为空时使用 FontFamily 或自动查找支持中文的字体
+	FontFamily      string `json:"fontFamily,omitempty"`      // 字体名称，需要 FFmpeg 支持 fontconfig
+	FontSize        int    `json:"fontSize,omitempty"`        // 字号（像素），为空时按层类型和画布高度计算
+	Color           string `json:"color,omitempty"`           // 文字颜色，默认 white；也是 fill-color 层的填充颜色，默认 black
+	BackgroundColor string `json:"backgroundColor,omitempty"` // 文字背景框颜色，如 black@0.5
+	Align           string `json:"align,omitempty"`           // 每行的水平对齐：left、center、right，为空时与位置一致
+	Animation       string `json:"animation,omitempty"`       // 动画：fade、slide、none，为空时按层类型选择
+
+	// 以下字段用于 voice 层：用语音合成引擎朗读 Text，作为旁白从片段的第 Start 秒开始播放
+	Voice      string  `json:"voice,omitempty"`      // 语音名称，由语音合成引擎解释，为空时使用引擎的默认语音
+	SpeechRate float64 `json:"speechRate,omitempty"` // 语速倍数，为空时为 1
+
+	explicit explicitZero // JSON 中显式写为零值的字段，如 "loop": false，不会被默认值覆盖
 }
 
 // Editly 视频编辑器
 type Editly struct {
-	spec *EditSpec
+	spec       *EditSpec
+	prober     *mediaProber
+	outputArgs KwArgs // 覆盖编码预设的输出参数，如预览的关键帧间隔
 }
 
 // NewEditly 创建新的视频编辑器
 func NewEditly(spec *EditSpec) *Editly {
 	return &Editly{
-		spec: spec,
+		spec:   spec,
+		prober: &mediaProber{},
 	}
 }
 
@@ -55,9 +121,12 @@ func (e *Editly) Edit() error {
 		log.Printf("处理 %d 个片段", len(e.spec.Clips))
 	}
 
-	// 验证输入文件
-	if err := e.validateInputs(); err != nil {
-		return fmt.Errorf("输入验证失败: %w", err)
+	// 验证规范和输入文件
+	if e.spec.Verbose {
+		log.Println("验证输入...")
+	}
+	if errs := e.Validate(); len(errs) > 0 {
+		return fmt.Errorf("输入验证失败: %w", errs)
 	}
 
 	// 确保输出目录存在
@@ -66,61 +135,27 @@ func (e *Editly) Edit() error {
 		return fmt.Errorf("创建输出目录失败: %w", err)
 	}
 
-	// 构建FFmpeg命令
-	args := []string{"-y"} // 覆盖输出文件
-
-	// 为每个clip添加输入
-	for _, clip := range e.spec.Clips {
-		for _, layer := range clip.Layers {
-			if layer.Type == "video" && layer.Path != "" {
-				args = append(args, "-i", layer.Path)
-			}
+	startTime := time.Now()
+	if e.spec.Parallel > 1 {
+		// 分段并行渲染
+		if err := e.renderSegments(); err != nil {
+			return fmt.Errorf("视频编辑失败: %w", err)
 		}
-	}
-
-	// 构建过滤器链
-	var filterChain []string
-	inputIndex := 0
-
-	for _, clip := range e.spec.Clips {
-		for _, layer := range clip.Layers {
-			if layer.Type == "video" && layer.Path != "" {
-				// 为每个视频输入创建标签
-				filterChain = append(filterChain, fmt.Sprintf("[%d:v]scale=%d:%d[v%d]", 
-					inputIndex, e.spec.Width, e.spec.Height, inputIndex))
-				inputIndex++
-			} else if layer.Type == "image" && layer.Path != "" {
-				// 图片处理
-				filterChain = append(filterChain, fmt.Sprintf("[%d:v]scale=%d:%d[img%d]", 
-					inputIndex, e.spec.Width, e.spec.Height, inputIndex))
-				inputIndex++
-			}
+	} else {
+		// 构建FFmpeg命令
+		stream, err := e.Build()
+		if err != nil {
+			return fmt.Errorf("构建FFmpeg命令失败: %w", err)
 		}
-	}
-
-	// 添加过滤器参数
-	if len(filterChain) > 0 {
-		args = append(args, "-filter_complex", strings.Join(filterChain, ";"))
-	}
-
-	// 设置输出参数
-	args = append(args, 
-		"-c:v", "libx264",
-		"-preset", "ultrafast",
-		"-crf", "23",
-		"-r", fmt.Sprintf("%d", e.spec.Fps),
-		"-pix_fmt", "yuv420p",
-		e.spec.OutPath)
 
-	if e.spec.Verbose {
-		log.Printf("执行FFmpeg命令: ffmpeg %s", strings.Join(args, " "))
-	}
+		if e.spec.Verbose {
+			log.Printf("执行FFmpeg命令: ffmpeg %s", strings.Join(stream.GetArgs(), " "))
+		}
 
-	// 执行FFmpeg命令
-	startTime := time.Now()
-	err := Input("").Output(e.spec.OutPath, nil).OverWriteOutput().Run()
-	if err != nil {
-		return fmt.Errorf("视频编辑失败: %w", err)
+		// 执行FFmpeg命令
+		if err := stream.Run(); err != nil {
+			return fmt.Errorf("视频编辑失败: %w", err)
+		}
 	}
 
 	executionTime := time.Since(startTime)
@@ -131,36 +166,26 @@ func (e *Editly) Edit() error {
 	return nil
 }
 
-// validateInputs 验证输入文件
-func (e *Editly) validateInputs() error {
-	if e.spec.Verbose {
-		log.Println("验证输入文件...")
+// encodingPreset 返回规范使用的编码预设
+func (e *Editly) encodingPreset() (*EncodingPreset, error) {
+	name := e.spec.Encoding
+	if name == "" {
+		name = DefaultEditlyEncoding
 	}
-
-	for _, clip := range e.spec.Clips {
-		for _, layer := range clip.Layers {
-			if (layer.Type == "video" || layer.Type == "image") && layer.Path != "" {
-				if e.spec.Verbose {
-					log.Printf("验证层: %s", layer.Path)
-				}
-				
-				// 检查文件是否存在
-				if strings.HasPrefix(layer.Path, "http") {
-					// 对于网络文件，只做基本检查
-					if !strings.Contains(layer.Path, "://") {
-						return fmt.Errorf("无效的URL: %s", layer.Path)
-					}
-				} else {
-					// 对于本地文件，检查文件是否存在
-					if _, err := os.Stat(layer.Path); os.IsNotExist(err) {
-						return fmt.Errorf("文件不存在: %s", layer.Path)
-					}
-				}
-			}
-		}
+	preset, ok := GetEncodingPreset(name)
+	if !ok {
+		return nil, fmt.Errorf("未知的编码预设: %s", name)
 	}
+	return preset, nil
+}
 
-	return nil
+// encodingArgs 返回编码预设对应的输出参数
+func (e *Editly) encodingArgs() (KwArgs, error) {
+	preset, err := e.encodingPreset()
+	if err != nil {
+		return nil, err
+	}
+	return preset.OutputArgs()
 }
 
 // Edit 是一个便捷函数，直接编辑视频
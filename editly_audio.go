@@ -0,0 +1,192 @@
+package ffmpeg_go
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// AudioTrack 成片的音轨，如背景音乐或旁白
+type AudioTrack struct {
+	Path      string   `json:"path"`
+	MixVolume *float64 `json:"mixVolume,omitempty"` // 混音音量，1 为原始音量，为空时为 1
+	Start     float64  `json:"start,omitempty"`     // 在成片中开始的时间（秒）
+	CutFrom   float64  `json:"cutFrom,omitempty"`   // 从音频的第几秒开始
+	CutTo     float64  `json:"cutTo,omitempty"`     // 到音频的第几秒结束，为空时到结尾
+	FadeIn    float64  `json:"fadeIn,omitempty"`    // 淡入时长（秒）
+	FadeOut   float64  `json:"fadeOut,omitempty"`   // 淡出时长（秒）
+	Loop      bool     `json:"loop,omitempty"`      // 循环播放到成片结束
+	VoiceOver bool     `json:"voiceOver,omitempty"` // 旁白：不会被压低，并和原声一起作为压低背景音乐的依据
+}
+
+// UnmarshalJSON 兼容只写路径的旧格式，如 "audioTracks": ["music.mp3"]
+func (t *AudioTrack) UnmarshalJSON(data []byte) error {
+	var path string
+	if err := json.Unmarshal(data, &path); err == nil {
+		*t = AudioTrack{Path: path}
+		return nil
+	}
+	type plain AudioTrack
+	return json.Unmarshal(data, (*plain)(t))
+}
+
+// AudioNorm 成片音频的动态音量均衡（dynaudnorm）
+type AudioNorm struct {
+	Enable    bool    `json:"enable"`
+	GaussSize int     `json:"gaussSize,omitempty"` // 高斯窗口大小（帧），默认 5
+	MaxGain   float64 `json:"maxGain,omitempty"`   // 最大增益，默认 30
+}
+
+// AudioDucking 原声或旁白出现时压低背景音乐（sidechaincompress）
+type AudioDucking struct {
+	Threshold float64 `json:"threshold,omitempty"` // 触发压低的音量阈值（0~1），默认 0.05
+	Ratio     float64 `json:"ratio,omitempty"`     // 压缩比，默认 8
+	Attack    float64 `json:"attack,omitempty"`    // 起效时间（毫秒），默认 20
+	Release   float64 `json:"release,omitempty"`   // 恢复时间（毫秒），默认 400
+}
+
+// orDefault 值为 0 时返回默认值
+func orDefault(v, def float64) float64 {
+	if v == 0 {
+		return def
+	}
+	return v
+}
+
+// formatNumber 格式化滤镜的数值参数
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// volume 音量不为 1 时加入 volume 滤镜
+func volume(s *Stream, v float64) *Stream {
+	if v == 1 {
+		return s
+	}
+	return s.Filter("volume", Args{formatNumber(v)})
+}
+
+// mixAmix 将多条音频混合，只有一条时原样返回
+func mixAmix(streams []*Stream) *Stream {
+	if len(streams) == 1 {
+		return streams[0]
+	}
+	return Filter(streams, "amix", nil, KwArgs{"inputs": len(streams), "duration": "first", "normalize": "0"})
+}
+
+// renderAudioTrack 将音轨裁剪、循环、淡入淡出并放到成片的时间线上，补齐到成片时长
+func (e *Editly) renderAudioTrack(index int, track *AudioTrack, total float64) (*Stream, error) {
+	if track.Path == "" {
+		return nil, fmt.Errorf("缺少音频路径")
+	}
+	if track.CutTo > 0 && track.CutTo <= track.CutFrom {
+		return nil, fmt.Errorf("cutTo(%s) 必须大于 cutFrom(%s)", seconds(track.CutTo), seconds(track.CutFrom))
+	}
+	if track.Start < 0 || track.FadeIn < 0 || track.FadeOut < 0 {
+		return nil, fmt.Errorf("start、fadeIn、fadeOut 不能为负数")
+	}
+	if track.Start >= total {
+		return nil, fmt.Errorf("开始时间 %s 秒超过成片时长 %s 秒", seconds(track.Start), seconds(total))
+	}
+
+	args := KwArgs{}
+	if track.CutFrom > 0 {
+		args["ss"] = seconds(track.CutFrom)
+	}
+	// 音频在成片中播放的时长，不循环时还受素材长度限制
+	length := total - track.Start
+	segment := 0.0
+	if track.CutTo > 0 {
+		segment = track.CutTo - track.CutFrom
+		args["t"] = seconds(segment)
+	} else if track.Loop || track.FadeOut > 0 {
+		info, err := e.prober.get(track.Path)
+		if err != nil {
+			return nil, err
+		}
+		segment = info.Duration - track.CutFrom
+		if segment <= 0 {
+			return nil, fmt.Errorf("cutFrom(%s) 超过音频时长 %s 秒", seconds(track.CutFrom), seconds(info.Duration))
+		}
+	}
+	if segment > 0 && segment < length && !track.Loop {
+		length = segment
+	}
+
+	s := Input(track.Path, args).Audio().Filter(fmt.Sprintf("asetpts@t%d", index), Args{"PTS-STARTPTS"})
+	if track.Loop && segment < length {
+		samples := int(math.Ceil(segment * editlySampleRate))
+		s = s.Filter("aresample", Args{strconv.Itoa(editlySampleRate)}).
+			Filter("aloop", nil, KwArgs{"loop": "-1", "size": strconv.Itoa(samples)})
+	}
+	s = s.Filter("atrim", nil, KwArgs{"duration": seconds(length)})
+	if track.FadeIn > 0 {
+		s = s.Filter("afade", nil, KwArgs{"t": "in", "st": "0", "d": seconds(math.Min(track.FadeIn, length))})
+	}
+	if track.FadeOut > 0 {
+		fadeOut := math.Min(track.FadeOut, length)
+		s = s.Filter("afade", nil, KwArgs{"t": "out", "st": seconds(length - fadeOut), "d": seconds(fadeOut)})
+	}
+	mixVolume := 1.0
+	if track.MixVolume != nil {
+		mixVolume = *track.MixVolume
+	}
+	s = volume(s, mixVolume)
+	if track.Start > 0 {
+		s = s.Filter("adelay", nil, KwArgs{"delays": strconv.Itoa(int(math.Round(track.Start * 1000))), "all": "1"})
+	}
+	return normalizeAudio(s, total), nil
+}
+
+// mixAudio 混合片段原声和各音轨：设置了 Ducking 时背景音乐在原声或旁白出现时被压低，
+// 设置了 AudioNorm 时对成片音频做动态音量均衡。没有任何音频时返回 nil
+func (e *Editly) mixAudio(clipsAudio *Stream, total float64) (*Stream, error) {
+	var voices, music []*Stream
+	if clipsAudio != nil {
+		clipsVolume := 1.0
+		if e.spec.ClipsAudioVolume != nil {
+			clipsVolume = *e.spec.ClipsAudioVolume
+		}
+		voices = append(voices, volume(clipsAudio, clipsVolume))
+	}
+	for i, track := range e.spec.AudioTracks {
+		s, err := e.renderAudioTrack(i, track, total)
+		if err != nil {
+			return nil, fmt.Errorf("audioTracks[%d]: %w", i, err)
+		}
+		if track.VoiceOver {
+			voices = append(voices, s)
+		} else {
+			music = append(music, s)
+		}
+	}
+
+	var audio *Stream
+	switch {
+	case len(voices) == 0 && len(music) == 0:
+		return nil, nil
+	case e.spec.Ducking != nil && len(voices) > 0 && len(music) > 0:
+		// 原声和旁白既要作为压缩的侧链，也要参与最终混音
+		split := mixAmix(voices).ASplit()
+		d := e.spec.Ducking
+		ducked := Filter([]*Stream{mixAmix(music), split.Get("0")}, "sidechaincompress", nil, KwArgs{
+			"threshold": formatNumber(orDefault(d.Threshold, 0.05)),
+			"ratio":     formatNumber(orDefault(d.Ratio, 8)),
+			"attack":    formatNumber(orDefault(d.Attack, 20)),
+			"release":   formatNumber(orDefault(d.Release, 400)),
+		})
+		audio = mixAmix([]*Stream{split.Get("1"), ducked})
+	default:
+		audio = mixAmix(append(voices, music...))
+	}
+
+	if norm := e.spec.AudioNorm; norm != nil && norm.Enable {
+		gaussSize := norm.GaussSize
+		if gaussSize <= 0 {
+			gaussSize = 5
+		}
+		audio = audio.Filter("dynaudnorm", nil, KwArgs{"g": strconv.Itoa(gaussSize), "m": formatNumber(orDefault(norm.MaxGain, 30))})
+	}
+	return audio, nil
+}
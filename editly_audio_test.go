@@ -0,0 +1,75 @@
+package ffmpeg_go
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudioTrackUnmarshal(t *testing.T) {
+	var spec EditSpec
+	err := json.Unmarshal([]byte(`{"audioTracks": ["a.mp3", {"path": "b.mp3", "mixVolume": 0, "fadeIn": 1}]}`), &spec)
+	assert.Nil(t, err)
+	assert.Equal(t, "a.mp3", spec.AudioTracks[0].Path)
+	assert.Nil(t, spec.AudioTracks[0].MixVolume)
+	assert.Equal(t, "b.mp3", spec.AudioTracks[1].Path)
+	assert.Equal(t, 0.0, *spec.AudioTracks[1].MixVolume)
+	assert.Equal(t, 1.0, spec.AudioTracks[1].FadeIn)
+}
+
+func TestEditlyAudioMix(t *testing.T) {
+	half, quiet := 0.5, 0.3
+	spec := &EditSpec{
+		OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25, KeepSourceAudio: true,
+		ClipsAudioVolume: &half,
+		Clips: []*Clip{
+			{Duration: 6, Layers: []*Layer{{Type: "video", Path: "a.mp4"}}},
+			{Duration: 4, Layers: []*Layer{{Type: "image", Path: "b.png"}}},
+		},
+		AudioTracks: []*AudioTrack{
+			{Path: "music.mp3", MixVolume: &quiet, CutFrom: 10, FadeIn: 1, FadeOut: 2, Loop: true},
+			{Path: "voice.mp3", Start: 1.5, CutTo: 3, VoiceOver: true},
+		},
+		AudioNorm: &AudioNorm{Enable: true},
+		Ducking:   &AudioDucking{Ratio: 4},
+	}
+	stream, err := newTestEditly(spec, map[string]*mediaInfo{
+		"a.mp4":     {Duration: 20, HasVideo: true, HasAudio: true},
+		"music.mp3": {Duration: 14},
+	}).Build()
+	assert.Nil(t, err)
+	args := stream.GetArgs()
+	cmd := strings.Join(args, " ")
+	graph := args[indexOfArg(args, "-filter_complex")+1]
+
+	assert.Contains(t, cmd, "-ss 10 -i music.mp3")
+	assert.Contains(t, cmd, "-t 3 -i voice.mp3")
+	// 背景音乐：4 秒素材循环到 10 秒，淡入淡出后降低音量
+	assert.Contains(t, graph, "aloop=loop=-1:size=192000")
+	assert.Contains(t, graph, "afade=d=1:st=0:t=in")
+	assert.Contains(t, graph, "afade=d=2:st=8:t=out")
+	assert.Contains(t, graph, "volume=0.3")
+	// 旁白：从 1.5 秒开始，与原声混合后作为侧链
+	assert.Contains(t, graph, "adelay=all=1:delays=1500")
+	assert.Contains(t, graph, "volume=0.5")
+	assert.Contains(t, graph, "asplit=2")
+	assert.Contains(t, graph, "sidechaincompress=attack=20:ratio=4:release=400:threshold=0.05")
+	assert.Contains(t, graph, "dynaudnorm=g=5:m=30")
+	assert.Equal(t, 2, strings.Count(graph, "amix="))
+	assert.Contains(t, cmd, "-ac 2 -ar 48000")
+}
+
+func TestEditlyAudioTrackErrors(t *testing.T) {
+	build := func(track *AudioTrack) error {
+		spec := &EditSpec{OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25,
+			Clips:       []*Clip{{Duration: 2, Layers: []*Layer{{Type: "image", Path: "a.png"}}}},
+			AudioTracks: []*AudioTrack{track}}
+		_, err := newTestEditly(spec, nil).Build()
+		return err
+	}
+	assert.Nil(t, build(&AudioTrack{Path: "music.mp3"}))
+	assert.EqualError(t, build(&AudioTrack{Path: "music.mp3", Start: 3}), "audioTracks[0]: 开始时间 3 秒超过成片时长 2 秒")
+	assert.EqualError(t, build(&AudioTrack{Path: "music.mp3", CutFrom: 3, CutTo: 2}), "audioTracks[0]: cutTo(2) 必须大于 cutFrom(3)")
+}
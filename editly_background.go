@@ -0,0 +1,72 @@
+package ffmpeg_go
+
+import (
+	"fmt"
+	"strings"
+)
+
+// defaultGradientColors 渐变层未设置颜色时使用的颜色
+var defaultGradientColors = []string{"0x2a5298", "0x1e3c72"}
+
+// maxGradientColors gradients 滤镜最多支持的颜色数
+const maxGradientColors = 8
+
+// isBackgroundLayer 判断是否为铺满画布的背景层
+func isBackgroundLayer(layerType string) bool {
+	return layerType == "fill-color" || layerType == "linear-gradient" || layerType == "radial-gradient"
+}
+
+// hasBackground 判断片段是否有背景层
+func hasBackground(clip *Clip) bool {
+	for _, layer := range clip.Layers {
+		if isBackgroundLayer(layer.Type) {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultBackground 没有背景层的片段使用的背景
+func (e *Editly) defaultBackground() *Layer {
+	if e.spec.Defaults == nil {
+		return nil
+	}
+	return e.spec.Defaults.Background
+}
+
+// renderBackground 用 lavfi 源生成画布大小、片段时长的纯色或渐变背景
+func (e *Editly) renderBackground(id string, layer *Layer, duration float64) (*Stream, error) {
+	size := fmt.Sprintf("%dx%d", e.spec.Width, e.spec.Height)
+	var source string
+	switch layer.Type {
+	case "fill-color":
+		color := layer.Color
+		if color == "" {
+			color = "black"
+		}
+		source = fmt.Sprintf("color=c=%s:s=%s:r=%d", color, size, e.spec.Fps)
+	case "linear-gradient", "radial-gradient":
+		colors := layer.Colors
+		if len(colors) == 0 {
+			colors = defaultGradientColors
+		}
+		if len(colors) < 2 || len(colors) > maxGradientColors {
+			return nil, fmt.Errorf("渐变需要 2~%d 种颜色，实际为 %d", maxGradientColors, len(colors))
+		}
+		// 线性渐变从上到下，径向渐变从中心到角落；speed 不能为 0，取最小值 0.00001，渐变看起来是静止的
+		options := []string{fmt.Sprintf("gradients=s=%s:r=%d:speed=0.00001:nb_colors=%d", size, e.spec.Fps, len(colors))}
+		for i, color := range colors {
+			options = append(options, fmt.Sprintf("c%d=%s", i, color))
+		}
+		if layer.Type == "linear-gradient" {
+			options = append(options, "type=linear", "x0=0", "y0=0", "x1=0", fmt.Sprintf("y1=%d", e.spec.Height))
+		} else {
+			options = append(options, "type=radial", fmt.Sprintf("x0=%d", e.spec.Width/2), fmt.Sprintf("y0=%d", e.spec.Height/2), "x1=0", "y1=0")
+		}
+		source = strings.Join(options, ":")
+	default:
+		return nil, fmt.Errorf("不支持的背景层类型: %s", layer.Type)
+	}
+	input := Input(source, KwArgs{"f": "lavfi", "t": seconds(duration)})
+	return input.Video().Filter("setpts@"+id, Args{"PTS-STARTPTS"}), nil
+}
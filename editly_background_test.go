@@ -0,0 +1,41 @@
+package ffmpeg_go
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditlyBackgroundLayers(t *testing.T) {
+	spec := &EditSpec{
+		OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25,
+		Defaults: &Defaults{Duration: 2, Background: &Layer{Type: "fill-color", Color: "white"}},
+		Clips: []*Clip{
+			{Layers: []*Layer{{Type: "title", Text: "a", FontPath: "a.ttf"}}},
+			{Layers: []*Layer{{Type: "linear-gradient", Colors: []string{"red", "blue"}}, {Type: "title", Text: "b", FontPath: "a.ttf"}}},
+			{Layers: []*Layer{{Type: "radial-gradient"}}},
+		},
+	}
+	stream, err := newTestEditly(spec, nil).Build()
+	assert.Nil(t, err)
+	args := stream.GetArgs()
+	cmd := strings.Join(args, " ")
+	graph := args[indexOfArg(args, "-filter_complex")+1]
+
+	// 第一个片段没有背景层，使用默认背景
+	assert.Contains(t, cmd, "-f lavfi -t 2 -i color=c=white:s=640x360:r=25")
+	assert.Contains(t, cmd, "-f lavfi -t 2 -i gradients=s=640x360:r=25:speed=0.00001:nb_colors=2:c0=red:c1=blue:type=linear:x0=0:y0=0:x1=0:y1=360")
+	assert.Contains(t, cmd, "gradients=s=640x360:r=25:speed=0.00001:nb_colors=2:c0=0x2a5298:c1=0x1e3c72:type=radial:x0=320:y0=180:x1=0:y1=0")
+	// gradients 的 speed 最小为 0.00001，为 0 时 ffmpeg 无法创建源
+	assert.NotContains(t, cmd, ":speed=0:")
+	assert.Contains(t, graph, "setpts@c0bg=PTS-STARTPTS")
+	assert.Contains(t, graph, "setpts@c1l0=PTS-STARTPTS")
+	// 背景层作为画布，不需要叠加
+	assert.NotContains(t, cmd, "color=c=black")
+	assert.NotContains(t, graph, "overlay=")
+
+	spec.Clips[2].Layers[0].Colors = []string{"red"}
+	_, err = newTestEditly(spec, nil).Build()
+	assert.EqualError(t, err, "clips[2].layers[0]: 渐变需要 2~8 种颜色，实际为 1")
+}
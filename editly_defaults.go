@@ -0,0 +1,233 @@
+package ffmpeg_go
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// explicitZero JSON 中显式写为零值的字段，按 Go 字段名索引。零值通常表示未设置，
+// 显式写出的零值如 "loop": false、"cutFrom": 0 表示覆盖默认值
+type explicitZero map[string]bool
+
+// explicitZeroFields 找出 JSON 对象中显式写为零值的字段，v 为已经解码的结构体指针，null 仍表示未设置
+func explicitZeroFields(data []byte, v interface{}) (explicitZero, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	var explicit explicitZero
+	rv := reflect.ValueOf(v).Elem()
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		raw, ok := fields[name]
+		if field.PkgPath != "" || !ok || string(raw) == "null" || !rv.Field(i).IsZero() {
+			continue
+		}
+		if explicit == nil {
+			explicit = explicitZero{}
+		}
+		explicit[field.Name] = true
+	}
+	return explicit, nil
+}
+
+// withExplicitZeros 在编码结果中补上显式写为零值的字段，v 为结构体指针
+func withExplicitZeros(data []byte, v interface{}, explicit explicitZero) ([]byte, error) {
+	if len(explicit) == 0 {
+		return data, nil
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	rv := reflect.ValueOf(v).Elem()
+	for name := range explicit {
+		field, ok := rv.Type().FieldByName(name)
+		if !ok {
+			continue
+		}
+		zero, err := json.Marshal(rv.FieldByName(name).Interface())
+		if err != nil {
+			return nil, err
+		}
+		fields[strings.Split(field.Tag.Get("json"), ",")[0]] = zero
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON 记录显式写为零值的字段
+func (l *Layer) UnmarshalJSON(data []byte) error {
+	type plain Layer
+	if err := json.Unmarshal(data, (*plain)(l)); err != nil {
+		return err
+	}
+	explicit, err := explicitZeroFields(data, l)
+	l.explicit = explicit
+	return err
+}
+
+// MarshalJSON 保留显式写为零值的字段，编码后再解码结果不变
+func (l Layer) MarshalJSON() ([]byte, error) {
+	type plain Layer
+	data, err := json.Marshal(plain(l))
+	if err != nil {
+		return nil, err
+	}
+	return withExplicitZeros(data, &l, l.explicit)
+}
+
+// explicitZeros 返回记录显式零值的字段，Layer 和 Transition 实现
+type explicitZeros interface {
+	explicitZeros() *explicitZero
+}
+
+func (l *Layer) explicitZeros() *explicitZero      { return &l.explicit }
+func (t *Transition) explicitZeros() *explicitZero { return &t.explicit }
+
+// mergeDefaults 用 src 中的值填充 dst 中未设置的字段，dst 和 src 为同类型的结构体指针。
+// 零值表示未设置，显式写为零值的字段（见 explicitZero）也算已设置，src 中显式的零值同样会保留到 dst
+func mergeDefaults(dst, src interface{}) {
+	sv := reflect.ValueOf(src)
+	if !sv.IsValid() || sv.IsNil() {
+		return
+	}
+	var dstExplicit *explicitZero
+	var srcExplicit explicitZero
+	if e, ok := dst.(explicitZeros); ok {
+		dstExplicit = e.explicitZeros()
+		srcExplicit = *src.(explicitZeros).explicitZeros()
+	}
+	copied := false
+	dv, sv := reflect.ValueOf(dst).Elem(), sv.Elem()
+	for i := 0; i < dv.NumField(); i++ {
+		field := dv.Type().Field(i)
+		if field.PkgPath != "" || !dv.Field(i).IsZero() || dstExplicit != nil && (*dstExplicit)[field.Name] {
+			continue
+		}
+		if !srcExplicit[field.Name] {
+			dv.Field(i).Set(sv.Field(i))
+			continue
+		}
+		// dst 可能是层的副本，与原来的层共用同一个 map，复制后再修改
+		if !copied {
+			merged := explicitZero{}
+			for name := range *dstExplicit {
+				merged[name] = true
+			}
+			*dstExplicit, copied = merged, true
+		}
+		(*dstExplicit)[field.Name] = true
+	}
+}
+
+// builtinLayerDefaults 各层类型内置的默认值，优先级最低
+func (e *Editly) builtinLayerDefaults(layerType string) *Layer {
+	if style, ok := textLayerStyles[layerType]; ok {
+		return &Layer{
+			FontSize:        int(math.Round(float64(e.spec.Height) * style.fontSize)),
+			Color:           "white",
+			BackgroundColor: style.background,
+			Position:        &Position{Preset: style.position},
+			Animation:       style.animation,
+		}
+	}
+	switch layerType {
+	case "video", "image", "overlay-video", "overlay-image":
+		return &Layer{ResizeMode: "contain"}
+	case "fill-color":
+		return &Layer{Color: "black"}
+	case "linear-gradient", "radial-gradient":
+		return &Layer{Colors: defaultGradientColors}
+	}
+	return nil
+}
+
+// resolveLayer 按优先级合并层的默认值：层自身的值、defaults.layerType 中对应类型的值、defaults.layer、内置默认值
+func (e *Editly) resolveLayer(layer *Layer) *Layer {
+	resolved := *layer
+	if defaults := e.spec.Defaults; defaults != nil {
+		if typeDefaults, ok := defaults.LayerType[layer.Type]; ok {
+			mergeDefaults(&resolved, typeDefaults)
+		}
+		mergeDefaults(&resolved, defaults.Layer)
+	}
+	mergeDefaults(&resolved, e.builtinLayerDefaults(layer.Type))
+	// 层类型不能被默认值改变
+	resolved.Type = layer.Type
+	return &resolved
+}
+
+// resolveTransition 按字段合并片段和默认转场，直接切换和最后一个片段返回 nil
+func (e *Editly) resolveTransition(index int, clip *Clip) *Transition {
+	if index == len(e.spec.Clips)-1 {
+		return nil
+	}
+	t := &Transition{}
+	if clip.Transition != nil {
+		*t = *clip.Transition
+	}
+	if e.spec.Defaults != nil {
+		mergeDefaults(t, e.spec.Defaults.Transition)
+	}
+	if t.Duration == 0 || t.Name == "none" {
+		return nil
+	}
+	mergeDefaults(t, &Transition{Name: "fade", Easing: "linear"})
+	return t
+}
+
+// Resolve 返回合并了所有默认值的规范：每个层都填充了默认值，每个片段都有确定的时长和转场，
+// voice 层合成语音后转换为旁白音轨，自动字幕转换为带显示时间的 subtitle 层。返回的规范与原规范渲染结果相同，只保留 defaults.background，可以用来查看实际渲染的参数
+func (e *Editly) Resolve() (*EditSpec, error) {
+	spec := *e.spec
+	spec.Defaults = nil
+	if background := e.defaultBackground(); background != nil {
+		spec.Defaults = &Defaults{Background: e.resolveLayer(background)}
+	}
+
+	spec.Clips = make([]*Clip, len(e.spec.Clips))
+	spec.AudioTracks = append([]*AudioTrack(nil), e.spec.AudioTracks...)
+	start := 0.0
+	for i, clip := range e.spec.Clips {
+		resolved := *clip
+		resolved.Layers = make([]*Layer, len(clip.Layers))
+		for j, layer := range clip.Layers {
+			resolved.Layers[j] = e.resolveLayer(layer)
+		}
+		duration, err := e.clipDuration(&resolved)
+		if err != nil {
+			return nil, fmt.Errorf("clips[%d]: %w", i, err)
+		}
+		resolved.Duration = duration
+
+		resolved.Transition = e.resolveTransition(i, clip)
+
+		// 自动字幕需要 voice 层的语音时长，在 voice 层转换为音轨之前生成
+		subtitleLayers, err := e.autoSubtitles(&resolved)
+		if err != nil {
+			return nil, fmt.Errorf("clips[%d].subtitles: %w", i, err)
+		}
+		resolved.Subtitles = ""
+		voices, layers, err := e.voiceTracks(&resolved, start)
+		if err != nil {
+			return nil, fmt.Errorf("clips[%d].%w", i, err)
+		}
+		resolved.Layers = append(layers, subtitleLayers...)
+		spec.AudioTracks = append(spec.AudioTracks, voices...)
+		start += duration
+		if resolved.Transition != nil {
+			start -= resolved.Transition.Duration
+		}
+		spec.Clips[i] = &resolved
+	}
+	return &spec, nil
+}
+
+// ResolveSpec 返回合并了所有默认值的规范，见 Editly.Resolve
+func ResolveSpec(spec *EditSpec) (*EditSpec, error) {
+	return NewEditly(spec).Resolve()
+}
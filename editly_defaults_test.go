@@ -0,0 +1,109 @@
+package ffmpeg_go
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditlyResolve(t *testing.T) {
+	var spec EditSpec
+	err := json.Unmarshal([]byte(`{
+		"outPath": "out.mp4", "width": 640, "height": 360, "fps": 25,
+		"defaults": {
+			"duration": 3,
+			"transition": {"name": "dissolve", "duration": 0.5},
+			"background": {"type": "linear-gradient"},
+			"layer": {"fontPath": "msyh.ttc", "color": "yellow"},
+			"layerType": {"title": {"position": "top", "fontSize": 48}}
+		},
+		"clips": [
+			{"layers": [{"type": "title", "text": "a"}, {"type": "subtitle", "text": "b", "color": "red"}]},
+			{"transition": {"duration": 1}, "layers": [{"type": "video", "path": "a.mp4", "cutTo": 4}]},
+			{"transition": {"name": "none"}, "layers": [{"type": "fill-color"}]},
+			{"layers": [{"type": "image", "path": "b.png"}]}
+		]
+	}`), &spec)
+	assert.Nil(t, err)
+
+	resolved, err := newTestEditly(&spec, nil).Resolve()
+	assert.Nil(t, err)
+	// 默认背景也填充了内置默认值，其余默认值已合并到片段和层中
+	assert.Equal(t, &Defaults{Background: &Layer{Type: "linear-gradient", FontPath: "msyh.ttc", Color: "yellow",
+		Colors: defaultGradientColors}}, resolved.Defaults)
+
+	title := resolved.Clips[0].Layers[0]
+	assert.Equal(t, &Position{Preset: "top"}, title.Position)
+	assert.Equal(t, 48, title.FontSize)
+	assert.Equal(t, "msyh.ttc", title.FontPath)
+	assert.Equal(t, "yellow", title.Color)
+	assert.Equal(t, "fade", title.Animation)
+	subtitle := resolved.Clips[0].Layers[1]
+	assert.Equal(t, "red", subtitle.Color)
+	assert.Equal(t, 18, subtitle.FontSize)
+	assert.Equal(t, "black@0.5", subtitle.BackgroundColor)
+	assert.Equal(t, "contain", resolved.Clips[1].Layers[0].ResizeMode)
+	assert.Equal(t, "yellow", resolved.Clips[2].Layers[0].Color)
+
+	var durations []float64
+	var transitions []*Transition
+	for _, clip := range resolved.Clips {
+		durations = append(durations, clip.Duration)
+		transitions = append(transitions, clip.Transition)
+	}
+	assert.Equal(t, []float64{3, 4, 3, 3}, durations)
+	assert.Equal(t, []*Transition{
+		{Name: "dissolve", Duration: 0.5, Easing: "linear"},
+		{Name: "dissolve", Duration: 1, Easing: "linear"},
+		nil,
+		nil,
+	}, transitions)
+
+	// 原规范不会被修改，合并后的规范渲染结果相同
+	assert.Equal(t, "", spec.Clips[0].Layers[0].Color)
+	assert.Equal(t, "", spec.Clips[1].Transition.Name)
+	original, err := newTestEditly(&spec, nil).Build()
+	assert.Nil(t, err)
+	again, err := newTestEditly(resolved, nil).Build()
+	assert.Nil(t, err)
+	assert.Equal(t, original.GetArgs(), again.GetArgs())
+}
+
+func TestEditlyResolveExplicitZero(t *testing.T) {
+	spec, err := ParseEditSpec([]byte(`{
+		"outPath": "out.mp4", "width": 640, "height": 360, "fps": 25,
+		"defaults": {
+			"transition": {"name": "dissolve", "duration": 0.5},
+			"layer": {"loop": true, "cutFrom": 2},
+			"layerType": {"video": {"cutFrom": 0}}
+		},
+		"clips": [
+			{"duration": 3, "layers": [{"type": "video", "path": "a.mp4", "loop": false}], "transition": {"duration": 0}},
+			{"duration": 3, "layers": [{"type": "video", "path": "a.mp4"}, {"type": "image", "path": "b.png"}]},
+			{"duration": 3, "layers": [{"type": "fill-color"}]}
+		]
+	}`))
+	assert.Nil(t, err)
+
+	// 显式写出的 false 和 0 覆盖默认值
+	resolved, err := newTestEditly(spec, nil).Resolve()
+	assert.Nil(t, err)
+	assert.False(t, resolved.Clips[0].Layers[0].Loop)
+	assert.Nil(t, resolved.Clips[0].Transition)
+	assert.True(t, resolved.Clips[1].Layers[0].Loop)
+	assert.Equal(t, 0.0, resolved.Clips[1].Layers[0].CutFrom)
+	assert.Equal(t, 2.0, resolved.Clips[1].Layers[1].CutFrom)
+	assert.Equal(t, 0.5, resolved.Clips[1].Transition.Duration)
+	assert.Nil(t, spec.Clips[1].Layers[0].explicit)
+
+	// 编码后再解码，显式的零值仍然保留
+	data, err := json.Marshal(resolved)
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"loop":false`)
+	var decoded EditSpec
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	again, err := newTestEditly(&decoded, nil).Resolve()
+	assert.Nil(t, err)
+	assert.False(t, again.Clips[0].Layers[0].Loop)
+}
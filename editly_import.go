@@ -0,0 +1,607 @@
+package ffmpeg_go
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ImportWarning 导入 editly 规范时无法转换、被忽略或近似处理的功能
+type ImportWarning struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (w *ImportWarning) String() string {
+	if w.Path == "" {
+		return w.Message
+	}
+	return w.Path + ": " + w.Message
+}
+
+// editlyTransitionNames editly（gl-transitions）转场名称对应的 xfade 转场，
+// 名称小写后与 xfade 相同的不需要列出
+var editlyTransitionNames = map[string]string{
+	"directional-left":  "slideleft",
+	"directional-right": "slideright",
+	"directional-up":    "slideup",
+	"directional-down":  "slidedown",
+	"directionalwipe":   "wipeleft",
+	"fadecolor":         "fadeblack",
+	"fadegrayscale":     "fadegrays",
+	"crosszoom":         "zoomin",
+	"squeeze":           "squeezev",
+}
+
+// editlyCurveEasings acrossfade 曲线对应的缓动名称
+var editlyCurveEasings = map[string]string{
+	"tri":  "linear",
+	"exp":  "easeIn",
+	"log":  "easeOut",
+	"hsin": "easeInOut",
+}
+
+// editlyObject editly 规范中的一个对象，记录读取过的字段，没有读取的字段作为警告报告
+type editlyObject struct {
+	im     *editlyImporter
+	path   string
+	values map[string]interface{}
+	used   map[string]bool
+}
+
+// editlyImporter 把 editly 规范转换为 EditSpec
+type editlyImporter struct {
+	warnings []*ImportWarning
+	// audioLayers 片段中的音频层，需要在所有片段转换后才能确定在成片中的开始时间
+	audioLayers []*editlyAudioLayer
+}
+
+// editlyAudioLayer 片段中的 audio 或 detached-audio 层
+type editlyAudioLayer struct {
+	path     string
+	clip     int
+	track    *AudioTrack
+	detached bool
+}
+
+func (im *editlyImporter) warn(path, format string, args ...interface{}) {
+	im.warnings = append(im.warnings, &ImportWarning{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// object 把解析出的值作为对象读取，不是对象时报告警告并返回 nil
+func (im *editlyImporter) object(path string, value interface{}) *editlyObject {
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		im.warn(path, "应为对象，已忽略")
+		return nil
+	}
+	return &editlyObject{im: im, path: path, values: values, used: map[string]bool{}}
+}
+
+func (o *editlyObject) field(key string) string {
+	return joinPath(o.path, key)
+}
+
+// has 字段存在且不为 null
+func (o *editlyObject) has(key string) bool {
+	return o.values[key] != nil
+}
+
+// raw 读取字段的原始值
+func (o *editlyObject) raw(key string) (interface{}, bool) {
+	o.used[key] = true
+	value, ok := o.values[key]
+	return value, ok && value != nil
+}
+
+func (o *editlyObject) number(key string) (float64, bool) {
+	value, ok := o.raw(key)
+	if !ok {
+		return 0, false
+	}
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		// editly 的音量等字段也接受字符串
+		if n, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+			return n, true
+		}
+	}
+	o.im.warn(o.field(key), "应为数字，已忽略")
+	return 0, false
+}
+
+func (o *editlyObject) integer(key string) (int, bool) {
+	n, ok := o.number(key)
+	if ok && n != float64(int(n)) {
+		o.im.warn(o.field(key), "应为整数，已取整为 %d", int(n))
+	}
+	return int(n), ok
+}
+
+func (o *editlyObject) string(key string) string {
+	value, ok := o.raw(key)
+	if !ok {
+		return ""
+	}
+	s, ok := value.(string)
+	if !ok {
+		o.im.warn(o.field(key), "应为字符串，已忽略")
+	}
+	return s
+}
+
+func (o *editlyObject) boolean(key string) bool {
+	value, ok := o.raw(key)
+	if !ok {
+		return false
+	}
+	b, ok := value.(bool)
+	if !ok {
+		o.im.warn(o.field(key), "应为布尔值，已忽略")
+	}
+	return b
+}
+
+func (o *editlyObject) array(key string) []interface{} {
+	value, ok := o.raw(key)
+	if !ok {
+		return nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		o.im.warn(o.field(key), "应为数组，已忽略")
+	}
+	return items
+}
+
+// unsupported 字段存在时报告不支持的原因
+func (o *editlyObject) unsupported(key, reason string) {
+	if _, ok := o.raw(key); ok {
+		o.im.warn(o.field(key), "%s，已忽略", reason)
+	}
+}
+
+// finish 报告没有读取过的字段
+func (o *editlyObject) finish() {
+	var keys []string
+	for key := range o.values {
+		if !o.used[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		o.im.warn(o.field(key), "不支持的字段，已忽略")
+	}
+}
+
+// ImportEditlySpec 导入原版 editly（Node.js）的 JSON5 规范。
+// editly 的片段、层和音频字段映射到 EditSpec，无法转换或近似处理的功能按路径作为警告返回
+func ImportEditlySpec(data []byte) (*EditSpec, []*ImportWarning, error) {
+	value, err := parseJSON5(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	im := &editlyImporter{}
+	root := im.object("", value)
+	if root == nil {
+		return nil, nil, fmt.Errorf("editly 规范应为对象")
+	}
+	spec := im.spec(root)
+	im.placeAudioLayers(spec)
+	return spec, im.warnings, nil
+}
+
+func (im *editlyImporter) spec(o *editlyObject) *EditSpec {
+	spec := &EditSpec{OutPath: o.string("outPath")}
+	spec.Width, _ = o.integer("width")
+	spec.Height, _ = o.integer("height")
+	spec.Fps, _ = o.integer("fps")
+	// editly 未设置尺寸和帧率时参考第一个视频，这里使用固定的值
+	if spec.Width == 0 && spec.Height == 0 {
+		spec.Width, spec.Height = 640, 360
+		im.warn("width", "未设置尺寸，editly 会按第一个视频推断，这里使用 640x360")
+	} else if spec.Width == 0 || spec.Height == 0 {
+		im.warn("", "只设置了 width 或 height，editly 会按第一个视频的宽高比推断另一个，这里需要同时设置")
+	}
+	if spec.Fps == 0 {
+		spec.Fps = 25
+	}
+
+	if value, ok := o.raw("defaults"); ok {
+		if defaults := im.object("defaults", value); defaults != nil {
+			spec.Defaults = im.defaults(defaults)
+		}
+	}
+	for i, value := range o.array("clips") {
+		path := fmt.Sprintf("clips[%d]", i)
+		if clip := im.object(path, value); clip != nil {
+			spec.Clips = append(spec.Clips, im.clip(clip, len(spec.Clips)))
+		}
+	}
+	if spec.Defaults == nil || spec.Defaults.Transition == nil {
+		// editly 的默认转场为 0.5 秒的 random
+		if spec.Defaults == nil {
+			spec.Defaults = &Defaults{}
+		}
+		spec.Defaults.Transition = &Transition{Name: "fade", Duration: 0.5}
+		if len(spec.Clips) > 1 {
+			im.warn("defaults.transition", "未设置默认转场，editly 使用随机转场，这里使用 fade")
+		}
+	}
+
+	if path := o.string("audioFilePath"); path != "" {
+		track := &AudioTrack{Path: path, Loop: o.boolean("loopAudio")}
+		if volume, ok := o.number("backgroundAudioVolume"); ok {
+			track.MixVolume = &volume
+		}
+		spec.AudioTracks = append(spec.AudioTracks, track)
+	} else {
+		o.unsupported("loopAudio", "没有设置 audioFilePath")
+		o.unsupported("backgroundAudioVolume", "没有设置 audioFilePath")
+	}
+	for i, value := range o.array("audioTracks") {
+		if track := im.object(fmt.Sprintf("audioTracks[%d]", i), value); track != nil {
+			spec.AudioTracks = append(spec.AudioTracks, im.audioTrack(track))
+		}
+	}
+	spec.KeepSourceAudio = o.boolean("keepSourceAudio")
+	if volume, ok := o.number("clipsAudioVolume"); ok {
+		spec.ClipsAudioVolume = &volume
+	}
+	if value, ok := o.raw("audioNorm"); ok {
+		if norm := im.object("audioNorm", value); norm != nil {
+			spec.AudioNorm = &AudioNorm{Enable: norm.boolean("enable")}
+			spec.AudioNorm.GaussSize, _ = norm.integer("gaussSize")
+			spec.AudioNorm.MaxGain, _ = norm.number("maxGain")
+			norm.finish()
+		}
+	}
+	spec.Verbose = o.boolean("verbose")
+
+	o.unsupported("outputVolume", "不支持调整成片音量，请使用 clipsAudioVolume 和音轨的 mixVolume")
+	o.unsupported("customOutputArgs", "不支持自定义 FFmpeg 参数，请使用 encoding 编码预设")
+	o.unsupported("fast", "不支持快速模式，请使用预览渲染")
+	o.unsupported("enableFfmpegLog", "请使用 verbose")
+	o.unsupported("allowRemoteRequests", "远程文件总是允许")
+	o.unsupported("keepTmp", "不使用临时文件")
+	o.finish()
+	return spec
+}
+
+func (im *editlyImporter) defaults(o *editlyObject) *Defaults {
+	defaults := &Defaults{}
+	defaults.Duration, _ = o.number("duration")
+	if value, ok := o.raw("transition"); ok {
+		if transition := im.object(o.field("transition"), value); transition != nil {
+			defaults.Transition = im.transition(transition)
+		}
+	} else if _, present := o.values["transition"]; present {
+		// null 表示默认直接切换
+		defaults.Transition = &Transition{Name: "none"}
+	}
+	if value, ok := o.raw("layer"); ok {
+		if layer := im.object(o.field("layer"), value); layer != nil {
+			defaults.Layer = im.layerFields(layer, &Layer{})
+			layer.finish()
+		}
+	}
+	if value, ok := o.raw("layerType"); ok {
+		if types := im.object(o.field("layerType"), value); types != nil {
+			defaults.LayerType = map[string]*Layer{}
+			var names []string
+			for name := range types.values {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				types.used[name] = true
+				layer := im.object(types.field(name), types.values[name])
+				if layer == nil {
+					continue
+				}
+				if name == "title-background" {
+					im.warn(layer.path, "title-background 会拆分为背景层和标题层，不能设置默认值，已忽略")
+					continue
+				}
+				layer.values["type"] = name
+				// 音频层和不支持的层返回空
+				for _, converted := range im.layer(layer, -1) {
+					converted.Type = ""
+					defaults.LayerType[editlyLayerType(name)] = converted
+				}
+			}
+			if len(defaults.LayerType) == 0 {
+				defaults.LayerType = nil
+			}
+		}
+	}
+	o.finish()
+	return defaults
+}
+
+// editlyLayerType editly 层类型对应的层类型
+func editlyLayerType(name string) string {
+	switch name {
+	case "image-overlay":
+		return "overlay-image"
+	case "pause":
+		return "fill-color"
+	}
+	return name
+}
+
+func (im *editlyImporter) transition(o *editlyObject) *Transition {
+	t := &Transition{}
+	t.Duration, _ = o.number("duration")
+	if name := o.string("name"); name != "" {
+		switch mapped, ok := editlyTransitionNames[strings.ToLower(name)]; {
+		case ok:
+			t.Name = mapped
+			im.warn(o.field("name"), "转场 %s 近似为 %s", name, mapped)
+		case xfadeTransitions[strings.ToLower(name)]:
+			t.Name = strings.ToLower(name)
+		default:
+			t.Name = "fade"
+			im.warn(o.field("name"), "不支持的转场 %s，改用 fade", name)
+		}
+	}
+
+	out, in := o.string("audioOutCurve"), o.string("audioInCurve")
+	if out == "" {
+		out = in
+	}
+	if out != "" {
+		if easing, ok := editlyCurveEasings[out]; !ok {
+			im.warn(o.field("audioOutCurve"), "不支持的音频曲线 %s，改用 tri", out)
+		} else if !easedTransition(t.Name, easing) {
+			im.warn(o.field("audioOutCurve"), "转场 %s 不支持缓动，音频曲线 %s 改用 tri", t.Name, out)
+		} else {
+			t.Easing = easing
+		}
+		if in != "" && in != out {
+			im.warn(o.field("audioInCurve"), "淡入和淡出只能使用同一条曲线，已使用 %s", out)
+		}
+	}
+	if easing := o.string("easing"); easing != "" && easing != "linear" {
+		im.warn(o.field("easing"), "不支持视频转场的缓动 %s，已忽略", easing)
+	}
+	o.unsupported("params", "不支持 gl-transitions 的参数")
+	o.finish()
+	return t
+}
+
+func (im *editlyImporter) clip(o *editlyObject, index int) *Clip {
+	clip := &Clip{}
+	clip.Duration, _ = o.number("duration")
+	if value, ok := o.raw("transition"); ok {
+		if transition := im.object(o.field("transition"), value); transition != nil {
+			clip.Transition = im.transition(transition)
+		}
+	} else if _, present := o.values["transition"]; present {
+		// editly 中 transition: null 表示直接切换
+		clip.Transition = &Transition{Name: "none"}
+	}
+	for j, value := range o.array("layers") {
+		if layer := im.object(fmt.Sprintf("%s.layers[%d]", o.path, j), value); layer != nil {
+			clip.Layers = append(clip.Layers, im.layer(layer, index)...)
+		}
+	}
+	o.finish()
+	return clip
+}
+
+// layerFields 转换各层类型共用的字段
+func (im *editlyImporter) layerFields(o *editlyObject, layer *Layer) *Layer {
+	layer.Path = o.string("path")
+	layer.Text = o.string("text")
+	layer.FontPath = o.string("fontPath")
+	layer.FontFamily = o.string("fontFamily")
+	if size, ok := o.number("fontSize"); ok {
+		if size < 1 {
+			im.warn(o.field("fontSize"), "字号为比例，请改为像素，已忽略")
+		} else {
+			layer.FontSize = int(math.Round(size))
+		}
+	}
+	// editly 的文字层用 textColor，纯色层用 color
+	layer.Color = o.string("textColor")
+	if color := o.string("color"); color != "" {
+		layer.Color = color
+	}
+	layer.BackgroundColor = o.string("backgroundColor")
+	layer.CutFrom, _ = o.number("cutFrom")
+	layer.CutTo, _ = o.number("cutTo")
+	layer.ResizeMode = o.string("resizeMode")
+	layer.ZoomDirection = o.string("zoomDirection")
+	layer.ZoomAmount, _ = o.number("zoomAmount")
+	layer.Width, _ = o.number("width")
+	layer.Height, _ = o.number("height")
+	layer.Start, _ = o.number("start")
+	layer.Stop, _ = o.number("stop")
+	if value, ok := o.raw("position"); ok {
+		var position Position
+		if data, err := json.Marshal(value); err == nil && json.Unmarshal(data, &position) == nil {
+			layer.Position = &position
+		} else {
+			im.warn(o.field("position"), "无效的位置，已忽略")
+		}
+	}
+	for _, color := range o.array("colors") {
+		if s, ok := color.(string); ok {
+			layer.Colors = append(layer.Colors, s)
+		} else {
+			im.warn(o.field("colors"), "颜色应为字符串，已忽略")
+		}
+	}
+	o.unsupported("mixVolume", "不支持单个视频层的音量，请使用 clipsAudioVolume")
+	return layer
+}
+
+// layer 转换一个层，title-background 转换为背景层和标题层，音频层和不支持的层返回空。
+// clip 为层所在片段的序号，-1 表示默认值中的层
+func (im *editlyImporter) layer(o *editlyObject, clip int) []*Layer {
+	layerType := o.string("type")
+	switch layerType {
+	case "audio", "detached-audio":
+		if clip < 0 {
+			im.warn(o.path, "音频层不能设置默认值，已忽略")
+		} else {
+			im.audioLayer(o, clip, layerType == "detached-audio")
+		}
+		return nil
+	case "title-background":
+		return im.titleBackground(o)
+	case "video", "image", "image-overlay", "title", "subtitle", "news-title", "slide-in-text",
+		"fill-color", "pause", "linear-gradient", "radial-gradient":
+	case "":
+		im.warn(o.path, "缺少层类型，已忽略")
+		return nil
+	default:
+		im.warn(o.path, "不支持的层类型 %s，已忽略", layerType)
+		return nil
+	}
+
+	layer := im.layerFields(o, &Layer{Type: editlyLayerType(layerType)})
+	switch layerType {
+	case "video":
+		// 指定了位置或尺寸的视频层是画中画
+		if o.has("left") || o.has("top") || layer.Width > 0 || layer.Height > 0 {
+			layer.Type = "overlay-video"
+			left, _ := o.number("left")
+			top, _ := o.number("top")
+			layer.Position = &Position{X: left, Y: top, OriginX: o.string("originX"), OriginY: o.string("originY")}
+		}
+	case "image-overlay", "title":
+		if layer.ZoomDirection != "" || layer.ZoomAmount > 0 {
+			im.warn(o.path, "%s 层不支持缩放动画，已忽略 zoomDirection 和 zoomAmount", layerType)
+			layer.ZoomDirection, layer.ZoomAmount = "", 0
+		}
+	case "subtitle":
+		o.unsupported("delay", "不支持字幕的延迟显示")
+		o.unsupported("speed", "不支持字幕的动画速度")
+	case "slide-in-text":
+		o.unsupported("charSpacing", "不支持字间距")
+	}
+	if layerType == "image" {
+		// editly 的图片默认缓慢放大，zoomDirection 为 null 时不移动
+		if _, present := o.values["zoomDirection"]; !present {
+			layer.ZoomDirection = "in"
+		} else if layer.ZoomDirection == "null" {
+			layer.ZoomDirection = ""
+		}
+	}
+	if (layer.Start > 0 || layer.Stop > 0) && !strings.HasPrefix(layer.Type, "overlay-") {
+		im.warn(o.path, "只有叠加层支持 start 和 stop，%s 层将显示整个片段", layerType)
+		layer.Start, layer.Stop = 0, 0
+	}
+	o.finish()
+	return []*Layer{layer}
+}
+
+// titleBackground 把 title-background 层拆分为背景层和标题层
+func (im *editlyImporter) titleBackground(o *editlyObject) []*Layer {
+	title := &Layer{Type: "title", Text: o.string("text"), FontPath: o.string("fontPath"), Color: o.string("textColor")}
+	background := &Layer{Type: "radial-gradient"}
+	if value, ok := o.raw("background"); ok {
+		if b := im.object(o.field("background"), value); b != nil {
+			switch t := b.string("type"); t {
+			case "fill-color", "linear-gradient", "radial-gradient":
+				background = im.layerFields(b, &Layer{Type: t})
+			default:
+				im.warn(b.field("type"), "不支持的背景类型 %s，改用 radial-gradient", t)
+			}
+			b.finish()
+		}
+	}
+	o.finish()
+	return []*Layer{background, title}
+}
+
+// audioLayer 记录片段中的音频层，片段中的 audio 层只播放到片段结束，detached-audio 层从片段开始后 start 秒播放到音频结束
+func (im *editlyImporter) audioLayer(o *editlyObject, clip int, detached bool) {
+	track := &AudioTrack{Path: o.string("path")}
+	track.CutFrom, _ = o.number("cutFrom")
+	track.CutTo, _ = o.number("cutTo")
+	if volume, ok := o.number("mixVolume"); ok {
+		track.MixVolume = &volume
+	}
+	if detached {
+		track.Start, _ = o.number("start")
+	}
+	o.finish()
+	im.audioLayers = append(im.audioLayers, &editlyAudioLayer{path: o.path, clip: clip, track: track, detached: detached})
+}
+
+func (im *editlyImporter) audioTrack(o *editlyObject) *AudioTrack {
+	track := &AudioTrack{Path: o.string("path")}
+	if volume, ok := o.number("mixVolume"); ok {
+		track.MixVolume = &volume
+	}
+	track.CutFrom, _ = o.number("cutFrom")
+	track.CutTo, _ = o.number("cutTo")
+	track.Start, _ = o.number("start")
+	o.finish()
+	return track
+}
+
+// staticClipDuration 不读取媒体文件能确定的片段时长，无法确定时返回 false
+func staticClipDuration(spec *EditSpec, clip *Clip) (float64, bool) {
+	if clip.Duration > 0 {
+		return clip.Duration, true
+	}
+	for _, layer := range clip.Layers {
+		if layer.Type == "video" && layer.Path != "" {
+			if layer.CutTo > 0 {
+				return (layer.CutTo - layer.CutFrom) / layer.speed(), true
+			}
+			return 0, false
+		}
+	}
+	if spec.Defaults != nil && spec.Defaults.Duration > 0 {
+		return spec.Defaults.Duration, true
+	}
+	return defaultClipDuration, true
+}
+
+// placeAudioLayers 把片段中的音频层转换为成片的音轨，片段的开始时间需要能够静态确定
+func (im *editlyImporter) placeAudioLayers(spec *EditSpec) {
+	if len(im.audioLayers) == 0 {
+		return
+	}
+	e := &Editly{spec: spec}
+	starts := make([]float64, len(spec.Clips))
+	durations := make([]float64, len(spec.Clips))
+	known := make([]bool, len(spec.Clips))
+	start, startKnown := 0.0, true
+	for i, clip := range spec.Clips {
+		starts[i] = start
+		duration, ok := staticClipDuration(spec, clip)
+		durations[i], known[i] = duration, ok && startKnown
+		startKnown = known[i]
+		start += duration
+		if t := e.resolveTransition(i, clip); t != nil {
+			start -= t.Duration
+		}
+	}
+	for _, audio := range im.audioLayers {
+		if !known[audio.clip] {
+			im.warn(audio.path, "无法在不读取视频的情况下确定片段的开始时间和时长，音频层已忽略；请为视频层设置 cutTo 或为片段设置 duration")
+			continue
+		}
+		track := audio.track
+		track.Start += starts[audio.clip]
+		if !audio.detached {
+			limit := track.CutFrom + durations[audio.clip]
+			if track.CutTo == 0 || track.CutTo > limit {
+				track.CutTo = limit
+			}
+		}
+		spec.AudioTracks = append(spec.AudioTracks, track)
+	}
+}
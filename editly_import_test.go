@@ -0,0 +1,131 @@
+package ffmpeg_go
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportEditlySpec(t *testing.T) {
+	spec, warnings, err := ImportEditlySpec([]byte(`{
+		outPath: './out.mp4',
+		width: 1280, height: 720, fps: 30,
+		defaults: {
+			duration: 3,
+			transition: {name: 'directional-left', duration: 0.4, audioOutCurve: 'exp', easing: 'easeOutExpo'},
+			layer: {fontPath: './msyh.ttc'},
+			layerType: {'image-overlay': {position: 'top-right'}},
+		},
+		clips: [
+			{duration: 2, transition: null, layers: [
+				{type: 'title-background', text: '标题', background: {type: 'linear-gradient', colors: ['#000', '#fff']}},
+				{type: 'audio', path: './voice.mp3', cutFrom: 1, mixVolume: 0.8},
+			]},
+			{layers: [
+				{type: 'video', path: './in.mp4', cutFrom: 1, cutTo: 5, mixVolume: 0.5},
+				{type: 'video', path: './pip.mp4', left: 0.6, top: 0.1, width: 0.3, originX: 'left'},
+				{type: 'image-overlay', path: './logo.png', zoomDirection: 'in'},
+				{type: 'subtitle', text: '字幕', textColor: 'yellow', delay: 1},
+				{type: 'gl', fragmentPath: './shader.frag'},
+			]},
+			{transition: {name: 'random'}, layers: [
+				{type: 'image', path: './a.jpg'},
+				{type: 'detached-audio', path: './sfx.mp3', start: 0.5},
+			]},
+			{layers: [{type: 'pause', color: 'white'}, {type: 'title', text: 'end', start: 1}]},
+		],
+		audioFilePath: './music.mp3',
+		loopAudio: true,
+		backgroundAudioVolume: '0.3',
+		keepSourceAudio: true,
+		audioNorm: {enable: true, gaussSize: 7, maxGain: 20},
+		outputVolume: '10dB',
+		fast: true,
+		customOutputArgs: ['-crf', '18'],
+	}`))
+	assert.Nil(t, err)
+
+	defaults := spec.Defaults
+	assert.Equal(t, 3.0, defaults.Duration)
+	assert.Equal(t, &Transition{Name: "slideleft", Duration: 0.4}, defaults.Transition)
+	assert.Equal(t, &Layer{FontPath: "./msyh.ttc"}, defaults.Layer)
+	assert.Equal(t, map[string]*Layer{"overlay-image": {Position: &Position{Preset: "top-right"}}}, defaults.LayerType)
+
+	assert.Len(t, spec.Clips, 4)
+	assert.Equal(t, &Transition{Name: "none"}, spec.Clips[0].Transition)
+	assert.Equal(t, []*Layer{
+		{Type: "linear-gradient", Colors: []string{"#000", "#fff"}},
+		{Type: "title", Text: "标题"},
+	}, spec.Clips[0].Layers)
+	assert.Equal(t, []*Layer{
+		{Type: "video", Path: "./in.mp4", CutFrom: 1, CutTo: 5},
+		{Type: "overlay-video", Path: "./pip.mp4", Width: 0.3, Position: &Position{X: 0.6, Y: 0.1, OriginX: "left"}},
+		{Type: "overlay-image", Path: "./logo.png"},
+		{Type: "subtitle", Text: "字幕", Color: "yellow"},
+	}, spec.Clips[1].Layers)
+	assert.Equal(t, &Transition{Name: "fade"}, spec.Clips[2].Transition)
+	assert.Equal(t, []*Layer{{Type: "image", Path: "./a.jpg", ZoomDirection: "in"}}, spec.Clips[2].Layers)
+	assert.Equal(t, []*Layer{{Type: "fill-color", Color: "white"}, {Type: "title", Text: "end"}}, spec.Clips[3].Layers)
+
+	// 片段中的音频层按片段的开始时间放到成片中：第一个片段直接切换，第二个片段 4 秒，之后 0.4 秒转场
+	music, voice := 0.3, 0.8
+	assert.Equal(t, []*AudioTrack{
+		{Path: "./music.mp3", Loop: true, MixVolume: &music},
+		{Path: "./voice.mp3", CutFrom: 1, CutTo: 3, MixVolume: &voice},
+		{Path: "./sfx.mp3", Start: 6.1},
+	}, spec.AudioTracks)
+	assert.True(t, spec.KeepSourceAudio)
+	assert.Equal(t, &AudioNorm{Enable: true, GaussSize: 7, MaxGain: 20}, spec.AudioNorm)
+
+	var messages []string
+	for _, w := range warnings {
+		messages = append(messages, w.String())
+	}
+	assert.Equal(t, []string{
+		"defaults.transition.name: 转场 directional-left 近似为 slideleft",
+		"defaults.transition.audioOutCurve: 转场 slideleft 不支持缓动，音频曲线 exp 改用 tri",
+		"defaults.transition.easing: 不支持视频转场的缓动 easeOutExpo，已忽略",
+		"clips[1].layers[0].mixVolume: 不支持单个视频层的音量，请使用 clipsAudioVolume，已忽略",
+		"clips[1].layers[2]: image-overlay 层不支持缩放动画，已忽略 zoomDirection 和 zoomAmount",
+		"clips[1].layers[3].delay: 不支持字幕的延迟显示，已忽略",
+		"clips[1].layers[4]: 不支持的层类型 gl，已忽略",
+		"clips[2].transition.name: 不支持的转场 random，改用 fade",
+		"clips[3].layers[1]: 只有叠加层支持 start 和 stop，title 层将显示整个片段",
+		"outputVolume: 不支持调整成片音量，请使用 clipsAudioVolume 和音轨的 mixVolume，已忽略",
+		"customOutputArgs: 不支持自定义 FFmpeg 参数，请使用 encoding 编码预设，已忽略",
+		"fast: 不支持快速模式，请使用预览渲染，已忽略",
+	}, messages)
+
+	// 导入的规范可以直接渲染
+	_, err = newTestEditly(spec, nil).Build()
+	assert.Nil(t, err)
+}
+
+func TestImportEditlySpecExample(t *testing.T) {
+	data, err := os.ReadFile("examples/editly_example.json")
+	assert.Nil(t, err)
+	spec, warnings, err := ImportEditlySpec(data)
+	assert.Nil(t, err)
+	assert.Empty(t, warnings)
+	assert.Len(t, spec.Clips, 4)
+	assert.Empty(t, spec.AudioTracks)
+	assert.Equal(t, "blue", spec.Clips[2].Layers[0].Color)
+	assert.Equal(t, 32, spec.Clips[2].Layers[0].FontSize)
+	assert.True(t, spec.KeepSourceAudio)
+
+	// 无法在不读取视频的情况下确定开始时间的音频层被忽略
+	_, warnings, err = ImportEditlySpec([]byte(`{clips: [
+		{layers: [{type: 'video', path: 'a.mp4'}]},
+		{layers: [{type: 'audio', path: 'b.mp3'}]},
+	]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, []*ImportWarning{
+		{Path: "width", Message: "未设置尺寸，editly 会按第一个视频推断，这里使用 640x360"},
+		{Path: "defaults.transition", Message: "未设置默认转场，editly 使用随机转场，这里使用 fade"},
+		{Path: "clips[1].layers[0]", Message: "无法在不读取视频的情况下确定片段的开始时间和时长，音频层已忽略；请为视频层设置 cutTo 或为片段设置 duration"},
+	}, warnings)
+
+	_, _, err = ImportEditlySpec([]byte(`[]`))
+	assert.EqualError(t, err, "editly 规范应为对象")
+}
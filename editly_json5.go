@@ -0,0 +1,323 @@
+package ffmpeg_go
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// json5Parser 解析 JSON5：注释、末尾逗号、不加引号的键、单引号字符串、十六进制和省略整数部分的数字。
+// 结果与 json.Unmarshal 到 interface{} 相同：对象为 map[string]interface{}，数字为 float64
+type json5Parser struct {
+	data  []byte
+	pos   int
+	depth int // 当前对象和数组的嵌套层数
+}
+
+// maxJSON5Depth 对象和数组最多的嵌套层数，与 encoding/json 相同，避免深层嵌套耗尽栈空间
+const maxJSON5Depth = 10000
+
+// parseJSON5 解析 JSON5 文本
+func parseJSON5(data []byte) (interface{}, error) {
+	p := &json5Parser{data: data}
+	// 跳过 UTF-8 BOM
+	if bytes.HasPrefix(data, []byte("\ufeff")) {
+		p.pos = 3
+	}
+	if err := p.skipSpace(); err != nil {
+		return nil, err
+	}
+	value, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.skipSpace(); err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.data) {
+		return nil, p.errorf("多余的内容")
+	}
+	return value, nil
+}
+
+// errorf 带行号和列号的解析错误
+func (p *json5Parser) errorf(format string, args ...interface{}) error {
+	consumed := string(p.data[:p.pos])
+	line := strings.Count(consumed, "\n") + 1
+	column := utf8.RuneCountInString(consumed[strings.LastIndex(consumed, "\n")+1:]) + 1
+	return fmt.Errorf("JSON5 第 %d 行第 %d 列: %s", line, column, fmt.Sprintf(format, args...))
+}
+
+// skipSpace 跳过空白和注释
+func (p *json5Parser) skipSpace() error {
+	for p.pos < len(p.data) {
+		r, size := utf8.DecodeRune(p.data[p.pos:])
+		switch {
+		case unicode.IsSpace(r) || r == '\ufeff':
+			p.pos += size
+		case bytes.HasPrefix(p.data[p.pos:], []byte("//")):
+			end := bytes.IndexByte(p.data[p.pos:], '\n')
+			if end < 0 {
+				p.pos = len(p.data)
+			} else {
+				p.pos += end + 1
+			}
+		case bytes.HasPrefix(p.data[p.pos:], []byte("/*")):
+			end := bytes.Index(p.data[p.pos+2:], []byte("*/"))
+			if end < 0 {
+				return p.errorf("注释没有结束")
+			}
+			p.pos += end + 4
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (p *json5Parser) value() (interface{}, error) {
+	if p.pos >= len(p.data) {
+		return nil, p.errorf("意外的结尾")
+	}
+	switch c := p.data[p.pos]; {
+	case c == '{' || c == '[':
+		if p.depth >= maxJSON5Depth {
+			return nil, p.errorf("嵌套超过 %d 层", maxJSON5Depth)
+		}
+		p.depth++
+		defer func() { p.depth-- }()
+		if c == '{' {
+			return p.object()
+		}
+		return p.array()
+	case c == '"' || c == '\'':
+		return p.string()
+	case c == '-' || c == '+' || c == '.' || c >= '0' && c <= '9':
+		return p.number()
+	}
+	start := p.pos
+	switch word := p.identifier(); word {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	case "Infinity", "NaN":
+		p.pos = start
+		return nil, p.errorf("不支持 %s", word)
+	}
+	p.pos = start
+	return nil, p.errorf("无法识别的值")
+}
+
+func (p *json5Parser) object() (interface{}, error) {
+	p.pos++
+	object := map[string]interface{}{}
+	for {
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == '}' {
+			p.pos++
+			return object, nil
+		}
+		var key string
+		if p.pos < len(p.data) && (p.data[p.pos] == '"' || p.data[p.pos] == '\'') {
+			s, err := p.string()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		} else if key = p.identifier(); key == "" {
+			return nil, p.errorf("需要对象的键")
+		}
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.data) || p.data[p.pos] != ':' {
+			return nil, p.errorf("键 %s 后需要冒号", key)
+		}
+		p.pos++
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		object[key] = value
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == '}' {
+			p.pos++
+			return object, nil
+		}
+		return nil, p.errorf("对象中需要逗号或 }")
+	}
+}
+
+func (p *json5Parser) array() (interface{}, error) {
+	p.pos++
+	array := []interface{}{}
+	for {
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == ']' {
+			p.pos++
+			return array, nil
+		}
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == ',' {
+			p.pos++
+			continue
+		}
+		if p.pos < len(p.data) && p.data[p.pos] == ']' {
+			p.pos++
+			return array, nil
+		}
+		return nil, p.errorf("数组中需要逗号或 ]")
+	}
+}
+
+// identifier 读取不加引号的键或关键字，没有时返回空字符串
+func (p *json5Parser) identifier() string {
+	start := p.pos
+	for p.pos < len(p.data) {
+		r, size := utf8.DecodeRune(p.data[p.pos:])
+		if r != '_' && r != '$' && !unicode.IsLetter(r) && !(p.pos > start && unicode.IsDigit(r)) {
+			break
+		}
+		p.pos += size
+	}
+	return string(p.data[start:p.pos])
+}
+
+func (p *json5Parser) string() (string, error) {
+	quote := p.data[p.pos]
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+		case c == '\n' || c == '\r':
+			return "", p.errorf("字符串中不能直接换行")
+		case c != '\\':
+			b.WriteByte(c)
+			p.pos++
+			continue
+		}
+		p.pos++
+		if p.pos >= len(p.data) {
+			break
+		}
+		escape := p.data[p.pos]
+		p.pos++
+		switch escape {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'v':
+			b.WriteByte('\v')
+		case '0':
+			b.WriteByte(0)
+		case '\n':
+			// 行尾的反斜杠表示字符串在下一行继续
+		case '\r':
+			if p.pos < len(p.data) && p.data[p.pos] == '\n' {
+				p.pos++
+			}
+		case 'x', 'u':
+			size := 2
+			if escape == 'u' {
+				size = 4
+			}
+			if p.pos+size > len(p.data) {
+				return "", p.errorf("无效的转义")
+			}
+			code, err := strconv.ParseUint(string(p.data[p.pos:p.pos+size]), 16, 32)
+			if err != nil {
+				return "", p.errorf("无效的转义")
+			}
+			p.pos += size
+			r := rune(code)
+			// 代理对组成一个字符
+			if utf16High := r >= 0xd800 && r < 0xdc00; utf16High && bytes.HasPrefix(p.data[p.pos:], []byte("\\u")) && p.pos+6 <= len(p.data) {
+				if low, err := strconv.ParseUint(string(p.data[p.pos+2:p.pos+6]), 16, 32); err == nil && low >= 0xdc00 && low < 0xe000 {
+					r = (r-0xd800)<<10 + (rune(low) - 0xdc00) + 0x10000
+					p.pos += 6
+				}
+			}
+			b.WriteRune(r)
+		default:
+			// 其余字符（包括引号和反斜杠）转义为自身
+			b.WriteByte(escape)
+		}
+	}
+	return "", p.errorf("字符串没有结束")
+}
+
+func (p *json5Parser) number() (interface{}, error) {
+	start := p.pos
+	sign := 1.0
+	if c := p.data[p.pos]; c == '+' || c == '-' {
+		if c == '-' {
+			sign = -1
+		}
+		p.pos++
+	}
+	if word := p.identifier(); word != "" {
+		// 只能是 Infinity、NaN 或十六进制以外的非法值
+		p.pos = start
+		return nil, p.errorf("不支持 %s", word)
+	}
+	digits := p.pos
+	for p.pos < len(p.data) && strings.IndexByte("0123456789abcdefABCDEFxX.+-", p.data[p.pos]) >= 0 {
+		// 指数之后才能出现正负号
+		if c := p.data[p.pos]; (c == '+' || c == '-') && !strings.ContainsAny(string(p.data[p.pos-1]), "eE") {
+			break
+		}
+		p.pos++
+	}
+	text := string(p.data[digits:p.pos])
+	if strings.HasPrefix(text, "0x") || strings.HasPrefix(text, "0X") {
+		v, err := strconv.ParseInt(text[2:], 16, 64)
+		if err != nil {
+			p.pos = start
+			return nil, p.errorf("无效的数字")
+		}
+		return sign * float64(v), nil
+	}
+	v, err := strconv.ParseFloat(strings.TrimSuffix(text, "."), 64)
+	if err != nil || strings.ContainsAny(text, "abcdfABCDF") {
+		p.pos = start
+		return nil, p.errorf("无效的数字")
+	}
+	return sign * v, nil
+}
@@ -0,0 +1,66 @@
+package ffmpeg_go
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseJSON5(t *testing.T) {
+	value, err := parseJSON5([]byte(`// editly 规范
+{
+	outPath: './out.mp4', /* 输出 */
+	"width": 0x280,
+	fps: +.5e2,
+	ratio: 5.,
+	text: 'It\'s "ok" 中\
+文',
+	$list: [1, -2, true, null,],
+}`))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"outPath": "./out.mp4",
+		"width":   float64(640),
+		"fps":     float64(50),
+		"ratio":   float64(5),
+		"text":    `It's "ok" 中文`,
+		"$list":   []interface{}{float64(1), float64(-2), true, nil},
+	}, value)
+
+	for text, message := range map[string]string{
+		"{a: 1 b: 2}":       "JSON5 第 1 行第 7 列: 对象中需要逗号或 }",
+		"{\n  a: Infinity}": "JSON5 第 2 行第 6 列: 不支持 Infinity",
+		"['a\nb']":          "JSON5 第 1 行第 4 列: 字符串中不能直接换行",
+		"{a: 1} /* x":       "JSON5 第 1 行第 8 列: 注释没有结束",
+		"[1, 2] 3":          "JSON5 第 1 行第 8 列: 多余的内容",
+		"{a: 0xzz}":         "JSON5 第 1 行第 5 列: 无效的数字",
+	} {
+		_, err := parseJSON5([]byte(text))
+		if assert.NotNil(t, err, text) {
+			assert.Equal(t, message, err.Error(), text)
+		}
+	}
+}
+
+func TestParseJSON5Large(t *testing.T) {
+	// 解析时间与输入长度成线性关系
+	var b strings.Builder
+	b.WriteString("[\n")
+	for i := 0; i < 50000; i++ {
+		b.WriteString("  /* 片段 */ {type: 'title', text: \"a\"}, // 注释\n")
+	}
+	b.WriteString("]")
+	start := time.Now()
+	value, err := parseJSON5([]byte(b.String()))
+	assert.Nil(t, err)
+	assert.Len(t, value, 50000)
+	assert.Less(t, time.Since(start), 2*time.Second)
+
+	// 嵌套层数有上限
+	_, err = parseJSON5([]byte(strings.Repeat("[", maxJSON5Depth+1)))
+	if assert.NotNil(t, err) {
+		assert.Equal(t, "JSON5 第 1 行第 10001 列: 嵌套超过 10000 层", err.Error())
+	}
+}
@@ -0,0 +1,205 @@
+package ffmpeg_go
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Position 层的位置：预设名称，或相对画布的比例坐标。JSON 中可以写为 "bottom-left" 或 {"x": 0.1, "y": 0.2}
+type Position struct {
+	Preset  string  `json:"-"`                 // top、center、bottom，可加 -left、-right，如 bottom-left
+	X       float64 `json:"x"`                 // 横坐标，画布宽度的比例
+	Y       float64 `json:"y"`                 // 纵坐标，画布高度的比例
+	OriginX string  `json:"originX,omitempty"` // 坐标对应层的哪一侧：left、center、right，默认 left
+	OriginY string  `json:"originY,omitempty"` // 坐标对应层的哪一侧：top、center、bottom，默认 top
+}
+
+// UnmarshalJSON 支持预设名称和坐标对象两种写法
+func (p *Position) UnmarshalJSON(data []byte) error {
+	var preset string
+	if err := json.Unmarshal(data, &preset); err == nil {
+		*p = Position{Preset: preset}
+		return nil
+	}
+	type plain Position
+	return json.Unmarshal(data, (*plain)(p))
+}
+
+// MarshalJSON 预设位置输出为字符串
+func (p Position) MarshalJSON() ([]byte, error) {
+	if p.Preset != "" {
+		return json.Marshal(p.Preset)
+	}
+	type plain Position
+	return json.Marshal(plain(p))
+}
+
+// splitPreset 将位置预设拆分为垂直和水平方向，如 bottom-left 为 bottom 和 left
+func splitPreset(preset string) (vertical, horizontal string, err error) {
+	parts := strings.SplitN(preset, "-", 2)
+	vertical, horizontal = parts[0], "center"
+	if len(parts) == 2 {
+		horizontal = parts[1]
+	}
+	if vertical != "top" && vertical != "center" && vertical != "bottom" ||
+		horizontal != "left" && horizontal != "center" && horizontal != "right" {
+		return "", "", fmt.Errorf("不支持的位置: %s", preset)
+	}
+	return vertical, horizontal, nil
+}
+
+// originFactors 坐标原点在层内的相对位置，left/top 为 0，center 为 0.5，right/bottom 为 1
+func (p *Position) originFactors() (fx, fy float64, err error) {
+	factors := map[string]float64{"": 0, "left": 0, "center": 0.5, "right": 1}
+	fx, ok := factors[p.OriginX]
+	if !ok {
+		return 0, 0, fmt.Errorf("不支持的 originX: %s", p.OriginX)
+	}
+	factors = map[string]float64{"": 0, "top": 0, "center": 0.5, "bottom": 1}
+	fy, ok = factors[p.OriginY]
+	if !ok {
+		return 0, 0, fmt.Errorf("不支持的 originY: %s", p.OriginY)
+	}
+	return fx, fy, nil
+}
+
+// overlayPosition overlay 滤镜的 x、y 表达式，W、H 为画布大小，w、h 为叠加层大小。未设置位置时居中
+func overlayPosition(p *Position) (x, y string, err error) {
+	if p == nil || p.Preset != "" {
+		preset := "center"
+		if p != nil {
+			preset = p.Preset
+		}
+		vertical, horizontal, err := splitPreset(preset)
+		if err != nil {
+			return "", "", err
+		}
+		xs := map[string]string{"left": "0", "center": "(W-w)/2", "right": "W-w"}
+		ys := map[string]string{"top": "0", "center": "(H-h)/2", "bottom": "H-h"}
+		return xs[horizontal], ys[vertical], nil
+	}
+	fx, fy, err := p.originFactors()
+	if err != nil {
+		return "", "", err
+	}
+	return fmt.Sprintf("%s*W-%s*w", formatNumber(p.X), formatNumber(fx)),
+		fmt.Sprintf("%s*H-%s*h", formatNumber(p.Y), formatNumber(fy)), nil
+}
+
+// evenSize 画布比例换算为像素，取偶数以兼容 yuv420p
+func evenSize(fraction float64, size int) int {
+	return int(math.Round(fraction*float64(size)/2)) * 2
+}
+
+// scaleOverlay 按画布比例缩放叠加层，只设置宽或高时保持宽高比，都设置时按缩放模式放入该区域
+func (e *Editly) scaleOverlay(s *Stream, layer *Layer) (*Stream, error) {
+	if layer.Width < 0 || layer.Height < 0 {
+		return nil, fmt.Errorf("width、height 不能为负数")
+	}
+	switch {
+	case layer.Width > 0 && layer.Height > 0:
+		w, h := evenSize(layer.Width, e.spec.Width), evenSize(layer.Height, e.spec.Height)
+		if layer.ResizeMode == "" || layer.ResizeMode == "contain" {
+			// 叠加层不需要补边，保持透明
+			s = s.Filter("scale", Args{strconv.Itoa(w), strconv.Itoa(h)}, KwArgs{"force_original_aspect_ratio": "decrease"})
+		} else {
+			var err error
+			if s, err = resizeFrame(s, layer.ResizeMode, w, h); err != nil {
+				return nil, err
+			}
+		}
+	case layer.Width > 0:
+		s = s.Filter("scale", Args{strconv.Itoa(evenSize(layer.Width, e.spec.Width)), "-2"})
+	case layer.Height > 0:
+		s = s.Filter("scale", Args{"-2", strconv.Itoa(evenSize(layer.Height, e.spec.Height))})
+	}
+	return s.Filter("setsar", Args{"1"}).Filter("fps", Args{strconv.Itoa(e.spec.Fps)}), nil
+}
+
+// roundCornersExpr 圆角外的像素透明
+func roundCornersExpr(radius int) string {
+	r := strconv.Itoa(radius)
+	return fmt.Sprintf("if(gt(abs(W/2-X),W/2-%[1]s)*gt(abs(H/2-Y),H/2-%[1]s),"+
+		"if(lte(hypot(%[1]s-(W/2-abs(W/2-X)),%[1]s-(H/2-abs(H/2-Y))),%[1]s),alpha(X,Y),0),alpha(X,Y))", r)
+}
+
+// decorateOverlay 为叠加层加边框、圆角和透明度
+func decorateOverlay(s *Stream, layer *Layer) (*Stream, error) {
+	if layer.BorderWidth < 0 || layer.CornerRadius < 0 {
+		return nil, fmt.Errorf("borderWidth、cornerRadius 不能为负数")
+	}
+	if layer.Opacity < 0 || layer.Opacity > 1 {
+		return nil, fmt.Errorf("opacity 必须在 0~1 之间: %s", formatNumber(layer.Opacity))
+	}
+	if layer.BorderWidth == 0 && layer.CornerRadius == 0 && (layer.Opacity == 0 || layer.Opacity == 1) {
+		return s, nil
+	}
+	s = s.Filter("format", Args{"rgba"})
+	if b := layer.BorderWidth; b > 0 {
+		color := layer.BorderColor
+		if color == "" {
+			color = "white"
+		}
+		s = s.Filter("pad", Args{fmt.Sprintf("iw+%d", 2*b), fmt.Sprintf("ih+%d", 2*b), strconv.Itoa(b), strconv.Itoa(b)},
+			KwArgs{"color": color})
+	}
+	if layer.CornerRadius > 0 {
+		s = s.Filter("geq", nil, KwArgs{"r": "r(X,Y)", "g": "g(X,Y)", "b": "b(X,Y)", "a": roundCornersExpr(layer.CornerRadius)})
+	}
+	if layer.Opacity > 0 && layer.Opacity < 1 {
+		s = s.Filter("colorchannelmixer", nil, KwArgs{"aa": formatNumber(layer.Opacity)})
+	}
+	return s, nil
+}
+
+// renderOverlay 渲染画中画或叠加图片，返回叠加层和 overlay 滤镜的参数。
+// 叠加层只在片段的 start~stop 秒之间显示，视频从 start 开始播放
+func (e *Editly) renderOverlay(id string, layer *Layer, duration float64) (*Stream, KwArgs, error) {
+	start, stop := layer.Start, layer.Stop
+	if stop == 0 {
+		stop = duration
+	}
+	if start < 0 || stop <= start || stop > duration {
+		return nil, nil, fmt.Errorf("start(%s)~stop(%s) 超出片段时长 %s 秒", seconds(start), seconds(stop), seconds(duration))
+	}
+	visible := stop - start
+
+	var s *Stream
+	var timing *layerTiming
+	switch layer.Type {
+	case "overlay-video":
+		var err error
+		if s, timing, err = e.openVideo(id, layer, visible); err != nil {
+			return nil, nil, err
+		}
+	case "overlay-image":
+		input := Input(layer.Path, KwArgs{"loop": "1", "framerate": strconv.Itoa(e.spec.Fps), "t": seconds(visible)})
+		s = input.Video().Filter("setpts@"+id, Args{"PTS-STARTPTS"})
+	}
+	s, err := e.scaleOverlay(s, layer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if timing != nil {
+		s = e.extendVideo(s, timing, visible)
+	}
+	if s, err = decorateOverlay(s, layer); err != nil {
+		return nil, nil, err
+	}
+	if start > 0 {
+		s = s.Filter("setpts", Args{fmt.Sprintf("PTS+%s/TB", seconds(start))})
+	}
+
+	x, y, err := overlayPosition(layer.Position)
+	if err != nil {
+		return nil, nil, err
+	}
+	args := KwArgs{"x": x, "y": y}
+	if start > 0 || stop < duration {
+		args["enable"] = fmt.Sprintf("between(t,%s,%s)", seconds(start), seconds(stop))
+	}
+	return s, args, nil
+}
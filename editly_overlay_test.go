@@ -0,0 +1,72 @@
+package ffmpeg_go
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPositionJSON(t *testing.T) {
+	var layers []*Layer
+	err := json.Unmarshal([]byte(`[{"position": "top-right"}, {"position": {"x": 0.5, "y": 0.25, "originX": "center"}}]`), &layers)
+	assert.Nil(t, err)
+	assert.Equal(t, &Position{Preset: "top-right"}, layers[0].Position)
+	assert.Equal(t, &Position{X: 0.5, Y: 0.25, OriginX: "center"}, layers[1].Position)
+
+	data, err := json.Marshal(layers[0].Position)
+	assert.Nil(t, err)
+	assert.Equal(t, `"top-right"`, string(data))
+}
+
+func TestEditlyOverlayLayers(t *testing.T) {
+	spec := &EditSpec{
+		OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25,
+		Clips: []*Clip{{Duration: 6, Layers: []*Layer{
+			{Type: "video", Path: "main.mp4"},
+			{Type: "overlay-video", Path: "pip.mp4", Width: 0.3, Position: &Position{Preset: "bottom-right"},
+				Start: 1, Stop: 4, CornerRadius: 12, BorderWidth: 4, BorderColor: "yellow"},
+			{Type: "overlay-image", Path: "logo.png", Height: 0.1, Opacity: 0.8,
+				Position: &Position{X: 0.95, Y: 0.05, OriginX: "right"}},
+		}}},
+	}
+	stream, err := newTestEditly(spec, map[string]*mediaInfo{
+		"pip.mp4": {Duration: 2, HasVideo: true},
+	}).Build()
+	assert.Nil(t, err)
+	args := stream.GetArgs()
+	cmd := strings.Join(args, " ")
+	graph := args[indexOfArg(args, "-filter_complex")+1]
+
+	// 画中画：宽度为画布的 30%，2 秒素材定格到 3 秒，从第 1 秒开始显示
+	assert.Contains(t, cmd, "-t 2 -i pip.mp4")
+	assert.Contains(t, graph, "scale=192:-2")
+	assert.Contains(t, graph, "tpad=stop_duration=1:stop_mode=clone")
+	assert.Contains(t, graph, "pad=iw+8:ih+8:4:4:color=yellow")
+	assert.Contains(t, graph, "geq=a=if(gt(abs(W/2-X)\\,W/2-12)")
+	assert.Contains(t, graph, "setpts=PTS+1/TB")
+	assert.Contains(t, graph, "overlay=enable=between(t\\,1\\,4):eof_action=pass:x=W-w:y=H-h")
+	// 图标：高度为画布的 10%，右上角按比例坐标定位
+	assert.Contains(t, cmd, "-t 6 -i logo.png")
+	assert.Contains(t, graph, "scale=-2:36")
+	assert.Contains(t, graph, "colorchannelmixer=aa=0.8")
+	assert.Contains(t, graph, "overlay=eof_action=pass:x=0.95*W-1*w:y=0.05*H-0*h")
+	// 各层按声明顺序叠加
+	assert.Equal(t, 3, strings.Count(graph, "overlay="))
+}
+
+func TestEditlyOverlayErrors(t *testing.T) {
+	build := func(layer *Layer) error {
+		spec := &EditSpec{OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25,
+			Clips: []*Clip{{Duration: 2, Layers: []*Layer{layer}}}}
+		_, err := newTestEditly(spec, nil).Build()
+		return err
+	}
+	assert.EqualError(t, build(&Layer{Type: "overlay-image", Path: "a.png", Start: 1, Stop: 3}),
+		"clips[0].layers[0]: start(1)~stop(3) 超出片段时长 2 秒")
+	assert.EqualError(t, build(&Layer{Type: "overlay-image", Path: "a.png", Opacity: 2}),
+		"clips[0].layers[0]: opacity 必须在 0~1 之间: 2")
+	assert.EqualError(t, build(&Layer{Type: "overlay-image", Path: "a.png", Position: &Position{OriginX: "middle"}}),
+		"clips[0].layers[0]: 不支持的 originX: middle")
+}
@@ -0,0 +1,182 @@
+package ffmpeg_go
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// defaultPreviewHeight 预览默认的最大高度（像素）
+	defaultPreviewHeight = 360
+	// defaultPreviewFps 预览默认的帧率
+	defaultPreviewFps = 15
+	// previewEncoding 预览使用的编码预设：x264 ultrafast
+	previewEncoding = "preview"
+)
+
+// PreviewOptions 预览渲染的参数，零值使用默认值
+type PreviewOptions struct {
+	OutPath          string  `json:"outPath,omitempty"`          // 预览文件路径，为空时在输出文件名后加 -preview，如 out-preview.mp4
+	MaxHeight        int     `json:"maxHeight,omitempty"`        // 最大高度（像素），默认 360，宽度按比例缩放
+	Fps              int     `json:"fps,omitempty"`              // 帧率，默认 15，不超过成片帧率
+	KeyframeInterval float64 `json:"keyframeInterval,omitempty"` // 关键帧间隔（秒），默认 1，便于在编辑器中拖动进度
+}
+
+// outPath 预览文件路径
+func (o *PreviewOptions) outPath(specOutPath string) string {
+	if o.OutPath != "" {
+		return o.OutPath
+	}
+	ext := filepath.Ext(specOutPath)
+	return strings.TrimSuffix(specOutPath, ext) + "-preview" + ext
+}
+
+// scalePixels 按比例缩放像素值，非零值至少为 1
+func scalePixels(v int, scale float64) int {
+	if v == 0 {
+		return 0
+	}
+	return int(math.Max(1, math.Round(float64(v)*scale)))
+}
+
+// BuildPreview 构建降低分辨率和帧率的预览命令。默认值按成片尺寸合并后，字号、圆角等像素值按相同比例缩小，
+// 预览的构图与成片一致
+func (e *Editly) BuildPreview(opts PreviewOptions) (*Stream, error) {
+	if opts.MaxHeight < 0 || opts.Fps < 0 || opts.KeyframeInterval < 0 {
+		return nil, fmt.Errorf("无效的预览参数: maxHeight=%d, fps=%d, keyframeInterval=%s",
+			opts.MaxHeight, opts.Fps, seconds(opts.KeyframeInterval))
+	}
+	spec, err := e.resolveForBuild()
+	if err != nil {
+		return nil, err
+	}
+
+	maxHeight := opts.MaxHeight
+	if maxHeight == 0 {
+		maxHeight = defaultPreviewHeight
+	}
+	scale := math.Min(1, float64(maxHeight)/float64(spec.Height))
+	spec.Width = int(math.Max(2, float64(evenSize(scale, spec.Width))))
+	spec.Height = int(math.Max(2, float64(evenSize(scale, spec.Height))))
+	fps := opts.Fps
+	if fps == 0 {
+		fps = defaultPreviewFps
+	}
+	if fps < spec.Fps {
+		spec.Fps = fps
+	}
+	spec.OutPath = opts.outPath(e.spec.OutPath)
+	spec.Encoding = previewEncoding
+	// Resolve 返回的层都是副本，可以直接修改
+	for _, clip := range spec.Clips {
+		for _, layer := range clip.Layers {
+			layer.FontSize = scalePixels(layer.FontSize, scale)
+			layer.CornerRadius = scalePixels(layer.CornerRadius, scale)
+			layer.BorderWidth = scalePixels(layer.BorderWidth, scale)
+		}
+	}
+
+	interval := opts.KeyframeInterval
+	if interval == 0 {
+		interval = 1
+	}
+	gop := int(math.Max(1, math.Round(interval*float64(spec.Fps))))
+	return (&Editly{spec: spec, prober: e.prober, outputArgs: KwArgs{"g:v": gop}}).build()
+}
+
+// Preview 渲染预览视频，返回预览文件路径
+func (e *Editly) Preview(opts PreviewOptions) (string, error) {
+	if errs := e.Validate(); len(errs) > 0 {
+		return "", fmt.Errorf("输入验证失败: %w", errs)
+	}
+	outPath := opts.outPath(e.spec.OutPath)
+	if err := os.MkdirAll(filepath.Dir(outPath), 0755); err != nil {
+		return "", fmt.Errorf("创建输出目录失败: %w", err)
+	}
+	stream, err := e.BuildPreview(opts)
+	if err != nil {
+		return "", fmt.Errorf("构建FFmpeg命令失败: %w", err)
+	}
+	if e.spec.Verbose {
+		log.Printf("执行FFmpeg命令: ffmpeg %s", strings.Join(stream.GetArgs(), " "))
+	}
+	if err := stream.Run(); err != nil {
+		return "", fmt.Errorf("预览渲染失败: %w", err)
+	}
+	return outPath, nil
+}
+
+// BuildFrame 构建截取时间轴上 t 秒处画面的命令，输出 PNG 到标准输出。
+// 只渲染 t 时刻显示的片段，处于转场中时渲染转场两侧的两个片段
+func (e *Editly) BuildFrame(t float64) (*Stream, error) {
+	spec, err := e.resolveForBuild()
+	if err != nil {
+		return nil, err
+	}
+	r := &Editly{spec: spec, prober: e.prober}
+	durations, transitions, err := r.timeline()
+	if err != nil {
+		return nil, err
+	}
+	if total := timelineDuration(durations, transitions); t < 0 || t >= total {
+		return nil, fmt.Errorf("时间 %s 秒超出成片时长 %s 秒", seconds(t), seconds(total))
+	}
+
+	var video *Stream
+	start := 0.0
+	for i, duration := range durations {
+		overlap := 0.0
+		if i < len(transitions) && transitions[i] != nil {
+			overlap = transitions[i].Duration
+		}
+		// 下一个片段在本片段结束前 overlap 秒开始
+		next := start + duration - overlap
+		if t >= start+duration {
+			start = next
+			continue
+		}
+		current, err := r.renderClip(i, spec.Clips[i], duration)
+		if err != nil {
+			return nil, err
+		}
+		video = current
+		if t >= next {
+			following, err := r.renderClip(i+1, spec.Clips[i+1], durations[i+1])
+			if err != nil {
+				return nil, err
+			}
+			video, _ = joinClips([]*Stream{current, following}, nil, durations[i:i+2], transitions[i:i+1])
+		}
+		break
+	}
+	video = video.Filter("trim", nil, KwArgs{"start": seconds(t - start)}).Filter("setpts", Args{"PTS-STARTPTS"})
+	return video.Output("pipe:", KwArgs{"frames:v": "1", "f": "image2", "c:v": "png"}), nil
+}
+
+// RenderFrame 返回时间轴上 t 秒处合成画面的 PNG 图片
+func (e *Editly) RenderFrame(t float64) ([]byte, error) {
+	stream, err := e.BuildFrame(t)
+	if err != nil {
+		return nil, err
+	}
+	var out, errOut bytes.Buffer
+	if err := stream.WithOutput(&out).WithErrorOutput(&errOut).Run(); err != nil {
+		return nil, fmt.Errorf("渲染画面失败: %w: %s", err, strings.TrimSpace(errOut.String()))
+	}
+	return out.Bytes(), nil
+}
+
+// Preview 是一个便捷函数，直接渲染预览视频
+func Preview(spec *EditSpec, opts PreviewOptions) (string, error) {
+	return NewEditly(spec).Preview(opts)
+}
+
+// RenderFrame 是一个便捷函数，直接返回时间轴上 t 秒处画面的 PNG 图片
+func RenderFrame(spec *EditSpec, t float64) ([]byte, error) {
+	return NewEditly(spec).RenderFrame(t)
+}
@@ -0,0 +1,83 @@
+package ffmpeg_go
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditlyBuildPreview(t *testing.T) {
+	spec := &EditSpec{
+		OutPath: "out/final.mp4", Width: 1920, Height: 1080, Fps: 30,
+		Clips: []*Clip{{Duration: 3, Layers: []*Layer{
+			{Type: "image", Path: "a.png"},
+			{Type: "title", Text: "标题", FontPath: "font.ttf"},
+			{Type: "overlay-image", Path: "logo.png", Width: 0.2, CornerRadius: 30},
+		}}},
+	}
+	stream, err := newTestEditly(spec, nil).BuildPreview(PreviewOptions{})
+	assert.Nil(t, err)
+	args := stream.GetArgs()
+	cmd := strings.Join(args, " ")
+	graph := args[indexOfArg(args, "-filter_complex")+1]
+
+	// 缩小到 360p、15fps，字号和圆角按相同比例缩小
+	assert.Contains(t, cmd, "color=c=black:s=640x360:r=15")
+	assert.Contains(t, graph, "fontsize=36")
+	assert.Contains(t, graph, "scale=128:-2")
+	assert.Contains(t, graph, "W/2-10")
+	assert.Contains(t, cmd, "-preset:v ultrafast")
+	assert.Contains(t, cmd, "-g:v 15")
+	assert.Contains(t, cmd, "-r 15")
+	assert.Equal(t, "out/final-preview.mp4", args[len(args)-2])
+	// 原规范不变
+	assert.Equal(t, 1920, spec.Width)
+
+	stream, err = newTestEditly(spec, nil).BuildPreview(PreviewOptions{OutPath: "p.mp4", MaxHeight: 720, Fps: 60, KeyframeInterval: 0.5})
+	assert.Nil(t, err)
+	cmd = strings.Join(stream.GetArgs(), " ")
+	assert.Contains(t, cmd, "s=1280x720:r=30")
+	assert.Contains(t, cmd, "-g:v 15")
+	assert.True(t, strings.HasSuffix(cmd, "p.mp4 -y"))
+}
+
+func TestEditlyBuildFrame(t *testing.T) {
+	spec := &EditSpec{
+		OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25,
+		Defaults: &Defaults{Transition: &Transition{Name: "wipeleft", Duration: 1}},
+		Clips: []*Clip{
+			{Duration: 3, Layers: []*Layer{{Type: "image", Path: "a.png"}}},
+			{Duration: 3, Layers: []*Layer{{Type: "image", Path: "b.png"}}},
+			{Duration: 3, Layers: []*Layer{{Type: "image", Path: "c.png"}}},
+		},
+	}
+	frame := func(at float64) string {
+		stream, err := newTestEditly(spec, nil).BuildFrame(at)
+		assert.Nil(t, err)
+		return strings.Join(stream.GetArgs(), " ")
+	}
+
+	// 只渲染当前片段，截取片段内的对应时间
+	cmd := frame(1)
+	assert.Contains(t, cmd, "-i a.png")
+	assert.NotContains(t, cmd, "b.png")
+	assert.Contains(t, cmd, "trim=start=1")
+	assert.Contains(t, cmd, "-c:v png -f image2 -frames:v 1 pipe:")
+
+	// 转场中渲染两侧的片段
+	cmd = frame(4.5)
+	assert.Contains(t, cmd, "-i b.png")
+	assert.Contains(t, cmd, "-i c.png")
+	assert.NotContains(t, cmd, "a.png")
+	assert.Contains(t, cmd, "xfade=duration=1:offset=2:transition=wipeleft")
+	assert.Contains(t, cmd, "trim=start=2.5")
+
+	cmd = frame(6)
+	assert.Contains(t, cmd, "-i c.png")
+	assert.NotContains(t, cmd, "b.png")
+	assert.Contains(t, cmd, "trim=start=2")
+
+	_, err := newTestEditly(spec, nil).BuildFrame(7)
+	assert.EqualError(t, err, "时间 7 秒超出成片时长 7 秒")
+}
@@ -0,0 +1,474 @@
+package ffmpeg_go
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+)
+
+const (
+	// defaultClipDuration 没有视频层且未指定时长的片段时长（秒），与 editly 一致
+	defaultClipDuration = 4.0
+	// editlySampleRate 输出音频的采样率，所有音频统一重采样后再拼接、混音
+	editlySampleRate = 48000
+)
+
+// mediaInfo 渲染需要的素材信息
+type mediaInfo struct {
+	Duration float64
+	Width    int
+	Height   int
+	HasVideo bool
+	HasAudio bool
+}
+
+// probeMedia 使用 ffprobe 获取素材信息
+func probeMedia(path string) (*mediaInfo, error) {
+	out, err := Probe(path)
+	if err != nil {
+		return nil, err
+	}
+	var data struct {
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+		Streams []struct {
+			CodecType string `json:"codec_type"`
+			Width     int    `json:"width"`
+			Height    int    `json:"height"`
+			Duration  string `json:"duration"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal([]byte(out), &data); err != nil {
+		return nil, fmt.Errorf("解析 ffprobe 输出失败: %w", err)
+	}
+	info := &mediaInfo{}
+	info.Duration, _ = strconv.ParseFloat(data.Format.Duration, 64)
+	for _, s := range data.Streams {
+		switch s.CodecType {
+		case "video":
+			if !info.HasVideo {
+				info.HasVideo, info.Width, info.Height = true, s.Width, s.Height
+			}
+		case "audio":
+			info.HasAudio = true
+		}
+		if info.Duration == 0 {
+			info.Duration, _ = strconv.ParseFloat(s.Duration, 64)
+		}
+	}
+	return info, nil
+}
+
+// seconds 格式化秒数，保留到毫秒
+func seconds(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64)
+}
+
+// mediaProber 带缓存的素材探测，同一个文件在多个片段中只探测一次
+type mediaProber struct {
+	probe func(path string) (*mediaInfo, error)
+	mutex sync.Mutex
+	cache map[string]*mediaInfo
+}
+
+func (p *mediaProber) get(path string) (*mediaInfo, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if info, ok := p.cache[path]; ok {
+		return info, nil
+	}
+	probe := p.probe
+	if probe == nil {
+		probe = probeMedia
+	}
+	info, err := probe(path)
+	if err != nil {
+		return nil, fmt.Errorf("探测素材失败 %s: %w", path, err)
+	}
+	if p.cache == nil {
+		p.cache = map[string]*mediaInfo{}
+	}
+	p.cache[path] = info
+	return info, nil
+}
+
+// speed 层的播放速度
+func (l *Layer) speed() float64 {
+	if l.SpeedFactor > 0 {
+		return l.SpeedFactor
+	}
+	return 1
+}
+
+// layerTiming 视频层的时间参数，时长均为秒
+type layerTiming struct {
+	cutFrom float64 // 源视频的起点
+	readLen float64 // 从源视频读取的时长
+	speed   float64
+	played  float64 // 变速后的播放时长
+	loop    bool
+}
+
+// inputArgs 返回截取源视频的输入参数
+func (t *layerTiming) inputArgs() KwArgs {
+	args := KwArgs{"t": seconds(t.readLen)}
+	if t.cutFrom > 0 {
+		args["ss"] = seconds(t.cutFrom)
+	}
+	return args
+}
+
+// sourceDuration 层截取后、变速前的时长，没有指定 cutTo 时使用探测到的素材时长
+func (e *Editly) sourceDuration(layer *Layer) (float64, error) {
+	if layer.CutTo > 0 {
+		if layer.CutTo <= layer.CutFrom {
+			return 0, fmt.Errorf("cutTo 必须大于 cutFrom")
+		}
+		return layer.CutTo - layer.CutFrom, nil
+	}
+	info, err := e.prober.get(layer.Path)
+	if err != nil {
+		return 0, err
+	}
+	if info.Duration <= layer.CutFrom {
+		return 0, fmt.Errorf("cutFrom %s 超出素材时长 %s", seconds(layer.CutFrom), seconds(info.Duration))
+	}
+	return info.Duration - layer.CutFrom, nil
+}
+
+// videoTiming 计算视频层在指定片段时长内的时间参数
+func (e *Editly) videoTiming(layer *Layer, duration float64) (*layerTiming, error) {
+	if layer.SpeedFactor < 0 {
+		return nil, fmt.Errorf("speedFactor 必须大于 0")
+	}
+	source, err := e.sourceDuration(layer)
+	if err != nil {
+		return nil, err
+	}
+	t := &layerTiming{cutFrom: layer.CutFrom, readLen: source, speed: layer.speed(), loop: layer.Loop}
+	// 片段比素材短时只读取需要的部分
+	if need := duration * t.speed; need < t.readLen {
+		t.readLen = need
+		t.loop = false
+	}
+	t.played = t.readLen / t.speed
+	return t, nil
+}
+
+// clipDuration 片段时长：依次取片段时长、第一个视频层的播放时长、voice 层语音结束的时间、默认时长
+func (e *Editly) clipDuration(clip *Clip) (float64, error) {
+	if clip.Duration > 0 {
+		return clip.Duration, nil
+	}
+	for _, layer := range clip.Layers {
+		if layer.Type == "video" && layer.Path != "" {
+			source, err := e.sourceDuration(layer)
+			if err != nil {
+				return 0, err
+			}
+			return source / layer.speed(), nil
+		}
+	}
+	if speech, err := e.speechDuration(clip); err != nil {
+		return 0, err
+	} else if speech > 0 {
+		return speech, nil
+	}
+	if e.spec.Defaults != nil && e.spec.Defaults.Duration > 0 {
+		return e.spec.Defaults.Duration, nil
+	}
+	return defaultClipDuration, nil
+}
+
+// atempoChain 将速度拆分为 atempo 支持的 0.5~2 倍的乘积
+func atempoChain(speed float64) []float64 {
+	var chain []float64
+	for speed > 2 {
+		chain = append(chain, 2)
+		speed /= 2
+	}
+	for speed < 0.5 {
+		chain = append(chain, 0.5)
+		speed /= 0.5
+	}
+	if math.Abs(speed-1) > 1e-9 {
+		chain = append(chain, speed)
+	}
+	return chain
+}
+
+// canvas 返回片段的画布：指定时长、尺寸和帧率的纯色视频
+func (e *Editly) canvas(color string, duration float64) *Stream {
+	return Input(fmt.Sprintf("color=c=%s:s=%dx%d:r=%d", color, e.spec.Width, e.spec.Height, e.spec.Fps),
+		KwArgs{"f": "lavfi", "t": seconds(duration)}).Video()
+}
+
+// silence 返回指定时长的静音
+func (e *Editly) silence(duration float64) *Stream {
+	return Input(fmt.Sprintf("anullsrc=r=%d:cl=stereo", editlySampleRate),
+		KwArgs{"f": "lavfi", "t": seconds(duration)}).Audio()
+}
+
+// fitFrame 按层的缩放模式将画面调整为画布大小，统一帧率和像素宽高比
+func (e *Editly) fitFrame(s *Stream, layer *Layer) (*Stream, error) {
+	s, err := resizeFrame(s, layer.ResizeMode, e.spec.Width, e.spec.Height)
+	if err != nil {
+		return nil, err
+	}
+	return s.Filter("setsar", Args{"1"}).Filter("fps", Args{strconv.Itoa(e.spec.Fps)}), nil
+}
+
+// normalizeAudio 统一采样率和声道布局，并补齐或截断到片段时长
+func normalizeAudio(s *Stream, duration float64) *Stream {
+	return s.Filter("aresample", Args{strconv.Itoa(editlySampleRate)}).
+		Filter("aformat", nil, KwArgs{"sample_fmts": "fltp", "channel_layouts": "stereo"}).
+		Filter("apad", nil).
+		Filter("atrim", nil, KwArgs{"duration": seconds(duration)}).
+		Filter("asetpts", Args{"PTS-STARTPTS"})
+}
+
+// openVideo 打开视频层的输入并调整播放速度，返回的画面还需要缩放和补齐时长（见 extendVideo）
+func (e *Editly) openVideo(id string, layer *Layer, duration float64) (*Stream, *layerTiming, error) {
+	timing, err := e.videoTiming(layer, duration)
+	if err != nil {
+		return nil, nil, err
+	}
+	pts := "PTS-STARTPTS"
+	if timing.speed != 1 {
+		pts = fmt.Sprintf("(PTS-STARTPTS)/%s", seconds(timing.speed))
+	}
+	return Input(layer.Path, timing.inputArgs()).Video().Filter("setpts@"+id, Args{pts}), timing, nil
+}
+
+// extendVideo 视频比片段短时循环播放或定格在最后一帧，s 需要已经统一帧率
+func (e *Editly) extendVideo(s *Stream, timing *layerTiming, duration float64) *Stream {
+	if timing.played >= duration {
+		return s
+	}
+	if timing.loop {
+		frames := int(math.Ceil(timing.played * float64(e.spec.Fps)))
+		return s.Filter("loop", nil, KwArgs{"loop": "-1", "size": strconv.Itoa(frames), "start": "0"}).
+			Filter("trim", nil, KwArgs{"duration": seconds(duration)})
+	}
+	// 素材不够长时定格在最后一帧
+	return s.Filter("tpad", nil, KwArgs{"stop_mode": "clone", "stop_duration": seconds(duration - timing.played)})
+}
+
+// renderLayer 渲染一个层，返回需要叠加到画布上的画面。
+// 相同输入上的相同滤镜会合并为同一个节点，所以每条滤镜链以带实例名 id 的 setpts 开头，
+// 同一素材在多个片段中使用时也各自占用一条链
+func (e *Editly) renderLayer(id string, layer *Layer, duration float64) (*Stream, error) {
+	switch layer.Type {
+	case "video":
+		if layer.ZoomDirection != "" {
+			return nil, fmt.Errorf("zoomDirection 只能用于图片层")
+		}
+		s, timing, err := e.openVideo(id, layer, duration)
+		if err != nil {
+			return nil, err
+		}
+		if s, err = e.fitFrame(s, layer); err != nil {
+			return nil, err
+		}
+		return e.extendVideo(s, timing, duration), nil
+	case "image":
+		input := Input(layer.Path, KwArgs{"loop": "1", "framerate": strconv.Itoa(e.spec.Fps), "t": seconds(duration)})
+		s := input.Video().Filter("setpts@"+id, Args{"PTS-STARTPTS"})
+		if layer.ZoomDirection != "" {
+			return e.kenBurns(s, layer, duration)
+		}
+		return e.fitFrame(s, layer)
+	}
+	return nil, fmt.Errorf("不支持的层类型: %s", layer.Type)
+}
+
+// clipAudio 片段的原声：第一个有音轨的视频层，没有时为静音。id 的作用同 renderLayer
+func (e *Editly) clipAudio(id string, clip *Clip, duration float64) (*Stream, error) {
+	for _, layer := range clip.Layers {
+		if layer.Type != "video" || layer.Path == "" {
+			continue
+		}
+		info, err := e.prober.get(layer.Path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.HasAudio {
+			continue
+		}
+		timing, err := e.videoTiming(layer, duration)
+		if err != nil {
+			return nil, err
+		}
+		s := Input(layer.Path, timing.inputArgs()).Audio().Filter("asetpts@"+id, Args{"PTS-STARTPTS"})
+		for _, tempo := range atempoChain(timing.speed) {
+			s = s.Filter("atempo", Args{seconds(tempo)})
+		}
+		if timing.loop && timing.played < duration {
+			samples := int(math.Ceil(timing.played * editlySampleRate))
+			s = s.Filter("aresample", Args{strconv.Itoa(editlySampleRate)}).
+				Filter("aloop", nil, KwArgs{"loop": "-1", "size": strconv.Itoa(samples)})
+		}
+		// 不循环时 normalizeAudio 用静音补齐
+		return normalizeAudio(s, duration), nil
+	}
+	return e.silence(duration), nil
+}
+
+// renderClip 渲染一个片段：各层按声明顺序叠加到画布上。
+// 片段没有背景层时使用默认背景，第一个层是背景层时直接作为画布
+func (e *Editly) renderClip(index int, clip *Clip, duration float64) (*Stream, error) {
+	video := e.canvas("black", duration)
+	if background := e.defaultBackground(); background != nil && !hasBackground(clip) {
+		s, err := e.renderBackground(fmt.Sprintf("c%dbg", index), background, duration)
+		if err != nil {
+			return nil, fmt.Errorf("defaults.background: %w", err)
+		}
+		video = s
+	}
+	for j, layer := range clip.Layers {
+		id := fmt.Sprintf("c%dl%d", index, j)
+		if isBackgroundLayer(layer.Type) {
+			s, err := e.renderBackground(id, layer, duration)
+			if err != nil {
+				return nil, fmt.Errorf("clips[%d].layers[%d]: %w", index, j, err)
+			}
+			if j == 0 {
+				video = s
+			} else {
+				video = video.Overlay(s, "pass")
+			}
+			continue
+		}
+		if isTextLayer(layer.Type) {
+			// 文字直接绘制在已叠加的画面上
+			s, err := e.drawText(video, id, layer, duration)
+			if err != nil {
+				return nil, fmt.Errorf("clips[%d].layers[%d]: %w", index, j, err)
+			}
+			video = s
+			continue
+		}
+		if layer.Type == "overlay-video" || layer.Type == "overlay-image" {
+			s, args, err := e.renderOverlay(id, layer, duration)
+			if err != nil {
+				return nil, fmt.Errorf("clips[%d].layers[%d]: %w", index, j, err)
+			}
+			video = video.Overlay(s, "pass", args)
+			continue
+		}
+		s, err := e.renderLayer(id, layer, duration)
+		if err != nil {
+			return nil, fmt.Errorf("clips[%d].layers[%d]: %w", index, j, err)
+		}
+		video = video.Overlay(s, "pass")
+	}
+	return video.Filter("format", Args{"yuv420p"}), nil
+}
+
+// Build 根据 EditSpec 构建完整的 FFmpeg 命令，返回的输出流可以通过 GetArgs 查看参数，Run 执行渲染
+func (e *Editly) Build() (*Stream, error) {
+	spec, err := e.resolveForBuild()
+	if err != nil {
+		return nil, err
+	}
+	return (&Editly{spec: spec, prober: e.prober}).build()
+}
+
+// resolveForBuild 检查片段数量和输出尺寸后合并默认值
+func (e *Editly) resolveForBuild() (*EditSpec, error) {
+	if len(e.spec.Clips) == 0 {
+		return nil, fmt.Errorf("至少需要一个片段")
+	}
+	if e.spec.Width <= 0 || e.spec.Height <= 0 || e.spec.Fps <= 0 {
+		return nil, fmt.Errorf("无效的输出尺寸或帧率: %dx%d@%d", e.spec.Width, e.spec.Height, e.spec.Fps)
+	}
+	return e.Resolve()
+}
+
+// timeline 各片段的时长和片段之间的转场
+func (e *Editly) timeline() ([]float64, []*Transition, error) {
+	durations := make([]float64, len(e.spec.Clips))
+	for i, clip := range e.spec.Clips {
+		duration, err := e.clipDuration(clip)
+		if err != nil {
+			return nil, nil, fmt.Errorf("clips[%d]: %w", i, err)
+		}
+		durations[i] = duration
+	}
+	transitions, err := e.transitions(durations)
+	if err != nil {
+		return nil, nil, err
+	}
+	return durations, transitions, nil
+}
+
+// setAudioDefault 编码预设没有设置音频参数 option（如 ar、ac，包括 ar:a 这样带流说明符的写法）时设置为 value
+func setAudioDefault(args KwArgs, option, value string) {
+	if _, ok := args[option]; ok {
+		return
+	}
+	if _, ok := args[streamOptionKey(option, SpecType(StreamTypeAudio))]; ok {
+		return
+	}
+	args[option] = value
+}
+
+// build 根据合并了默认值的规范构建命令
+func (e *Editly) build() (*Stream, error) {
+	durations, transitions, err := e.timeline()
+	if err != nil {
+		return nil, err
+	}
+	total := timelineDuration(durations, transitions)
+
+	var videos, audios []*Stream
+	for i, clip := range e.spec.Clips {
+		video, err := e.renderClip(i, clip, durations[i])
+		if err != nil {
+			return nil, err
+		}
+		videos = append(videos, video)
+		if e.spec.KeepSourceAudio {
+			audio, err := e.clipAudio(fmt.Sprintf("c%d", i), clip, durations[i])
+			if err != nil {
+				return nil, fmt.Errorf("clips[%d]: %w", i, err)
+			}
+			audios = append(audios, audio)
+		}
+	}
+	video, audio := joinClips(videos, audios, durations, transitions)
+
+	// 背景音乐、旁白与原声混音，时长以视频为准
+	audio, err = e.mixAudio(audio, total)
+	if err != nil {
+		return nil, err
+	}
+
+	preset, err := e.encodingPreset()
+	if err != nil {
+		return nil, err
+	}
+	// 画布超过编码预设的分辨率时缩小成片
+	if width, height := preset.FitSize(e.spec.Width, e.spec.Height); width != e.spec.Width || height != e.spec.Height {
+		video = video.Filter("scale", Args{strconv.Itoa(width), strconv.Itoa(height)})
+	}
+	encodingArgs, err := preset.OutputArgs()
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range e.outputArgs {
+		encodingArgs[k] = v
+	}
+	encodingArgs["r"] = strconv.Itoa(e.spec.Fps)
+	streams := []*Stream{video}
+	if audio != nil {
+		streams = append(streams, audio)
+		// 混音后统一输出采样率和声道布局
+		setAudioDefault(encodingArgs, "ar", strconv.Itoa(editlySampleRate))
+		setAudioDefault(encodingArgs, "ac", "2")
+	}
+	return Output(streams, e.spec.OutPath, encodingArgs).OverWriteOutput(), nil
+}
@@ -0,0 +1,160 @@
+package ffmpeg_go
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestEditly 使用固定的素材信息，测试不依赖 ffprobe
+func newTestEditly(spec *EditSpec, media map[string]*mediaInfo) *Editly {
+	e := NewEditly(spec)
+	e.prober.probe = func(path string) (*mediaInfo, error) {
+		if info, ok := media[path]; ok {
+			return info, nil
+		}
+		return &mediaInfo{Duration: 10, HasVideo: true}, nil
+	}
+	return e
+}
+
+func TestEditlyBuild(t *testing.T) {
+	spec := &EditSpec{
+		OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25,
+		Clips: []*Clip{
+			{Layers: []*Layer{{Type: "video", Path: "a.mp4"}}},
+			{Layers: []*Layer{{Type: "image", Path: "b.png"}}},
+		},
+		AudioTracks:     []*AudioTrack{{Path: "music.mp3"}},
+		KeepSourceAudio: true,
+	}
+	stream, err := newTestEditly(spec, map[string]*mediaInfo{
+		"a.mp4": {Duration: 3.5, HasVideo: true, HasAudio: true},
+	}).Build()
+	assert.Nil(t, err)
+	args := stream.GetArgs()
+	cmd := strings.Join(args, " ")
+
+	assert.Contains(t, cmd, "-t 3.5 -i a.mp4")
+	assert.Contains(t, cmd, "-framerate 25 -loop 1 -t 4 -i b.png")
+	assert.Contains(t, cmd, "-f lavfi -t 4 -i anullsrc=r=48000:cl=stereo")
+	graph := args[indexOfArg(args, "-filter_complex")+1]
+	assert.Contains(t, graph, "scale=640:360:force_original_aspect_ratio=decrease")
+	assert.Contains(t, graph, "overlay=eof_action=pass")
+	assert.Contains(t, graph, "concat=a=1:n=2:v=1")
+	assert.Contains(t, graph, "amix=duration=first:inputs=2:normalize=0")
+	assert.Contains(t, graph, "atrim=duration=7.5")
+	assert.Equal(t, "out.mp4", args[len(args)-2])
+	assert.Equal(t, "-y", args[len(args)-1])
+	assert.Contains(t, cmd, "-c:v libx264")
+	assert.Contains(t, cmd, "-r 25")
+}
+
+func TestEditlyEncodingResolution(t *testing.T) {
+	build := func(width, height int) string {
+		spec := &EditSpec{
+			OutPath: "out.mp4", Width: width, Height: height, Fps: 25, Encoding: "web-720p",
+			Clips: []*Clip{{Layers: []*Layer{{Type: "image", Path: "a.png"}}}},
+		}
+		stream, err := newTestEditly(spec, nil).Build()
+		assert.Nil(t, err)
+		args := stream.GetArgs()
+		return args[indexOfArg(args, "-filter_complex")+1]
+	}
+	// 1080p 画布按预设缩小为 720p，不超过预设的画布不缩放
+	assert.Contains(t, build(1920, 1080), "format=yuv420p[s6];[s6]scale=1280:720[s7]")
+	assert.NotContains(t, build(1280, 720), "scale=1280:720[")
+}
+
+func TestEditlyEncodingAudioArgs(t *testing.T) {
+	// web-1080p 设置了 ar:a 和 ac:a，不再重复设置 ar 和 ac
+	spec := &EditSpec{
+		OutPath: "out.mp4", Width: 1280, Height: 720, Fps: 25, Encoding: "web-1080p",
+		Clips:       []*Clip{{Layers: []*Layer{{Type: "image", Path: "a.png"}}}},
+		AudioTracks: []*AudioTrack{{Path: "music.mp3"}},
+	}
+	stream, err := newTestEditly(spec, nil).Build()
+	assert.Nil(t, err)
+	args := stream.GetArgs()
+	assert.Equal(t, -1, indexOfArg(args, "-ar"))
+	assert.Equal(t, -1, indexOfArg(args, "-ac"))
+	assert.Equal(t, "48000", args[indexOfArg(args, "-ar:a")+1])
+	assert.Equal(t, "2", args[indexOfArg(args, "-ac:a")+1])
+
+	// 预设没有设置时使用混音的采样率和声道数
+	spec.Encoding = ""
+	stream, err = newTestEditly(spec, nil).Build()
+	assert.Nil(t, err)
+	args = stream.GetArgs()
+	assert.Equal(t, "48000", args[indexOfArg(args, "-ar")+1])
+	assert.Equal(t, "2", args[indexOfArg(args, "-ac")+1])
+}
+
+func TestEditlyBuildErrors(t *testing.T) {
+	_, err := newTestEditly(&EditSpec{OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25}, nil).Build()
+	assert.NotNil(t, err)
+	_, err = newTestEditly(&EditSpec{OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25,
+		Clips: []*Clip{{Layers: []*Layer{{Type: "unknown"}}}}}, nil).Build()
+	assert.EqualError(t, err, "clips[0].layers[0]: 不支持的层类型: unknown")
+}
+
+func indexOfArg(args []string, arg string) int {
+	for i, a := range args {
+		if a == arg {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestEditlyTiming(t *testing.T) {
+	spec := &EditSpec{
+		OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25, KeepSourceAudio: true,
+		Defaults: &Defaults{Duration: 2},
+		Clips: []*Clip{
+			// 3~8 秒两倍速，播放 2.5 秒
+			{Layers: []*Layer{{Type: "video", Path: "a.mp4", CutFrom: 3, CutTo: 8, SpeedFactor: 2}}},
+			// 素材 1 秒，循环到 3 秒
+			{Duration: 3, Layers: []*Layer{{Type: "video", Path: "short.mp4", Loop: true}}},
+			// 素材 1 秒，定格到 2 秒
+			{Duration: 2, Layers: []*Layer{{Type: "video", Path: "short.mp4"}}},
+			{Layers: []*Layer{{Type: "image", Path: "b.png"}}},
+		},
+	}
+	e := newTestEditly(spec, map[string]*mediaInfo{
+		"a.mp4":     {Duration: 20, HasVideo: true, HasAudio: true},
+		"short.mp4": {Duration: 1, HasVideo: true, HasAudio: true},
+	})
+	var durations []float64
+	for _, clip := range spec.Clips {
+		d, err := e.clipDuration(clip)
+		assert.Nil(t, err)
+		durations = append(durations, d)
+	}
+	assert.Equal(t, []float64{2.5, 3, 2, 2}, durations)
+
+	stream, err := e.Build()
+	assert.Nil(t, err)
+	args := stream.GetArgs()
+	cmd := strings.Join(args, " ")
+	assert.Contains(t, cmd, "-ss 3 -t 5 -i a.mp4")
+	assert.Contains(t, cmd, "-t 1 -i short.mp4")
+	graph := args[indexOfArg(args, "-filter_complex")+1]
+	assert.Contains(t, graph, "setpts@c0l0=(PTS-STARTPTS)/2")
+	assert.Contains(t, graph, "atempo=2")
+	assert.Contains(t, graph, "loop=loop=-1:size=25:start=0")
+	assert.Contains(t, graph, "aloop=loop=-1:size=48000")
+	assert.Contains(t, graph, "tpad=stop_duration=1:stop_mode=clone")
+	assert.Contains(t, graph, "concat=a=1:n=4:v=1")
+
+	_, err = newTestEditly(&EditSpec{OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25,
+		Clips: []*Clip{{Layers: []*Layer{{Type: "video", Path: "a.mp4", CutFrom: 5, CutTo: 3}}}}}, nil).Build()
+	assert.NotNil(t, err)
+}
+
+func TestAtempoChain(t *testing.T) {
+	assert.Equal(t, []float64(nil), atempoChain(1))
+	assert.Equal(t, []float64{2, 2, 1.25}, atempoChain(5))
+	assert.Equal(t, []float64{0.5, 0.5}, atempoChain(0.25))
+}
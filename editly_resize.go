@@ -0,0 +1,87 @@
+package ffmpeg_go
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// kenBurnsScale Ken Burns 效果先放大到画布的倍数再缩放平移，减少 zoompan 取整造成的抖动
+const kenBurnsScale = 2
+
+// defaultZoomAmount Ken Burns 默认的缩放比例
+const defaultZoomAmount = 0.1
+
+// resizeModes 视频层和图片层支持的缩放模式
+var resizeModes = []string{"contain", "contain-blur", "cover", "stretch"}
+
+// zoomDirections 图片层 Ken Burns 效果支持的方向
+var zoomDirections = []string{"in", "out", "left", "right"}
+
+// resizeFrame 按缩放模式将画面调整为指定大小：
+// contain 等比缩放后补黑边，contain-blur 用模糊的画面填充空白，cover 等比放大后裁剪，stretch 拉伸
+func resizeFrame(s *Stream, mode string, width, height int) (*Stream, error) {
+	w, h := strconv.Itoa(width), strconv.Itoa(height)
+	switch mode {
+	case "", "contain":
+		return s.Filter("scale", Args{w, h}, KwArgs{"force_original_aspect_ratio": "decrease"}).
+			Filter("pad", Args{w, h, "(ow-iw)/2", "(oh-ih)/2"}), nil
+	case "contain-blur":
+		split := s.Split()
+		radius := height / 40
+		if radius < 1 {
+			radius = 1
+		}
+		background := split.Get("0").
+			Filter("scale", Args{w, h}, KwArgs{"force_original_aspect_ratio": "increase"}).
+			Filter("crop", Args{w, h}).
+			Filter("boxblur", Args{strconv.Itoa(radius)})
+		foreground := split.Get("1").Filter("scale", Args{w, h}, KwArgs{"force_original_aspect_ratio": "decrease"})
+		return background.Overlay(foreground, "", KwArgs{"x": "(W-w)/2", "y": "(H-h)/2"}), nil
+	case "cover":
+		return s.Filter("scale", Args{w, h}, KwArgs{"force_original_aspect_ratio": "increase"}).
+			Filter("crop", Args{w, h}), nil
+	case "stretch":
+		return s.Filter("scale", Args{w, h}), nil
+	}
+	return nil, fmt.Errorf("不支持的缩放模式: %s", mode)
+}
+
+// kenBurns 图片层的 Ken Burns 效果：zoompan 按输出帧率逐帧缩放或平移，持续整个片段
+func (e *Editly) kenBurns(s *Stream, layer *Layer, duration float64) (*Stream, error) {
+	amount := layer.ZoomAmount
+	if amount <= 0 {
+		amount = defaultZoomAmount
+	}
+	// 图片输入按输出帧率循环，on 为从 0 开始的输出帧序号，progress 从第一帧的 0 到最后一帧的 1
+	frames := int(math.Ceil(duration * float64(e.spec.Fps)))
+	progress := fmt.Sprintf("on/%d", int(math.Max(float64(frames-1), 1)))
+	zoom, x, y := "", "iw/2-iw/zoom/2", "ih/2-ih/zoom/2"
+	switch layer.ZoomDirection {
+	case "in":
+		zoom = fmt.Sprintf("1+%s*%s", seconds(amount), progress)
+	case "out":
+		zoom = fmt.Sprintf("1+%s*(1-%s)", seconds(amount), progress)
+	case "left":
+		zoom = seconds(1 + amount)
+		x = fmt.Sprintf("(iw-iw/zoom)*(1-%s)", progress)
+	case "right":
+		zoom = seconds(1 + amount)
+		x = fmt.Sprintf("(iw-iw/zoom)*%s", progress)
+	default:
+		return nil, fmt.Errorf("不支持的 zoomDirection: %s", layer.ZoomDirection)
+	}
+
+	s, err := resizeFrame(s, layer.ResizeMode, e.spec.Width*kenBurnsScale, e.spec.Height*kenBurnsScale)
+	if err != nil {
+		return nil, err
+	}
+	return s.Filter("zoompan", nil, KwArgs{
+		"z":   zoom,
+		"x":   x,
+		"y":   y,
+		"d":   "1",
+		"s":   fmt.Sprintf("%dx%d", e.spec.Width, e.spec.Height),
+		"fps": strconv.Itoa(e.spec.Fps),
+	}).Filter("setsar", Args{"1"}), nil
+}
@@ -0,0 +1,136 @@
+package ffmpeg_go
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditlyResizeModes(t *testing.T) {
+	spec := &EditSpec{
+		OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25,
+		Clips: []*Clip{
+			{Duration: 2, Layers: []*Layer{{Type: "video", Path: "a.mp4", ResizeMode: "cover"}}},
+			{Duration: 2, Layers: []*Layer{{Type: "image", Path: "b.png", ResizeMode: "contain-blur"}}},
+			{Duration: 2, Layers: []*Layer{{Type: "image", Path: "c.png", ResizeMode: "stretch"}}},
+		},
+	}
+	stream, err := newTestEditly(spec, nil).Build()
+	assert.Nil(t, err)
+	args := stream.GetArgs()
+	graph := args[indexOfArg(args, "-filter_complex")+1]
+	assert.Contains(t, graph, "scale=640:360:force_original_aspect_ratio=increase")
+	assert.Contains(t, graph, "crop=640:360")
+	assert.Contains(t, graph, "split=2")
+	assert.Contains(t, graph, "boxblur=9")
+	assert.Contains(t, graph, "overlay=eof_action=repeat:x=(W-w)/2:y=(H-h)/2")
+	assert.NotContains(t, graph, "pad=")
+
+	spec.Clips[0].Layers[0].ResizeMode = "fill"
+	_, err = newTestEditly(spec, nil).Build()
+	assert.EqualError(t, err, "clips[0].layers[0]: 不支持的缩放模式: fill")
+}
+
+func TestEditlyKenBurns(t *testing.T) {
+	spec := &EditSpec{
+		OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25,
+		Clips: []*Clip{
+			{Duration: 4, Layers: []*Layer{{Type: "image", Path: "a.png", ZoomDirection: "in"}}},
+			{Duration: 2, Layers: []*Layer{{Type: "image", Path: "b.png", ZoomDirection: "left", ZoomAmount: 0.2, ResizeMode: "cover"}}},
+		},
+	}
+	stream, err := newTestEditly(spec, nil).Build()
+	assert.Nil(t, err)
+	args := stream.GetArgs()
+	graph := args[indexOfArg(args, "-filter_complex")+1]
+	assert.Contains(t, graph, "scale=1280:720:force_original_aspect_ratio=decrease")
+	assert.Contains(t, graph, "zoompan=d=1:fps=25:s=640x360:x=iw/2-iw/zoom/2:y=ih/2-ih/zoom/2:z=1+0.1*on/99")
+	assert.Contains(t, graph, "crop=1280:720")
+	assert.Contains(t, graph, "zoompan=d=1:fps=25:s=640x360:x=(iw-iw/zoom)*(1-on/49):y=ih/2-ih/zoom/2:z=1.2")
+
+	spec.Clips[0].Layers[0].ZoomDirection = "up"
+	_, err = newTestEditly(spec, nil).Build()
+	assert.EqualError(t, err, "clips[0].layers[0]: 不支持的 zoomDirection: up")
+	spec.Clips[0].Layers[0] = &Layer{Type: "video", Path: "a.mp4", ZoomDirection: "in"}
+	_, err = newTestEditly(spec, nil).Build()
+	assert.EqualError(t, err, "clips[0].layers[0]: zoomDirection 只能用于图片层")
+}
+
+// evalArithmetic evaluates an expression of numbers, "on", + - * / and parentheses, enough for zoompan zoom values.
+func evalArithmetic(expr string, on float64) float64 {
+	pos := 0
+	var sum, product, factor func() float64
+	factor = func() float64 {
+		if expr[pos] == '(' {
+			pos++
+			v := sum()
+			pos++ // ')'
+			return v
+		}
+		if strings.HasPrefix(expr[pos:], "on") {
+			pos += 2
+			return on
+		}
+		start := pos
+		for pos < len(expr) && (expr[pos] == '.' || expr[pos] >= '0' && expr[pos] <= '9') {
+			pos++
+		}
+		v, _ := strconv.ParseFloat(expr[start:pos], 64)
+		return v
+	}
+	product = func() float64 {
+		v := factor()
+		for pos < len(expr) && (expr[pos] == '*' || expr[pos] == '/') {
+			op := expr[pos]
+			pos++
+			if op == '*' {
+				v *= factor()
+			} else {
+				v /= factor()
+			}
+		}
+		return v
+	}
+	sum = func() float64 {
+		v := product()
+		for pos < len(expr) && (expr[pos] == '+' || expr[pos] == '-') {
+			op := expr[pos]
+			pos++
+			if op == '+' {
+				v += product()
+			} else {
+				v -= product()
+			}
+		}
+		return v
+	}
+	return sum()
+}
+
+func TestEditlyKenBurnsFrames(t *testing.T) {
+	// zoompan numbers output frames from on=0, the first frame starts at the first zoom and the last frame of the clip
+	// (on=frames-1) reaches the end
+	zoomRegexp := regexp.MustCompile(`zoompan=[^;\[]*:z=([^:;\[]+)`)
+	for _, c := range []struct {
+		direction   string
+		first, last float64
+	}{
+		{"in", 1, 1.1},
+		{"out", 1.1, 1},
+	} {
+		spec := &EditSpec{
+			OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25,
+			Clips: []*Clip{{Duration: 4, Layers: []*Layer{{Type: "image", Path: "a.png", ZoomDirection: c.direction}}}},
+		}
+		stream, err := newTestEditly(spec, nil).Build()
+		assert.Nil(t, err)
+		args := stream.GetArgs()
+		m := zoomRegexp.FindStringSubmatch(args[indexOfArg(args, "-filter_complex")+1])
+		assert.NotNil(t, m, c.direction)
+		assert.InDelta(t, c.first, evalArithmetic(m[1], 0), 1e-9, c.direction)
+		assert.InDelta(t, c.last, evalArithmetic(m[1], 4*25-1), 1e-9, c.direction)
+	}
+}
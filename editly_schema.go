@@ -0,0 +1,327 @@
+package ffmpeg_go
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// schemaRequired 各类型的必填字段。Layer 也用于 defaults，所以层类型由 Validate 检查
+var schemaRequired = map[string][]string{
+	"EditSpec":   {"clips"},
+	"Clip":       {"layers"},
+	"AudioTrack": {"path"},
+}
+
+// positionPresets 位置预设名称
+func positionPresets() []string {
+	var presets []string
+	for _, vertical := range []string{"top", "center", "bottom"} {
+		presets = append(presets, vertical, vertical+"-left", vertical+"-right")
+	}
+	return presets
+}
+
+// schemaStringForms 除对象外还可以写为字符串的类型，见各类型的 UnmarshalJSON
+func schemaStringForms() map[string]map[string]interface{} {
+	return map[string]map[string]interface{}{
+		"Position":   {"type": "string", "enum": positionPresets()},
+		"AudioTrack": {"type": "string"},
+	}
+}
+
+// schemaRules 字段的取值约束，按 "类型名.JSON 字段名" 索引
+func schemaRules() map[string]map[string]interface{} {
+	nonNegative := map[string]interface{}{"minimum": 0.0}
+	unit := map[string]interface{}{"minimum": 0.0, "maximum": 1.0}
+	positive := map[string]interface{}{"minimum": 1.0}
+
+	var transitionNames []string
+	for name := range xfadeTransitions {
+		transitionNames = append(transitionNames, name)
+	}
+	transitionNames = append(transitionNames, "none")
+	sort.Strings(transitionNames)
+	var easings []string
+	for easing := range transitionCurves {
+		if easing != "" {
+			easings = append(easings, easing)
+		}
+	}
+	sort.Strings(easings)
+
+	return map[string]map[string]interface{}{
+		"EditSpec.width":            positive,
+		"EditSpec.height":           positive,
+		"EditSpec.fps":              positive,
+		"EditSpec.clips":            {"minItems": 1},
+		"EditSpec.clipsAudioVolume": nonNegative,
+		"EditSpec.encoding":         {"enum": EncodingPresetNames()},
+		"EditSpec.parallel":         nonNegative,
+		"Defaults.duration":         nonNegative,
+		"Defaults.layerType":        {"propertyNames": map[string]interface{}{"enum": editlyLayerTypes()}},
+		"Clip.duration":             nonNegative,
+		"Clip.subtitles":            {"enum": []string{"auto"}},
+		"Layer.type":                {"enum": editlyLayerTypes()},
+		"Layer.cutFrom":             nonNegative,
+		"Layer.cutTo":               nonNegative,
+		"Layer.speedFactor":         nonNegative,
+		"Layer.resizeMode":          {"enum": resizeModes},
+		"Layer.zoomDirection":       {"enum": zoomDirections},
+		"Layer.zoomAmount":          nonNegative,
+		"Layer.width":               nonNegative,
+		"Layer.height":              nonNegative,
+		"Layer.opacity":             unit,
+		"Layer.start":               nonNegative,
+		"Layer.stop":                nonNegative,
+		"Layer.cornerRadius":        nonNegative,
+		"Layer.borderWidth":         nonNegative,
+		"Layer.colors":              {"maxItems": maxGradientColors},
+		"Layer.fontSize":            nonNegative,
+		"Layer.align":               {"enum": []string{"left", "center", "right"}},
+		"Layer.animation":           {"enum": []string{"none", "fade", "slide"}},
+		"Position.originX":          {"enum": []string{"left", "center", "right"}},
+		"Position.originY":          {"enum": []string{"top", "center", "bottom"}},
+		"Transition.name":           {"enum": transitionNames},
+		"Transition.duration":       nonNegative,
+		"Transition.easing":         {"enum": easings},
+		"AudioTrack.mixVolume":      nonNegative,
+		"AudioTrack.start":          nonNegative,
+		"AudioTrack.cutFrom":        nonNegative,
+		"AudioTrack.cutTo":          nonNegative,
+		"AudioTrack.fadeIn":         nonNegative,
+		"AudioTrack.fadeOut":        nonNegative,
+		"AudioNorm.gaussSize":       {"minimum": 0.0, "maximum": 301.0},
+		"AudioNorm.maxGain":         {"minimum": 0.0, "maximum": 100.0},
+		"AudioDucking.threshold":    unit,
+		"AudioDucking.ratio":        {"minimum": 0.0, "maximum": 20.0},
+		"AudioDucking.attack":       nonNegative,
+		"AudioDucking.release":      nonNegative,
+	}
+}
+
+// schemaGenerator 按 Go 类型和 json 标签生成 JSON Schema，结构体放在 $defs 中按名称引用
+type schemaGenerator struct {
+	defs        map[string]interface{}
+	rules       map[string]map[string]interface{}
+	stringForms map[string]map[string]interface{}
+}
+
+func (g *schemaGenerator) schemaOf(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.schemaOf(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		name := t.Name()
+		if _, ok := g.defs[name]; !ok {
+			// 先占位，结构体可以引用自身
+			g.defs[name] = nil
+			g.defs[name] = g.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	}
+	return map[string]interface{}{}
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" || field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		property := g.schemaOf(field.Type)
+		for key, value := range g.rules[t.Name()+"."+name] {
+			property[key] = value
+		}
+		properties[name] = property
+	}
+	schema := map[string]interface{}{"type": "object", "properties": properties, "additionalProperties": false}
+	if required, ok := schemaRequired[t.Name()]; ok {
+		schema["required"] = required
+	}
+	if stringForm, ok := g.stringForms[t.Name()]; ok {
+		return map[string]interface{}{"oneOf": []interface{}{stringForm, schema}}
+	}
+	return schema
+}
+
+// EditSpecSchema 返回由 EditSpec 类型生成的 JSON Schema（draft 2020-12），未知字段不允许出现
+func EditSpecSchema() map[string]interface{} {
+	g := &schemaGenerator{defs: map[string]interface{}{}, rules: schemaRules(), stringForms: schemaStringForms()}
+	root := g.schemaOf(reflect.TypeOf(EditSpec{}))
+	return map[string]interface{}{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   "EditSpec",
+		"$ref":    root["$ref"],
+		"$defs":   g.defs,
+	}
+}
+
+// jsonType JSON 值的类型名称，整数的数值为 integer
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// matchType 判断 JSON 值是否符合 schema 的类型，整数也是 number
+func matchType(expected, actual string) bool {
+	return expected == "" || expected == actual || expected == "number" && actual == "integer"
+}
+
+// joinPath 拼接对象字段的路径
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// validateSchema 按 EditSpecSchema 生成的 schema 检查 JSON 值，只支持生成时用到的关键字。null 与未设置相同
+func validateSchema(errs *ValidationErrors, path string, schema, defs map[string]interface{}, value interface{}) {
+	if value == nil {
+		return
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		schema = defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+	}
+	actual := jsonType(value)
+	if alternatives, ok := schema["oneOf"].([]interface{}); ok {
+		var types []string
+		for _, alternative := range alternatives {
+			s := alternative.(map[string]interface{})
+			if matchType(s["type"].(string), actual) {
+				validateSchema(errs, path, s, defs, value)
+				return
+			}
+			types = append(types, s["type"].(string))
+		}
+		errs.add(path, "类型应为 %s，实际为 %s", strings.Join(types, " 或 "), actual)
+		return
+	}
+	if expected, _ := schema["type"].(string); !matchType(expected, actual) {
+		errs.add(path, "类型应为 %s，实际为 %s", expected, actual)
+		return
+	}
+
+	switch v := value.(type) {
+	case string:
+		if enum, ok := schema["enum"].([]string); ok && !containsString(enum, v) {
+			errs.add(path, "不支持的值: %s", v)
+		}
+	case float64:
+		if minimum, ok := schema["minimum"].(float64); ok && v < minimum {
+			errs.add(path, "不能小于 %s: %s", formatNumber(minimum), formatNumber(v))
+		}
+		if maximum, ok := schema["maximum"].(float64); ok && v > maximum {
+			errs.add(path, "不能大于 %s: %s", formatNumber(maximum), formatNumber(v))
+		}
+	case []interface{}:
+		if minItems, ok := schema["minItems"].(int); ok && len(v) < minItems {
+			errs.add(path, "至少需要 %d 项", minItems)
+		}
+		if maxItems, ok := schema["maxItems"].(int); ok && len(v) > maxItems {
+			errs.add(path, "最多 %d 项，实际为 %d", maxItems, len(v))
+		}
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range v {
+				validateSchema(errs, fmt.Sprintf("%s[%d]", path, i), items, defs, item)
+			}
+		}
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]string)
+		for _, name := range required {
+			if _, ok := v[name]; !ok {
+				errs.add(joinPath(path, name), "缺少必填字段")
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if names, ok := schema["propertyNames"].(map[string]interface{}); ok {
+				validateSchema(errs, joinPath(path, key), names, defs, key)
+			}
+			if property, ok := properties[key]; ok {
+				validateSchema(errs, joinPath(path, key), property.(map[string]interface{}), defs, v[key])
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case bool:
+				if !additional {
+					errs.add(joinPath(path, key), "未知字段")
+				}
+			case map[string]interface{}:
+				validateSchema(errs, joinPath(path, key), additional, defs, v[key])
+			}
+		}
+	}
+}
+
+// ValidateEditSpecJSON 按 EditSpecSchema 检查已解析的 JSON 值，如 json.Unmarshal 到 interface{} 的结果
+func ValidateEditSpecJSON(value interface{}) ValidationErrors {
+	schema := EditSpecSchema()
+	var errs ValidationErrors
+	if value == nil {
+		errs.add("", "编辑规范不能为空")
+		return errs
+	}
+	validateSchema(&errs, "", schema, schema["$defs"].(map[string]interface{}), value)
+	return errs
+}
+
+// ParseEditSpec 按 EditSpecSchema 严格解析 JSON 编辑规范：未知字段、类型和取值范围错误都会返回 ValidationErrors
+func ParseEditSpec(data []byte) (*EditSpec, error) {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("解析编辑规范失败: %w", err)
+	}
+	if errs := ValidateEditSpecJSON(value); len(errs) > 0 {
+		return nil, errs
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var spec EditSpec
+	if err := decoder.Decode(&spec); err != nil {
+		return nil, fmt.Errorf("解析编辑规范失败: %w", err)
+	}
+	return &spec, nil
+}
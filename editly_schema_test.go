@@ -0,0 +1,73 @@
+package ffmpeg_go
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditSpecSchema(t *testing.T) {
+	schema := EditSpecSchema()
+	assert.Equal(t, "#/$defs/EditSpec", schema["$ref"])
+	defs := schema["$defs"].(map[string]interface{})
+	layer := defs["Layer"].(map[string]interface{})
+	assert.Equal(t, false, layer["additionalProperties"])
+	assert.Nil(t, layer["required"])
+	properties := layer["properties"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"type": "number", "minimum": 0.0, "maximum": 1.0}, properties["opacity"])
+	assert.Contains(t, properties["type"].(map[string]interface{})["enum"], "overlay-video")
+	assert.Equal(t, map[string]interface{}{"$ref": "#/$defs/Position"}, properties["position"])
+	// 位置和音轨也可以写为字符串
+	assert.Len(t, defs["Position"].(map[string]interface{})["oneOf"], 2)
+	assert.Len(t, defs["AudioTrack"].(map[string]interface{})["oneOf"], 2)
+
+	// 可以序列化为 JSON 提供给客户端
+	_, err := json.Marshal(schema)
+	assert.Nil(t, err)
+}
+
+func TestParseEditSpec(t *testing.T) {
+	spec, err := ParseEditSpec([]byte(`{
+		"outPath": "out.mp4", "width": 640, "height": 360, "fps": 25,
+		"clips": [{"layers": [{"type": "title", "text": "a", "position": "top"}]}],
+		"audioTracks": ["music.mp3", {"path": "voice.mp3", "voiceOver": true}]
+	}`))
+	assert.Nil(t, err)
+	assert.Equal(t, &Position{Preset: "top"}, spec.Clips[0].Layers[0].Position)
+	assert.Equal(t, "music.mp3", spec.AudioTracks[0].Path)
+	assert.True(t, spec.AudioTracks[1].VoiceOver)
+
+	_, err = ParseEditSpec([]byte(`{
+		"width": 640.5, "fps": "25", "colour": "red",
+		"defaults": {"layerType": {"titel": {}}},
+		"clips": [{"layers": [
+			{"type": "video", "path": "a.mp4", "cutFrom": -1, "resizeMode": "fill"},
+			{"type": "title", "text": "a", "position": {"x": 0.5, "originX": "middle"}},
+			{"type": "linear-gradient", "colors": ["a", "b", "c", "d", "e", "f", "g", "h", "i"]}
+		], "transition": {"name": "swirl"}}],
+		"audioTracks": [1, {"mixVolume": 1}]
+	}`))
+	errs, ok := err.(ValidationErrors)
+	assert.True(t, ok)
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	assert.Equal(t, []string{
+		"audioTracks[0] 类型应为 string 或 object，实际为 integer",
+		"audioTracks[1].path 缺少必填字段",
+		"clips[0].layers[0].cutFrom 不能小于 0: -1",
+		"clips[0].layers[0].resizeMode 不支持的值: fill",
+		"clips[0].layers[1].position.originX 不支持的值: middle",
+		"clips[0].layers[2].colors 最多 8 项，实际为 9",
+		"clips[0].transition.name 不支持的值: swirl",
+		"colour 未知字段",
+		"defaults.layerType.titel 不支持的值: titel",
+		"fps 类型应为 integer，实际为 string",
+		"width 类型应为 integer，实际为 number",
+	}, messages)
+
+	_, err = ParseEditSpec([]byte(`{"clips": `))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,214 @@
+package ffmpeg_go
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/u2takey/ffmpeg-go/utils"
+)
+
+// segmentRanges 在没有转场的片段边界把时间轴切分为最多 n 段，各段时长尽量接近。
+// 返回每段的片段范围 [from, to)，转场两侧的片段总在同一段中
+func segmentRanges(durations []float64, transitions []*Transition, n int) [][2]int {
+	target := timelineDuration(durations, transitions) / float64(n)
+	var ranges [][2]int
+	from, length := 0, 0.0
+	for i, duration := range durations {
+		length += duration
+		last := i == len(durations)-1
+		if !last && transitions[i] != nil {
+			length -= transitions[i].Duration
+			continue
+		}
+		if last || length >= target && len(ranges) < n-1 {
+			ranges = append(ranges, [2]int{from, i + 1})
+			from, length = i+1, 0
+		}
+	}
+	return ranges
+}
+
+// segmentPlan 并行渲染的命令：各段的画面、整条时间轴的音频和最后的无损拼接
+type segmentPlan struct {
+	segments []*Stream
+	audio    *Stream // 没有音频时为 nil
+	concat   *Stream
+	listPath string // concat demuxer 的列表文件
+	list     string
+}
+
+// buildSegments 构建分段渲染的命令，中间文件放在 workDir 中。时间轴只有一段时返回 nil。
+// 各段只渲染画面，使用相同的编码参数；音频按整条时间轴单独渲染一次，保证拼接处连续
+func (e *Editly) buildSegments(workDir string) (*segmentPlan, error) {
+	spec, err := e.resolveForBuild()
+	if err != nil {
+		return nil, err
+	}
+	r := &Editly{spec: spec, prober: e.prober}
+	durations, transitions, err := r.timeline()
+	if err != nil {
+		return nil, err
+	}
+	ranges := segmentRanges(durations, transitions, e.spec.Parallel)
+	if len(ranges) < 2 {
+		return nil, nil
+	}
+
+	ext := filepath.Ext(spec.OutPath)
+	plan := &segmentPlan{listPath: filepath.Join(workDir, "segments.txt")}
+	var list strings.Builder
+	for i, clips := range ranges {
+		segment := *spec
+		segment.Clips = spec.Clips[clips[0]:clips[1]]
+		segment.OutPath = filepath.Join(workDir, fmt.Sprintf("segment-%03d%s", i, ext))
+		segment.KeepSourceAudio, segment.AudioTracks, segment.AudioNorm, segment.Ducking = false, nil, nil, nil
+		stream, err := (&Editly{spec: &segment, prober: e.prober}).build()
+		if err != nil {
+			return nil, fmt.Errorf("第 %d 段: %w", i, err)
+		}
+		plan.segments = append(plan.segments, stream)
+		path, err := filepath.Abs(segment.OutPath)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&list, "file '%s'\n", strings.ReplaceAll(path, "'", `'\''`))
+	}
+	plan.list = list.String()
+
+	audioPath := filepath.Join(workDir, "audio"+ext)
+	plan.audio, err = r.buildAudio(durations, transitions, audioPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// 各段已按相同参数编码，拼接时直接复制码流，只保留容器参数
+	args := KwArgs{"c": "copy"}
+	encodingArgs, err := r.encodingArgs()
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range encodingArgs {
+		if !strings.Contains(k, ":") {
+			args[k] = v
+		}
+	}
+	streams := []*Stream{Input(plan.listPath, KwArgs{"f": "concat", "safe": "0"}).Video()}
+	if plan.audio != nil {
+		streams = append(streams, Input(audioPath).Audio())
+	}
+	plan.concat = Output(streams, spec.OutPath, args).OverWriteOutput()
+	return plan, nil
+}
+
+// buildAudio 构建只渲染整条时间轴音频的命令，没有音频时返回 nil
+func (e *Editly) buildAudio(durations []float64, transitions []*Transition, outPath string) (*Stream, error) {
+	var clipsAudio *Stream
+	if e.spec.KeepSourceAudio {
+		var audios []*Stream
+		for i, clip := range e.spec.Clips {
+			audio, err := e.clipAudio(fmt.Sprintf("c%d", i), clip, durations[i])
+			if err != nil {
+				return nil, fmt.Errorf("clips[%d]: %w", i, err)
+			}
+			audios = append(audios, audio)
+		}
+		clipsAudio = joinAudio(audios, transitions)
+	}
+	audio, err := e.mixAudio(clipsAudio, timelineDuration(durations, transitions))
+	if err != nil || audio == nil {
+		return nil, err
+	}
+
+	encodingArgs, err := e.encodingArgs()
+	if err != nil {
+		return nil, err
+	}
+	args := KwArgs{}
+	for k, v := range encodingArgs {
+		if !strings.HasSuffix(k, ":v") {
+			args[k] = v
+		}
+	}
+	setAudioDefault(args, "ar", fmt.Sprint(editlySampleRate))
+	setAudioDefault(args, "ac", "2")
+	return Output([]*Stream{audio}, outPath, args).OverWriteOutput(), nil
+}
+
+// renderSegments 并行渲染各段画面和音频，再用 concat demuxer 无损拼接。时间轴无法切分时按单个进程渲染
+func (e *Editly) renderSegments() error {
+	workDir, err := os.MkdirTemp(filepath.Dir(e.spec.OutPath), ".editly-segments-")
+	if err != nil {
+		return fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	plan, err := e.buildSegments(workDir)
+	if err != nil {
+		return fmt.Errorf("构建FFmpeg命令失败: %w", err)
+	}
+	if plan == nil {
+		stream, err := e.Build()
+		if err != nil {
+			return fmt.Errorf("构建FFmpeg命令失败: %w", err)
+		}
+		return stream.Run()
+	}
+	if err := os.WriteFile(plan.listPath, []byte(plan.list), 0644); err != nil {
+		return fmt.Errorf("写入分段列表失败: %w", err)
+	}
+
+	jobs := plan.segments
+	if plan.audio != nil {
+		jobs = append(jobs, plan.audio)
+	}
+	if e.spec.Verbose {
+		log.Printf("分 %d 段并行渲染，并发数: %d", len(plan.segments), e.spec.Parallel)
+	}
+	// 渲染时间不确定，不设置任务超时
+	pool := utils.NewGoroutinePool(
+		utils.WithMinWorkers(int32(e.spec.Parallel)),
+		utils.WithMaxWorkers(int32(e.spec.Parallel)),
+		utils.WithTaskQueueSize(len(jobs)),
+		utils.WithTaskTimeout(0),
+	)
+	pool.Start()
+	defer pool.Stop()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(jobs))
+	for i, job := range jobs {
+		i, job := i, job
+		wg.Add(1)
+		err := pool.SubmitFunc(func() error {
+			defer wg.Done()
+			if e.spec.Verbose {
+				log.Printf("执行FFmpeg命令: ffmpeg %s", strings.Join(job.GetArgs(), " "))
+			}
+			errs[i] = job.Run()
+			return errs[i]
+		})
+		if err != nil {
+			wg.Done()
+			errs[i] = err
+		}
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		if i == len(plan.segments) {
+			return fmt.Errorf("渲染音频失败: %w", err)
+		}
+		return fmt.Errorf("渲染第 %d 段失败: %w", i, err)
+	}
+
+	if e.spec.Verbose {
+		log.Printf("执行FFmpeg命令: ffmpeg %s", strings.Join(plan.concat.GetArgs(), " "))
+	}
+	return plan.concat.Run()
+}
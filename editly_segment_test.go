@@ -0,0 +1,80 @@
+package ffmpeg_go
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSegmentRanges(t *testing.T) {
+	fade := &Transition{Name: "fade", Duration: 1}
+	// 只在没有转场的边界切分
+	assert.Equal(t, [][2]int{{0, 3}, {3, 4}},
+		segmentRanges([]float64{3, 3, 3, 3}, []*Transition{nil, fade, nil}, 2))
+	assert.Equal(t, [][2]int{{0, 2}, {2, 4}},
+		segmentRanges([]float64{3, 3, 3, 3}, []*Transition{nil, nil, nil}, 2))
+	assert.Equal(t, [][2]int{{0, 1}, {1, 2}, {2, 3}, {3, 4}},
+		segmentRanges([]float64{3, 3, 3, 3}, []*Transition{nil, nil, nil}, 8))
+	assert.Equal(t, [][2]int{{0, 3}},
+		segmentRanges([]float64{3, 3, 3}, []*Transition{fade, fade}, 4))
+}
+
+func TestEditlyBuildSegments(t *testing.T) {
+	spec := &EditSpec{
+		OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25, Parallel: 2,
+		Clips: []*Clip{
+			{Duration: 3, Layers: []*Layer{{Type: "video", Path: "a.mp4"}}},
+			{Duration: 3, Transition: &Transition{Name: "wipeleft", Duration: 1}, Layers: []*Layer{{Type: "image", Path: "b.png"}}},
+			{Duration: 3, Layers: []*Layer{{Type: "image", Path: "c.png"}}},
+			{Duration: 3, Layers: []*Layer{{Type: "image", Path: "d.png"}}},
+		},
+		AudioTracks:     []*AudioTrack{{Path: "music.mp3"}},
+		KeepSourceAudio: true,
+	}
+	workDir := t.TempDir()
+	plan, err := newTestEditly(spec, map[string]*mediaInfo{
+		"a.mp4": {Duration: 10, HasVideo: true, HasAudio: true},
+	}).buildSegments(workDir)
+	assert.Nil(t, err)
+
+	// 转场两侧的片段在同一段中，各段只有画面
+	assert.Len(t, plan.segments, 2)
+	first := strings.Join(plan.segments[0].GetArgs(), " ")
+	assert.Contains(t, first, "-i b.png")
+	assert.Contains(t, first, "xfade=duration=1:offset=5:transition=wipeleft")
+	assert.NotContains(t, first, "d.png")
+	assert.NotContains(t, first, "amix")
+	assert.Contains(t, first, "-c:v libx264")
+	assert.Contains(t, first, filepath.Join(workDir, "segment-000.mp4"))
+	second := strings.Join(plan.segments[1].GetArgs(), " ")
+	assert.Contains(t, second, "-i d.png")
+	assert.NotContains(t, second, "c.png")
+
+	// 音频按整条时间轴渲染一次
+	audio := strings.Join(plan.audio.GetArgs(), " ")
+	assert.Contains(t, audio, "-i a.mp4")
+	assert.Contains(t, audio, "acrossfade=c1=tri:c2=tri:d=1")
+	assert.Contains(t, audio, "concat=a=1:n=2:v=0")
+	assert.Contains(t, audio, "amix=duration=first:inputs=2:normalize=0")
+	assert.Contains(t, audio, "atrim=duration=11")
+	assert.NotContains(t, audio, "-c:v")
+	assert.Contains(t, audio, filepath.Join(workDir, "audio.mp4"))
+
+	list, err := filepath.Abs(filepath.Join(workDir, "segment-001.mp4"))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, strings.Count(plan.list, "file '"))
+	assert.Contains(t, plan.list, "file '"+list+"'\n")
+	concat := strings.Join(plan.concat.GetArgs(), " ")
+	assert.Contains(t, concat, "-f concat -safe 0 -i "+plan.listPath)
+	assert.Contains(t, concat, "-c copy")
+	assert.True(t, strings.HasSuffix(concat, "out.mp4 -y"))
+
+	// 不能切分时按单个进程渲染
+	spec.Parallel = 8
+	spec.Clips = spec.Clips[1:3]
+	plan, err = newTestEditly(spec, nil).buildSegments(workDir)
+	assert.Nil(t, err)
+	assert.Nil(t, plan)
+}
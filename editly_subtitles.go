@@ -0,0 +1,89 @@
+package ffmpeg_go
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/u2takey/ffmpeg-go/subtitles"
+)
+
+// speechSpan 一段旁白文本和它在片段中的显示时间
+type speechSpan struct {
+	text        string
+	start, stop float64
+}
+
+// subtitleLineWidth 按画布宽度和字幕字号估算一条字幕最多的列数（中日韩字符占两列），不超过 subtitles.DefaultMaxLineWidth
+func subtitleLineWidth(width, fontSize int) int {
+	if fontSize <= 0 {
+		return subtitles.DefaultMaxLineWidth
+	}
+	// 与 drawText 相同，画布左右各留 5% 的边距，半角字符宽度按字号的 0.6 倍估算
+	columns := int(float64(width-2*(width/20)) / (float64(fontSize) * 0.6))
+	if columns > subtitles.DefaultMaxLineWidth {
+		return subtitles.DefaultMaxLineWidth
+	}
+	if columns < 8 {
+		return 8
+	}
+	return columns
+}
+
+// autoSubtitles 为 subtitles 为 auto 的片段生成字幕层：旁白文本按标点和行宽拆分为字幕条，按字数分配到语音时间内。
+// 有 narration 时在各 voice 层的语音时间内（没有 voice 层时在整个片段中）显示 narration，否则显示各 voice 层的文本。
+// clip 需要已经确定时长
+func (e *Editly) autoSubtitles(clip *Clip) ([]*Layer, error) {
+	switch clip.Subtitles {
+	case "":
+		return nil, nil
+	case "auto":
+	default:
+		return nil, fmt.Errorf("不支持的字幕模式: %s，只能为 auto", clip.Subtitles)
+	}
+
+	var spans []speechSpan
+	for _, layer := range clip.Layers {
+		if layer.Type != "voice" || layer.Start >= clip.Duration {
+			continue
+		}
+		_, duration, err := e.speech(layer)
+		if err != nil {
+			return nil, err
+		}
+		spans = append(spans, speechSpan{text: layer.Text, start: layer.Start, stop: math.Min(layer.Start+duration, clip.Duration)})
+	}
+	if strings.TrimSpace(clip.Narration) != "" {
+		narration := speechSpan{text: clip.Narration, start: 0, stop: clip.Duration}
+		if len(spans) > 0 {
+			narration.start, narration.stop = spans[0].start, spans[0].stop
+			for _, span := range spans[1:] {
+				narration.start = math.Min(narration.start, span.start)
+				narration.stop = math.Max(narration.stop, span.stop)
+			}
+		}
+		spans = []speechSpan{narration}
+	}
+	if len(spans) == 0 {
+		return nil, fmt.Errorf("自动字幕需要 narration 或 voice 层")
+	}
+
+	style := e.resolveLayer(&Layer{Type: "subtitle"})
+	maxWidth := subtitleLineWidth(e.spec.Width, style.FontSize)
+	var layers []*Layer
+	for _, span := range spans {
+		texts := subtitles.SplitText(span.text, maxWidth)
+		for _, cue := range subtitles.LayoutCues(texts, secondsDuration(span.start), secondsDuration(span.stop)) {
+			layer := e.resolveLayer(&Layer{Type: "subtitle", Text: cue.Text})
+			layer.Start, layer.Stop = cue.Start.Seconds(), cue.End.Seconds()
+			layers = append(layers, layer)
+		}
+	}
+	return layers, nil
+}
+
+// secondsDuration 将秒数转换为 time.Duration
+func secondsDuration(v float64) time.Duration {
+	return time.Duration(math.Round(v * float64(time.Second)))
+}
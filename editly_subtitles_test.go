@@ -0,0 +1,58 @@
+package ffmpeg_go
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditlyAutoSubtitles(t *testing.T) {
+	SetSpeechSynthesizer(&fakeSynthesizer{})
+	defer SetSpeechSynthesizer(nil)
+
+	spec := &EditSpec{
+		OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25,
+		Clips: []*Clip{
+			{Duration: 5, Subtitles: "auto", Layers: []*Layer{{Type: "voice", Text: "你好。欢迎观看！", Start: 1}}},
+			{Duration: 2, Subtitles: "auto", Narration: "Hello world"},
+		},
+	}
+	e := newTestEditly(spec, map[string]*mediaInfo{
+		"speech-你好。欢迎观看！.wav": {Duration: 3, HasAudio: true},
+	})
+
+	// 字幕按字数分配到语音时间内，没有 voice 层时在整个片段中显示
+	resolved, err := e.Resolve()
+	assert.Nil(t, err)
+	var cues [][3]interface{}
+	for _, clip := range resolved.Clips {
+		assert.Empty(t, clip.Subtitles)
+		for _, layer := range clip.Layers {
+			assert.Equal(t, "subtitle", layer.Type)
+			assert.Equal(t, 18, layer.FontSize)
+			cues = append(cues, [3]interface{}{layer.Text, layer.Start, layer.Stop})
+		}
+	}
+	assert.Equal(t, [][3]interface{}{{"你好", 1.0, 2.0}, {"欢迎观看！", 2.0, 4.0}, {"Hello world", 0.0, 2.0}}, cues)
+
+	stream, err := e.Build()
+	assert.Nil(t, err)
+	args := strings.Join(stream.GetArgs(), " ")
+	assert.Contains(t, args, "enable=between(t\\,1\\,2)")
+	assert.Contains(t, args, "enable=between(t\\,0\\,2)")
+	assert.Empty(t, e.Validate())
+
+	spec.Clips[0].Subtitles = "manual"
+	spec.Clips[1].Narration = ""
+	assert.Equal(t, ValidationErrors{
+		{Path: "clips[0].subtitles", Message: "不支持的字幕模式: manual，只能为 auto"},
+		{Path: "clips[1].subtitles", Message: "自动字幕需要 narration 或 voice 层"},
+	}, e.Validate())
+}
+
+func TestSubtitleLineWidth(t *testing.T) {
+	assert.Equal(t, 42, subtitleLineWidth(1920, 54))
+	assert.Equal(t, 16, subtitleLineWidth(1080, 96))
+	assert.Equal(t, 8, subtitleLineWidth(320, 200))
+}
@@ -0,0 +1,328 @@
+package ffmpeg_go
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 模板变量的类型
+const (
+	TemplateString  = "string"
+	TemplateNumber  = "number"
+	TemplateColor   = "color"
+	TemplatePath    = "path"
+	TemplateBoolean = "boolean"
+)
+
+var (
+	templatePlaceholder  = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+	templateVariableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	templateName         = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+	// ffmpeg 的颜色：名称或 #RRGGBB[AA]、0xRRGGBB[AA]，可加 @透明度
+	templateColor = regexp.MustCompile(`^([A-Za-z]+|(#|0x)[0-9A-Fa-f]{6}([0-9A-Fa-f]{2})?)(@[0-9.]+)?$`)
+)
+
+// TemplateVariable 模板变量的声明
+type TemplateVariable struct {
+	Type        string      `json:"type"`                  // string、number、color、path、boolean
+	Default     interface{} `json:"default,omitempty"`     // 默认值，为空时渲染必须提供
+	Description string      `json:"description,omitempty"` // 说明
+	Min         *float64    `json:"min,omitempty"`         // number 的最小值
+	Max         *float64    `json:"max,omitempty"`         // number 的最大值
+}
+
+// EditTemplate 参数化的编辑规范。规范的字符串中可以使用 {{变量}}，
+// 整个字符串只有一个变量时替换为变量的值，数字和布尔变量因此可以用在时长等字段中；
+// 变量嵌在其他文字中时替换为变量的文本
+type EditTemplate struct {
+	Name        string                       `json:"name"`
+	Description string                       `json:"description,omitempty"`
+	Variables   map[string]*TemplateVariable `json:"variables,omitempty"`
+	Spec        json.RawMessage              `json:"spec"` // 含 {{变量}} 的编辑规范
+}
+
+// ParseEditTemplate 严格解析模板，不允许未知字段，并检查模板是否有效
+func ParseEditTemplate(data []byte) (*EditTemplate, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var t EditTemplate
+	if err := decoder.Decode(&t); err != nil {
+		return nil, fmt.Errorf("解析模板失败: %w", err)
+	}
+	if errs := t.Validate(); len(errs) > 0 {
+		return nil, errs
+	}
+	return &t, nil
+}
+
+// convert 检查变量的值并转换为替换使用的值，数字和布尔值也接受字符串形式
+func (v *TemplateVariable) convert(value interface{}) (interface{}, error) {
+	switch v.Type {
+	case TemplateNumber:
+		var n float64
+		switch x := value.(type) {
+		case float64:
+			n = x
+		case int:
+			n = float64(x)
+		case string:
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(x), 64)
+			if err != nil {
+				return nil, fmt.Errorf("必须是数字: %s", x)
+			}
+			n = parsed
+		default:
+			return nil, fmt.Errorf("必须是数字")
+		}
+		if v.Min != nil && n < *v.Min {
+			return nil, fmt.Errorf("不能小于 %s", formatNumber(*v.Min))
+		}
+		if v.Max != nil && n > *v.Max {
+			return nil, fmt.Errorf("不能大于 %s", formatNumber(*v.Max))
+		}
+		return n, nil
+	case TemplateBoolean:
+		switch x := value.(type) {
+		case bool:
+			return x, nil
+		case string:
+			if b, err := strconv.ParseBool(x); err == nil {
+				return b, nil
+			}
+		}
+		return nil, fmt.Errorf("必须是布尔值")
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		return nil, fmt.Errorf("必须是字符串")
+	}
+	switch v.Type {
+	case TemplateColor:
+		if !templateColor.MatchString(s) {
+			return nil, fmt.Errorf("不是有效的颜色: %s", s)
+		}
+	case TemplatePath:
+		if s == "" {
+			return nil, fmt.Errorf("路径不能为空")
+		}
+	}
+	return s, nil
+}
+
+// sample 没有默认值的变量在检查模板时使用的值
+func (v *TemplateVariable) sample() interface{} {
+	switch v.Type {
+	case TemplateNumber:
+		if v.Min != nil {
+			return *v.Min
+		}
+		if v.Max != nil && *v.Max < 1 {
+			return *v.Max
+		}
+		return 1.0
+	case TemplateBoolean:
+		return false
+	case TemplateColor:
+		return "black"
+	case TemplatePath:
+		return "template"
+	}
+	return "template"
+}
+
+// variableNames 按名称排序的变量
+func (t *EditTemplate) variableNames() []string {
+	var names []string
+	for name := range t.Variables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// walkTemplate 按路径遍历解析出的规范中的字符串，fn 返回替换后的值
+func walkTemplate(path string, value interface{}, fn func(path, s string) interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			v[key] = walkTemplate(joinPath(path, key), v[key], fn)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = walkTemplate(fmt.Sprintf("%s[%d]", path, i), item, fn)
+		}
+	case string:
+		return fn(path, v)
+	}
+	return value
+}
+
+// Validate 检查模板：名称、变量声明和默认值，规范只能使用声明过的变量，
+// 并用默认值（没有默认值时用示例值）渲染一次，检查规范的结构
+func (t *EditTemplate) Validate() ValidationErrors {
+	var errs ValidationErrors
+	if !templateName.MatchString(t.Name) {
+		errs.add("name", "只能包含字母、数字、下划线和连字符: %q", t.Name)
+	}
+	values := map[string]interface{}{}
+	for _, name := range t.variableNames() {
+		path := "variables." + name
+		v := t.Variables[name]
+		if !templateVariableName.MatchString(name) {
+			errs.add(path, "变量名只能包含字母、数字和下划线，且不能以数字开头")
+			continue
+		}
+		if v == nil {
+			errs.add(path, "缺少变量声明")
+			continue
+		}
+		switch v.Type {
+		case TemplateString, TemplateNumber, TemplateColor, TemplatePath, TemplateBoolean:
+		default:
+			errs.add(path+".type", "必须是 string、number、color、path 或 boolean: %q", v.Type)
+			continue
+		}
+		if (v.Min != nil || v.Max != nil) && v.Type != TemplateNumber {
+			errs.add(path, "只有 number 变量可以设置 min 和 max")
+		}
+		if v.Min != nil && v.Max != nil && *v.Min > *v.Max {
+			errs.add(path, "min 不能大于 max")
+		}
+		if v.Default == nil {
+			values[name] = v.sample()
+		} else if _, err := v.convert(v.Default); err != nil {
+			errs.add(path+".default", "%s", err)
+		} else {
+			values[name] = v.Default
+		}
+	}
+
+	var spec interface{}
+	if err := json.Unmarshal(t.Spec, &spec); err != nil {
+		errs.add("spec", "不是有效的 JSON: %s", err)
+		return errs
+	}
+	if _, ok := spec.(map[string]interface{}); !ok {
+		errs.add("spec", "必须是对象")
+		return errs
+	}
+	walkTemplate("spec", spec, func(path, s string) interface{} {
+		for _, match := range templatePlaceholder.FindAllStringSubmatch(s, -1) {
+			if _, ok := t.Variables[match[1]]; !ok {
+				errs.add(path, "使用了未声明的变量 %s", match[1])
+			}
+		}
+		return s
+	})
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if _, err := t.Render(values); err != nil {
+		if specErrs, ok := err.(ValidationErrors); ok {
+			for _, e := range specErrs {
+				errs.add(joinPath("spec", e.Path), "%s", e.Message)
+			}
+		} else {
+			errs.add("spec", "%s", err)
+		}
+	}
+	return errs
+}
+
+// resolveVariables 检查变量的值并合并默认值
+func (t *EditTemplate) resolveVariables(vars map[string]interface{}) (map[string]interface{}, ValidationErrors) {
+	var errs ValidationErrors
+	var unknown []string
+	for name := range vars {
+		if _, ok := t.Variables[name]; !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		errs.add("variables."+name, "未声明的变量")
+	}
+
+	values := map[string]interface{}{}
+	for _, name := range t.variableNames() {
+		v := t.Variables[name]
+		if v == nil {
+			errs.add("variables."+name, "缺少变量声明")
+			continue
+		}
+		value, ok := vars[name]
+		if !ok || value == nil {
+			if v.Default == nil {
+				errs.add("variables."+name, "缺少必填变量")
+				continue
+			}
+			value = v.Default
+		}
+		converted, err := v.convert(value)
+		if err != nil {
+			errs.add("variables."+name, "%s", err)
+			continue
+		}
+		values[name] = converted
+	}
+	return values, errs
+}
+
+// Render 检查变量并替换模板中的 {{变量}}，返回严格解析后的编辑规范。
+// 变量错误和规范错误都以 ValidationErrors 返回
+func (t *EditTemplate) Render(vars map[string]interface{}) (*EditSpec, error) {
+	values, errs := t.resolveVariables(vars)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	var spec interface{}
+	if err := json.Unmarshal(t.Spec, &spec); err != nil {
+		return nil, fmt.Errorf("解析模板规范失败: %w", err)
+	}
+	spec = walkTemplate("", spec, func(path, s string) interface{} {
+		for _, match := range templatePlaceholder.FindAllStringSubmatch(s, -1) {
+			if _, ok := values[match[1]]; !ok {
+				errs.add(path, "使用了未声明的变量 %s", match[1])
+			}
+		}
+		// 整个字符串只有一个变量时保留变量的类型
+		if match := templatePlaceholder.FindStringSubmatchIndex(s); match != nil && match[0] == 0 && match[1] == len(s) {
+			return values[s[match[2]:match[3]]]
+		}
+		return templatePlaceholder.ReplaceAllStringFunc(s, func(placeholder string) string {
+			switch value := values[templatePlaceholder.FindStringSubmatch(placeholder)[1]].(type) {
+			case float64:
+				return formatNumber(value)
+			default:
+				return fmt.Sprint(value)
+			}
+		})
+	})
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	data, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("生成编辑规范失败: %w", err)
+	}
+	return ParseEditSpec(data)
+}
+
+// RenderTemplate 用一组变量渲染模板，见 EditTemplate.Render
+func RenderTemplate(template *EditTemplate, vars map[string]interface{}) (*EditSpec, error) {
+	return template.Render(vars)
+}
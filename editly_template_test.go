@@ -0,0 +1,82 @@
+package ffmpeg_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testTemplate = `{
+	"name": "greeting",
+	"variables": {
+		"name":     {"type": "string"},
+		"photo":    {"type": "path"},
+		"color":    {"type": "color", "default": "0xd02a42"},
+		"duration": {"type": "number", "default": 3, "min": 1, "max": 10}
+	},
+	"spec": {
+		"outPath": "out/{{name}}.mp4", "width": 640, "height": 360, "fps": 25,
+		"clips": [
+			{"duration": "{{duration}}", "layers": [
+				{"type": "fill-color", "color": "{{ color }}"},
+				{"type": "title", "text": "你好，{{name}}！"}
+			]},
+			{"layers": [{"type": "image", "path": "{{photo}}"}]}
+		]
+	}
+}`
+
+func TestEditTemplateRender(t *testing.T) {
+	template, err := ParseEditTemplate([]byte(testTemplate))
+	assert.Nil(t, err)
+
+	spec, err := template.Render(map[string]interface{}{"name": "小明", "photo": "a.jpg", "duration": "4.5"})
+	assert.Nil(t, err)
+	assert.Equal(t, "out/小明.mp4", spec.OutPath)
+	assert.Equal(t, 4.5, spec.Clips[0].Duration)
+	assert.Equal(t, "0xd02a42", spec.Clips[0].Layers[0].Color)
+	assert.Equal(t, "你好，小明！", spec.Clips[0].Layers[1].Text)
+	assert.Equal(t, "a.jpg", spec.Clips[1].Layers[0].Path)
+
+	_, err = RenderTemplate(template, map[string]interface{}{"photo": "", "color": "not a color!", "duration": 20, "age": 3})
+	assert.Equal(t, ValidationErrors{
+		{Path: "variables.age", Message: "未声明的变量"},
+		{Path: "variables.color", Message: "不是有效的颜色: not a color!"},
+		{Path: "variables.duration", Message: "不能大于 10"},
+		{Path: "variables.name", Message: "缺少必填变量"},
+		{Path: "variables.photo", Message: "路径不能为空"},
+	}, err)
+}
+
+func TestEditTemplateValidate(t *testing.T) {
+	_, err := ParseEditTemplate([]byte(`{
+		"name": "bad name",
+		"variables": {
+			"size": {"type": "number", "default": "big"},
+			"flag": {"type": "boolean", "min": 1},
+			"text": {"type": "text"}
+		},
+		"spec": {"clips": [{"layers": [{"type": "title", "text": "{{text}} {{missing}}"}]}]}
+	}`))
+	assert.Equal(t, ValidationErrors{
+		{Path: "name", Message: `只能包含字母、数字、下划线和连字符: "bad name"`},
+		{Path: "variables.flag", Message: "只有 number 变量可以设置 min 和 max"},
+		{Path: "variables.size.default", Message: "必须是数字: big"},
+		{Path: "variables.text.type", Message: `必须是 string、number、color、path 或 boolean: "text"`},
+		{Path: "spec.clips[0].layers[0].text", Message: "使用了未声明的变量 missing"},
+	}, err)
+
+	// 用示例值渲染时发现规范本身的错误
+	_, err = ParseEditTemplate([]byte(`{
+		"name": "t",
+		"variables": {"d": {"type": "string"}},
+		"spec": {"clips": [{"duration": "{{d}}", "layers": []}], "unknown": 1}
+	}`))
+	assert.Equal(t, ValidationErrors{
+		{Path: "spec.clips[0].duration", Message: "类型应为 number，实际为 string"},
+		{Path: "spec.unknown", Message: "未知字段"},
+	}, err)
+
+	_, err = ParseEditTemplate([]byte(`{"name": "t", "spec": {}, "extra": true}`))
+	assert.NotNil(t, err)
+}
@@ -2,7 +2,6 @@ package ffmpeg_go
 
 import (
 	"testing"
-	"github.com/stretchr/testify/assert"
 )
 
 func TestEditlySimpleVideo(t *testing.T) {
@@ -0,0 +1,262 @@
+package ffmpeg_go
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// textStyle 文字层类型的默认样式
+type textStyle struct {
+	fontSize   float64 // 相对画布高度
+	position   string
+	background string
+	animation  string
+}
+
+var textLayerStyles = map[string]textStyle{
+	"title":         {fontSize: 0.1, position: "center", animation: "fade"},
+	"subtitle":      {fontSize: 0.05, position: "bottom", background: "black@0.5", animation: "none"},
+	"news-title":    {fontSize: 0.0625, position: "bottom-left", background: "0xd02a42", animation: "none"},
+	"lower-third":   {fontSize: 0.05, position: "bottom-left", background: "black@0.6", animation: "fade"},
+	"slide-in-text": {fontSize: 0.08, position: "center", animation: "slide"},
+}
+
+// EditlyFontFiles 未指定字体时依次查找的字体文件，优先选择支持中文的字体
+var EditlyFontFiles = []string{
+	"/usr/share/fonts/opentype/noto/NotoSansCJK-Regular.ttc",
+	"/usr/share/fonts/noto-cjk/NotoSansCJK-Regular.ttc",
+	"/usr/share/fonts/google-noto-cjk/NotoSansCJK-Regular.ttc",
+	"/usr/share/fonts/truetype/wqy/wqy-microhei.ttc",
+	"/usr/share/fonts/wqy-microhei/wqy-microhei.ttc",
+	"/System/Library/Fonts/PingFang.ttc",
+	"/System/Library/Fonts/STHeiti Medium.ttc",
+	"C:/Windows/Fonts/msyh.ttc",
+	"C:/Windows/Fonts/simhei.ttf",
+}
+
+// defaultCJKFontFamily 找不到字体文件时通过 fontconfig 选择的中文字体
+const defaultCJKFontFamily = "Noto Sans CJK SC"
+
+// textAnimationDuration 文字淡入淡出或滑入的时长（秒）
+const textAnimationDuration = 0.5
+
+// isTextLayer 判断是否为文字层
+func isTextLayer(layerType string) bool {
+	_, ok := textLayerStyles[layerType]
+	return ok
+}
+
+// isWideRune 判断字符是否按全角宽度显示
+func isWideRune(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		(r >= 0x3000 && r <= 0x303f) || (r >= 0xff00 && r <= 0xffef)
+}
+
+// hasWideRune 判断文本是否包含中日韩字符
+func hasWideRune(text string) bool {
+	for _, r := range text {
+		if isWideRune(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// textWidth 估算文本宽度：全角字符按字号计算，其余字符按字号的 0.6 倍计算
+func textWidth(text string, fontSize float64) float64 {
+	width := 0.0
+	for _, r := range text {
+		if isWideRune(r) {
+			width += fontSize
+		} else {
+			width += fontSize * 0.6
+		}
+	}
+	return width
+}
+
+// wrapText 按宽度折行：中日韩字符可以在任意位置断开，其余文字在空格处断开，保留文本中的换行
+func wrapText(text string, maxWidth, fontSize float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		// 拆分为不可断开的片段：单个全角字符、单词或空格
+		var tokens []string
+		word := ""
+		for _, r := range paragraph {
+			if isWideRune(r) || unicode.IsSpace(r) {
+				if word != "" {
+					tokens = append(tokens, word)
+					word = ""
+				}
+				tokens = append(tokens, string(r))
+				continue
+			}
+			word += string(r)
+		}
+		if word != "" {
+			tokens = append(tokens, word)
+		}
+
+		line, width := "", 0.0
+		for _, token := range tokens {
+			w := textWidth(token, fontSize)
+			if width+w > maxWidth && strings.TrimSpace(line) != "" {
+				lines = append(lines, strings.TrimRightFunc(line, unicode.IsSpace))
+				line, width = "", 0
+				if strings.TrimSpace(token) == "" {
+					continue
+				}
+			}
+			line += token
+			width += w
+		}
+		lines = append(lines, strings.TrimRightFunc(line, unicode.IsSpace))
+	}
+	return lines
+}
+
+// fontArgs 字体参数：依次使用字体文件、字体名称、找到的默认字体，中文文本最后回退到 fontconfig 的中文字体
+func fontArgs(layer *Layer) KwArgs {
+	if layer.FontPath != "" {
+		return KwArgs{"fontfile": escapeChars(layer.FontPath, "\\':")}
+	}
+	if layer.FontFamily != "" {
+		return KwArgs{"font": escapeChars(layer.FontFamily, "\\':")}
+	}
+	for _, path := range EditlyFontFiles {
+		if _, err := os.Stat(path); err == nil {
+			return KwArgs{"fontfile": escapeChars(path, "\\':")}
+		}
+	}
+	if hasWideRune(layer.Text) {
+		return KwArgs{"font": defaultCJKFontFamily}
+	}
+	return KwArgs{}
+}
+
+// textX 文字行的横坐标表达式
+func textX(align string, margin int) (string, error) {
+	switch align {
+	case "left":
+		return strconv.Itoa(margin), nil
+	case "center":
+		return "(w-text_w)/2", nil
+	case "right":
+		return fmt.Sprintf("w-text_w-%d", margin), nil
+	}
+	return "", fmt.Errorf("不支持的对齐方式: %s", align)
+}
+
+// drawText 将文字层逐行绘制到画面上，每行一个 drawtext 滤镜
+func (e *Editly) drawText(s *Stream, id string, layer *Layer, duration float64) (*Stream, error) {
+	if strings.TrimSpace(layer.Text) == "" {
+		return nil, fmt.Errorf("文字层缺少文本")
+	}
+	// 字号、颜色、位置和动画的默认值已由 Resolve 按层类型填充
+	fontSize, color, background, animation := layer.FontSize, layer.Color, layer.BackgroundColor, layer.Animation
+	if fontSize <= 0 {
+		return nil, fmt.Errorf("无效的字号: %d", fontSize)
+	}
+	preset := "center"
+	if layer.Position != nil {
+		preset = layer.Position.Preset
+	}
+	vertical, horizontal := "", ""
+	if preset != "" {
+		var err error
+		if vertical, horizontal, err = splitPreset(preset); err != nil {
+			return nil, err
+		}
+	}
+	align := layer.Align
+	if align == "" {
+		align = horizontal
+	}
+	if animation != "none" && animation != "fade" && animation != "slide" {
+		return nil, fmt.Errorf("不支持的文字动画: %s", animation)
+	}
+
+	// 画布四周留出 5% 的边距
+	marginX, marginY := e.spec.Width/20, e.spec.Height/20
+	border := 0
+	if background != "" {
+		border = fontSize / 4
+	}
+	lines := wrapText(layer.Text, float64(e.spec.Width-2*marginX-2*border), float64(fontSize))
+	lineHeight := int(math.Round(float64(fontSize) * 1.3))
+	if background != "" {
+		lineHeight += 2 * border
+	}
+	var top int
+	var x string
+	if preset == "" {
+		// 比例坐标：原点按 originX、originY 对齐文字块
+		fx, fy, err := layer.Position.originFactors()
+		if err != nil {
+			return nil, err
+		}
+		top = int(math.Round(layer.Position.Y*float64(e.spec.Height) - fy*float64(len(lines)*lineHeight)))
+		x = fmt.Sprintf("%d-%s*text_w", int(math.Round(layer.Position.X*float64(e.spec.Width))), formatNumber(fx))
+	} else {
+		top = marginY + border
+		switch vertical {
+		case "center":
+			top = (e.spec.Height - len(lines)*lineHeight) / 2
+		case "bottom":
+			top = e.spec.Height - marginY - border - len(lines)*lineHeight
+		}
+		var err error
+		if x, err = textX(align, marginX+border); err != nil {
+			return nil, err
+		}
+	}
+	// 设置了 start、stop 时只在这段时间内显示，动画从 start 开始计时
+	t, enable := "t", ""
+	if layer.Start > 0 || layer.Stop > 0 {
+		stop := duration
+		if layer.Stop > 0 {
+			stop = math.Min(layer.Stop, duration)
+		}
+		enable = fmt.Sprintf("between(t,%s,%s)", seconds(layer.Start), seconds(stop))
+		if layer.Start > 0 {
+			t = fmt.Sprintf("(t-%s)", seconds(layer.Start))
+		}
+		duration = stop - layer.Start
+	}
+	animationDuration := math.Min(textAnimationDuration, duration/4)
+	if animation == "slide" {
+		// 从画面左侧外滑入，减速停在目标位置
+		x = fmt.Sprintf("if(lt(%[3]s,%[1]s),(%[2]s+text_w)*(1-pow(1-%[3]s/%[1]s,2))-text_w,%[2]s)", seconds(animationDuration), x, t)
+	}
+
+	for i, line := range lines {
+		if line == "" {
+			continue
+		}
+		args := fontArgs(layer)
+		args["text"] = escapeChars(line, "\\':")
+		args["expansion"] = "none"
+		args["fontsize"] = strconv.Itoa(fontSize)
+		args["fontcolor"] = color
+		args["x"] = x
+		args["y"] = strconv.Itoa(top + i*lineHeight)
+		if background != "" {
+			args["box"] = "1"
+			args["boxcolor"] = background
+			args["boxborderw"] = strconv.Itoa(border)
+		}
+		if animation == "fade" {
+			args["alpha"] = fmt.Sprintf("if(lt(%[4]s,%[1]s),%[4]s/%[1]s,if(gt(%[4]s,%[2]s),(%[3]s-%[4]s)/%[1]s,1))",
+				seconds(animationDuration), seconds(duration-animationDuration), seconds(duration), t)
+		}
+		if enable != "" {
+			args["enable"] = enable
+		}
+		s = s.Filter(fmt.Sprintf("drawtext@%sn%d", id, i), nil, args)
+	}
+	return s, nil
+}
@@ -0,0 +1,59 @@
+package ffmpeg_go
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapText(t *testing.T) {
+	// 全角字符宽 10，半角字符宽 6
+	assert.Equal(t, []string{"一二三四", "五六"}, wrapText("一二三四五六", 40, 10))
+	assert.Equal(t, []string{"hello", "world foo"}, wrapText("hello world foo", 60, 10))
+	assert.Equal(t, []string{"ab中文", "", "cd"}, wrapText("ab中文\n\ncd", 100, 10))
+}
+
+func TestEditlyTextLayers(t *testing.T) {
+	fonts := EditlyFontFiles
+	EditlyFontFiles = nil
+	defer func() { EditlyFontFiles = fonts }()
+
+	spec := &EditSpec{
+		OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25,
+		Clips: []*Clip{
+			{Duration: 4, Layers: []*Layer{
+				{Type: "image", Path: "a.png"},
+				{Type: "title", Text: "你好，世界", Color: "yellow"},
+				{Type: "subtitle", Text: "Hello: it's 100%", FontPath: "C:/Windows/Fonts/msyh.ttc", FontSize: 20},
+			}},
+			{Duration: 2, Layers: []*Layer{{Type: "slide-in-text", Text: "slide", Position: &Position{Preset: "top-left"}}}},
+		},
+	}
+	stream, err := newTestEditly(spec, nil).Build()
+	assert.Nil(t, err)
+	args := stream.GetArgs()
+	graph := args[indexOfArg(args, "-filter_complex")+1]
+
+	// 中文标题：默认字号为画布高度的 1/10，居中，淡入淡出
+	assert.Contains(t, graph, "drawtext@c0l1n0=alpha=if(lt(t\\,0.5)\\,t/0.5\\,if(gt(t\\,3.5)\\,(4-t)/0.5\\,1)):expansion=none:font=Noto Sans CJK SC:fontcolor=yellow:fontsize=36")
+	assert.Contains(t, graph, "text=你好，世界:x=(w-text_w)/2:y=156")
+	// 字幕：特殊字符转义，字体路径中的冒号转义，带背景框
+	assert.Contains(t, graph, `box=1:boxborderw=5:boxcolor=black@0.5:expansion=none:fontcolor=white:fontfile=C\\:/Windows/Fonts/msyh.ttc:fontsize=20`)
+	assert.Contains(t, graph, `text=Hello\\: it\\\'s 100%:x=(w-text_w)/2:y=`)
+	// 滑入文字
+	assert.Contains(t, graph, "x=if(lt(t\\,0.5)\\,(32+text_w)*(1-pow(1-t/0.5\\,2))-text_w\\,32):y=18")
+	assert.Equal(t, 3, strings.Count(graph, "drawtext@"))
+}
+
+func TestEditlyTextLayerErrors(t *testing.T) {
+	build := func(layer *Layer) error {
+		spec := &EditSpec{OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25,
+			Clips: []*Clip{{Duration: 2, Layers: []*Layer{layer}}}}
+		_, err := newTestEditly(spec, nil).Build()
+		return err
+	}
+	assert.EqualError(t, build(&Layer{Type: "title"}), "clips[0].layers[0]: 文字层缺少文本")
+	assert.EqualError(t, build(&Layer{Type: "title", Text: "a", Position: &Position{Preset: "middle"}}), "clips[0].layers[0]: 不支持的位置: middle")
+	assert.EqualError(t, build(&Layer{Type: "title", Text: "a", Animation: "spin"}), "clips[0].layers[0]: 不支持的文字动画: spin")
+}
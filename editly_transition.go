@@ -0,0 +1,219 @@
+package ffmpeg_go
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Transition 片段之间的转场，设置在前一个片段上
+type Transition struct {
+	Name     string  `json:"name,omitempty"`     // xfade 的转场名称，如 fade、wipeleft、circleopen，为空时为 fade，none 表示直接切换
+	Duration float64 `json:"duration,omitempty"` // 转场时长（秒），为 0 时直接切换
+	Easing   string  `json:"easing,omitempty"`   // 转场的缓动：linear、easeIn、easeOut、easeInOut，为空时为 linear，画面只有 fade 支持非线性缓动
+
+	explicit explicitZero // JSON 中显式写为零值的字段，如 "duration": 0，不会被默认值覆盖
+}
+
+// UnmarshalJSON 记录显式写为零值的字段
+func (t *Transition) UnmarshalJSON(data []byte) error {
+	type plain Transition
+	if err := json.Unmarshal(data, (*plain)(t)); err != nil {
+		return err
+	}
+	explicit, err := explicitZeroFields(data, t)
+	t.explicit = explicit
+	return err
+}
+
+// MarshalJSON 保留显式写为零值的字段
+func (t Transition) MarshalJSON() ([]byte, error) {
+	type plain Transition
+	data, err := json.Marshal(plain(t))
+	if err != nil {
+		return nil, err
+	}
+	return withExplicitZeros(data, &t, t.explicit)
+}
+
+// xfadeTransitions xfade 滤镜支持的转场（不含需要表达式的 custom）
+var xfadeTransitions = map[string]bool{
+	"fade": true, "fadeblack": true, "fadewhite": true, "fadegrays": true, "fadefast": true, "fadeslow": true,
+	"dissolve": true, "pixelize": true, "distance": true, "radial": true, "hblur": true,
+	"wipeleft": true, "wiperight": true, "wipeup": true, "wipedown": true,
+	"wipetl": true, "wipetr": true, "wipebl": true, "wipebr": true,
+	"slideleft": true, "slideright": true, "slideup": true, "slidedown": true,
+	"smoothleft": true, "smoothright": true, "smoothup": true, "smoothdown": true,
+	"coverleft": true, "coverright": true, "coverup": true, "coverdown": true,
+	"revealleft": true, "revealright": true, "revealup": true, "revealdown": true,
+	"circlecrop": true, "rectcrop": true, "circleopen": true, "circleclose": true,
+	"vertopen": true, "vertclose": true, "horzopen": true, "horzclose": true,
+	"diagtl": true, "diagtr": true, "diagbl": true, "diagbr": true,
+	"hlslice": true, "hrslice": true, "vuslice": true, "vdslice": true,
+	"hlwind": true, "hrwind": true, "vuwind": true, "vdwind": true,
+	"squeezeh": true, "squeezev": true, "zoomin": true,
+}
+
+// transitionCurves 缓动名称对应的 acrossfade 曲线
+var transitionCurves = map[string]string{
+	"":          "tri",
+	"linear":    "tri",
+	"easeIn":    "exp",
+	"easeOut":   "log",
+	"easeInOut": "hsin",
+}
+
+// easedTransition 转场是否支持非线性缓动：xfade 的内置转场按时间线性推进，只有 fade 可以改用自定义表达式
+func easedTransition(name, easing string) bool {
+	return easing == "" || easing == "linear" || name == "" || name == "fade"
+}
+
+// xfadeArgs xfade 滤镜的参数，fade 转场有非线性缓动时用自定义表达式实现
+func xfadeArgs(t *Transition, offset float64) KwArgs {
+	args := KwArgs{"transition": t.Name, "duration": seconds(t.Duration), "offset": seconds(offset)}
+	if t.Name == "fade" && t.Easing != "" && t.Easing != "linear" {
+		// P 在转场中从 1 变为 0，缓动后的进度从前一片段 A 渐变到后一片段 B
+		args["transition"] = "custom"
+		args["expr"] = Lerp(Var("A"), Var("B"), Ease(Easing(t.Easing), Sub(1, Var("P"))))
+	}
+	return args
+}
+
+// transitions 返回每个片段与下一个片段之间的转场，直接切换的位置为 nil。
+// 片段未设置转场时使用默认转场，转场不能长于相邻的任一片段，片段前后两个转场的时长之和也不能超过片段的时长
+func (e *Editly) transitions(durations []float64) ([]*Transition, error) {
+	clips := e.spec.Clips
+	result := make([]*Transition, len(clips)-1)
+	for i := range result {
+		t := clips[i].Transition
+		if t == nil && e.spec.Defaults != nil {
+			t = e.spec.Defaults.Transition
+		}
+		if t == nil || t.Name == "none" || t.Duration == 0 {
+			continue
+		}
+		if t.Duration < 0 {
+			return nil, fmt.Errorf("clips[%d].transition: 转场时长不能为负数: %s", i, seconds(t.Duration))
+		}
+		name := t.Name
+		if name == "" {
+			name = "fade"
+		}
+		if !xfadeTransitions[name] {
+			return nil, fmt.Errorf("clips[%d].transition: 不支持的转场: %s", i, name)
+		}
+		if _, ok := transitionCurves[t.Easing]; !ok {
+			return nil, fmt.Errorf("clips[%d].transition: 不支持的缓动: %s", i, t.Easing)
+		}
+		if !easedTransition(name, t.Easing) {
+			return nil, fmt.Errorf("clips[%d].transition: 转场 %s 不支持缓动 %s，只有 fade 支持非线性缓动", i, name, t.Easing)
+		}
+		for _, j := range []int{i, i + 1} {
+			if t.Duration > durations[j] {
+				return nil, fmt.Errorf("clips[%d].transition: 转场时长 %s 秒超过片段 clips[%d] 的时长 %s 秒",
+					i, seconds(t.Duration), j, seconds(durations[j]))
+			}
+		}
+		result[i] = &Transition{Name: name, Duration: t.Duration, Easing: t.Easing}
+	}
+	for j := 1; j < len(result); j++ {
+		in, out := result[j-1], result[j]
+		if in != nil && out != nil && in.Duration+out.Duration > durations[j] {
+			return nil, fmt.Errorf("clips[%d].transition: 片段 clips[%d] 前后转场的时长之和 %s 秒超过片段的时长 %s 秒",
+				j, j, seconds(in.Duration+out.Duration), seconds(durations[j]))
+		}
+	}
+	return result, nil
+}
+
+// timelineDuration 成片时长：片段时长之和减去转场重叠的部分
+func timelineDuration(durations []float64, transitions []*Transition) float64 {
+	total := 0.0
+	for _, d := range durations {
+		total += d
+	}
+	for _, t := range transitions {
+		if t != nil {
+			total -= t.Duration
+		}
+	}
+	return total
+}
+
+// joinClips 按顺序连接各片段的画面和原声（audios 为空时不处理音频）。
+// 没有转场时一次拼接全部片段，否则逐个连接：转场处用 xfade/acrossfade 重叠，其余位置拼接
+func joinClips(videos, audios []*Stream, durations []float64, transitions []*Transition) (video, audio *Stream) {
+	hasTransition := false
+	for _, t := range transitions {
+		hasTransition = hasTransition || t != nil
+	}
+	if !hasTransition {
+		switch {
+		case len(videos) == 1 && audios != nil:
+			return videos[0], audios[0]
+		case len(videos) == 1:
+			return videos[0], nil
+		case audios != nil:
+			// concat 的输入需要按片段交替排列视频和音频
+			var parts []*Stream
+			for i := range videos {
+				parts = append(parts, videos[i], audios[i])
+			}
+			concat := FilterMultiOutput(parts, "concat", nil, KwArgs{"n": len(videos), "v": 1, "a": 1})
+			return concat.Stream("0", ""), concat.Stream("1", "")
+		default:
+			return Concat(videos), nil
+		}
+	}
+
+	video = videos[0]
+	if audios != nil {
+		audio = audios[0]
+	}
+	length := durations[0]
+	for i := 1; i < len(videos); i++ {
+		t := transitions[i-1]
+		switch {
+		case t == nil && audio != nil:
+			concat := FilterMultiOutput([]*Stream{video, audio, videos[i], audios[i]}, "concat", nil, KwArgs{"n": 2, "v": 1, "a": 1})
+			video, audio = concat.Stream("0", ""), concat.Stream("1", "")
+		case t == nil:
+			video = Concat([]*Stream{video, videos[i]})
+		default:
+			// 转场从已连接部分结束前 t.Duration 秒开始
+			video = Filter([]*Stream{video, videos[i]}, "xfade", nil, xfadeArgs(t, length-t.Duration))
+			if audio != nil {
+				curve := transitionCurves[t.Easing]
+				audio = Filter([]*Stream{audio, audios[i]}, "acrossfade", nil, KwArgs{
+					"d": seconds(t.Duration), "c1": curve, "c2": curve})
+			}
+			length -= t.Duration
+		}
+		length += durations[i]
+	}
+	return video, audio
+}
+
+// joinAudio 只连接各片段的原声，结果与 joinClips 的音频部分相同
+func joinAudio(audios []*Stream, transitions []*Transition) *Stream {
+	hasTransition := false
+	for _, t := range transitions {
+		hasTransition = hasTransition || t != nil
+	}
+	if len(audios) == 1 {
+		return audios[0]
+	}
+	if !hasTransition {
+		return Concat(audios, KwArgs{"v": 0, "a": 1})
+	}
+	audio := audios[0]
+	for i := 1; i < len(audios); i++ {
+		if t := transitions[i-1]; t != nil {
+			curve := transitionCurves[t.Easing]
+			audio = Filter([]*Stream{audio, audios[i]}, "acrossfade", nil, KwArgs{
+				"d": seconds(t.Duration), "c1": curve, "c2": curve})
+		} else {
+			audio = Concat([]*Stream{audio, audios[i]}, KwArgs{"v": 0, "a": 1})
+		}
+	}
+	return audio
+}
@@ -0,0 +1,74 @@
+package ffmpeg_go
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditlyTransitions(t *testing.T) {
+	spec := &EditSpec{
+		OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25, KeepSourceAudio: true,
+		Defaults: &Defaults{Duration: 3, Transition: &Transition{Duration: 1}},
+		Clips: []*Clip{
+			{Layers: []*Layer{{Type: "image", Path: "a.png"}}},
+			{Transition: &Transition{Name: "none"}, Layers: []*Layer{{Type: "image", Path: "b.png"}}},
+			{Transition: &Transition{Duration: 0.5, Easing: "easeInOut"}, Layers: []*Layer{{Type: "image", Path: "c.png"}}},
+			{Layers: []*Layer{{Type: "image", Path: "d.png"}}},
+		},
+		AudioTracks: []*AudioTrack{{Path: "music.mp3"}},
+	}
+	stream, err := newTestEditly(spec, nil).Build()
+	assert.Nil(t, err)
+	args := stream.GetArgs()
+	graph := args[indexOfArg(args, "-filter_complex")+1]
+	// 3+3+3+3 减去 1 秒和 0.5 秒的重叠
+	assert.Contains(t, graph, "xfade=duration=1:offset=2:transition=fade")
+	assert.Contains(t, graph, "acrossfade=c1=tri:c2=tri:d=1")
+	assert.Contains(t, graph, "concat=a=1:n=2:v=1")
+	// fade 的缓动用自定义表达式实现
+	assert.Contains(t, graph, "xfade=duration=0.5:expr=(A+((B-A)*(((1-P)*(1-P))*(3-(2*(1-P)))))):offset=7.5:transition=custom")
+	assert.Contains(t, graph, "acrossfade=c1=hsin:c2=hsin:d=0.5")
+	assert.Contains(t, graph, "atrim=duration=10.5")
+	assert.Equal(t, 2, strings.Count(graph, "xfade="))
+}
+
+func TestEditlyTransitionErrors(t *testing.T) {
+	build := func(transition *Transition) error {
+		spec := &EditSpec{
+			OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25,
+			Clips: []*Clip{
+				{Duration: 2, Transition: transition, Layers: []*Layer{{Type: "image", Path: "a.png"}}},
+				{Duration: 1, Layers: []*Layer{{Type: "image", Path: "b.png"}}},
+			},
+		}
+		_, err := newTestEditly(spec, nil).Build()
+		return err
+	}
+	assert.Nil(t, build(&Transition{Duration: 1}))
+	assert.EqualError(t, build(&Transition{Duration: 1.5}), "clips[0].transition: 转场时长 1.5 秒超过片段 clips[1] 的时长 1 秒")
+	assert.EqualError(t, build(&Transition{Name: "spin", Duration: 1}), "clips[0].transition: 不支持的转场: spin")
+	assert.EqualError(t, build(&Transition{Duration: 1, Easing: "bounce"}), "clips[0].transition: 不支持的缓动: bounce")
+	assert.Nil(t, build(&Transition{Name: "wipeleft", Duration: 1, Easing: "linear"}))
+	assert.EqualError(t, build(&Transition{Name: "wipeleft", Duration: 1, Easing: "easeIn"}),
+		"clips[0].transition: 转场 wipeleft 不支持缓动 easeIn，只有 fade 支持非线性缓动")
+}
+
+func TestEditlyTransitionOverlap(t *testing.T) {
+	// 中间片段 2 秒，前后各 1 秒的转场刚好用完，再长就会重叠
+	build := func(out float64) error {
+		spec := &EditSpec{
+			OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25,
+			Clips: []*Clip{
+				{Duration: 2, Transition: &Transition{Duration: 1}, Layers: []*Layer{{Type: "image", Path: "a.png"}}},
+				{Duration: 2, Transition: &Transition{Duration: out}, Layers: []*Layer{{Type: "image", Path: "b.png"}}},
+				{Duration: 2, Layers: []*Layer{{Type: "image", Path: "c.png"}}},
+			},
+		}
+		_, err := newTestEditly(spec, nil).Build()
+		return err
+	}
+	assert.Nil(t, build(1))
+	assert.EqualError(t, build(1.5), "clips[1].transition: 片段 clips[1] 前后转场的时长之和 2.5 秒超过片段的时长 2 秒")
+}
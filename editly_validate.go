@@ -0,0 +1,431 @@
+package ffmpeg_go
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ValidationError 规范中一个字段的错误，Path 为字段路径，如 clips[2].layers[0].cutTo
+type ValidationError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + " " + e.Message
+}
+
+// ValidationErrors 规范中的所有错误，没有错误时为空
+type ValidationErrors []*ValidationError
+
+func (errs ValidationErrors) Error() string {
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// add 记录一个字段错误
+func (errs *ValidationErrors) add(path, format string, args ...interface{}) {
+	*errs = append(*errs, &ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// mediaLayerTypes 需要素材文件的层类型
+var mediaLayerTypes = map[string]bool{"video": true, "image": true, "overlay-video": true, "overlay-image": true}
+
+// editlyLayerTypes 支持的所有层类型，按名称排序
+func editlyLayerTypes() []string {
+	var types []string
+	for t := range mediaLayerTypes {
+		types = append(types, t)
+	}
+	for t := range textLayerStyles {
+		types = append(types, t)
+	}
+	types = append(types, "fill-color", "linear-gradient", "radial-gradient", "voice")
+	sort.Strings(types)
+	return types
+}
+
+// isEditlyLayerType 判断是否为支持的层类型
+func isEditlyLayerType(layerType string) bool {
+	return mediaLayerTypes[layerType] || isTextLayer(layerType) || isBackgroundLayer(layerType) || layerType == "voice"
+}
+
+// isRemotePath 判断是否为网络地址，网络素材不检查文件是否存在
+func isRemotePath(path string) bool {
+	return strings.Contains(path, "://")
+}
+
+// checkFile 检查本地文件是否存在
+func (errs *ValidationErrors) checkFile(path, file string) bool {
+	if isRemotePath(file) {
+		return true
+	}
+	if _, err := os.Stat(file); err != nil {
+		errs.add(path, "文件不存在: %s", file)
+		return false
+	}
+	return true
+}
+
+// Validate 检查规范中的类型、取值范围、时长、字体和素材，返回所有错误而不是第一个。
+// 层按合并默认值后的值检查，素材会通过 ffprobe 探测
+func (e *Editly) Validate() ValidationErrors {
+	var errs ValidationErrors
+	spec := e.spec
+	if spec.OutPath == "" {
+		errs.add("outPath", "不能为空")
+	}
+	for _, size := range []struct {
+		path  string
+		value int
+	}{{"width", spec.Width}, {"height", spec.Height}} {
+		if size.value <= 0 {
+			errs.add(size.path, "必须大于 0: %d", size.value)
+		} else if size.value%2 != 0 {
+			errs.add(size.path, "必须为偶数: %d", size.value)
+		}
+	}
+	if spec.Fps <= 0 {
+		errs.add("fps", "必须大于 0: %d", spec.Fps)
+	}
+	if spec.Encoding != "" {
+		if _, ok := GetEncodingPreset(spec.Encoding); !ok {
+			errs.add("encoding", "未知的编码预设: %s", spec.Encoding)
+		}
+	}
+	if spec.Parallel < 0 {
+		errs.add("parallel", "不能为负数")
+	}
+	if spec.ClipsAudioVolume != nil && *spec.ClipsAudioVolume < 0 {
+		errs.add("clipsAudioVolume", "不能为负数")
+	}
+	e.validateDefaults(&errs)
+
+	if len(spec.Clips) == 0 {
+		errs.add("clips", "至少需要一个片段")
+	}
+	// 时长无法确定的片段记为 -1，不再检查相邻的转场
+	durations := make([]float64, len(spec.Clips))
+	for i, clip := range spec.Clips {
+		durations[i] = e.validateClip(&errs, i, clip)
+	}
+	for i, clip := range spec.Clips {
+		if i == len(spec.Clips)-1 {
+			break
+		}
+		path := fmt.Sprintf("clips[%d].transition", i)
+		t := e.resolveTransition(i, clip)
+		if t == nil || !validateTransition(&errs, path, t) {
+			continue
+		}
+		for _, j := range []int{i, i + 1} {
+			if durations[j] >= 0 && t.Duration > durations[j] {
+				errs.add(path+".duration", "转场时长 %s 秒超过片段 clips[%d] 的时长 %s 秒",
+					seconds(t.Duration), j, seconds(durations[j]))
+			}
+		}
+	}
+
+	for i, track := range spec.AudioTracks {
+		e.validateAudioTrack(&errs, fmt.Sprintf("audioTracks[%d]", i), track)
+	}
+	if norm := spec.AudioNorm; norm != nil {
+		if norm.GaussSize != 0 && (norm.GaussSize < 3 || norm.GaussSize > 301 || norm.GaussSize%2 == 0) {
+			errs.add("audioNorm.gaussSize", "必须是 3~301 之间的奇数: %d", norm.GaussSize)
+		}
+		if norm.MaxGain != 0 && (norm.MaxGain < 1 || norm.MaxGain > 100) {
+			errs.add("audioNorm.maxGain", "必须在 1~100 之间: %s", formatNumber(norm.MaxGain))
+		}
+	}
+	if ducking := spec.Ducking; ducking != nil {
+		if ducking.Threshold < 0 || ducking.Threshold > 1 {
+			errs.add("ducking.threshold", "必须在 0~1 之间: %s", formatNumber(ducking.Threshold))
+		}
+		if ducking.Ratio != 0 && (ducking.Ratio < 1 || ducking.Ratio > 20) {
+			errs.add("ducking.ratio", "必须在 1~20 之间: %s", formatNumber(ducking.Ratio))
+		}
+		if ducking.Attack < 0 {
+			errs.add("ducking.attack", "不能为负数")
+		}
+		if ducking.Release < 0 {
+			errs.add("ducking.release", "不能为负数")
+		}
+	}
+	return errs
+}
+
+// validateDefaults 检查 defaults 中的时长、转场和层默认值
+func (e *Editly) validateDefaults(errs *ValidationErrors) {
+	defaults := e.spec.Defaults
+	if defaults == nil {
+		return
+	}
+	if defaults.Duration < 0 {
+		errs.add("defaults.duration", "不能为负数")
+	}
+	if defaults.Transition != nil {
+		validateTransition(errs, "defaults.transition", defaults.Transition)
+	}
+	if background := defaults.Background; background != nil {
+		if !isBackgroundLayer(background.Type) {
+			errs.add("defaults.background.type", "必须是背景层类型 fill-color、linear-gradient 或 radial-gradient: %s", background.Type)
+		} else {
+			e.validateLayer(errs, "defaults.background", e.resolveLayer(background), -1)
+		}
+	}
+	for layerType := range defaults.LayerType {
+		if !isEditlyLayerType(layerType) {
+			errs.add("defaults.layerType", "不支持的层类型: %s", layerType)
+		}
+	}
+}
+
+// validateClip 检查片段和其中的层，返回片段时长，无法确定时返回 -1
+func (e *Editly) validateClip(errs *ValidationErrors, index int, clip *Clip) float64 {
+	path := fmt.Sprintf("clips[%d]", index)
+	if clip.Duration < 0 {
+		errs.add(path+".duration", "不能为负数")
+		return -1
+	}
+	resolved := *clip
+	resolved.Layers = make([]*Layer, len(clip.Layers))
+	for j, layer := range clip.Layers {
+		resolved.Layers[j] = e.resolveLayer(layer)
+	}
+	// 叠加层的显示时间需要片段时长，先检查素材再确定时长；视频层或 voice 层有错误时时长无法确定
+	videoValid := true
+	for j, layer := range resolved.Layers {
+		count := len(*errs)
+		e.validateMedia(errs, fmt.Sprintf("%s.layers[%d]", path, j), layer)
+		if (layer.Type == "video" || layer.Type == "voice") && len(*errs) > count {
+			videoValid = false
+		}
+	}
+	duration := -1.0
+	if videoValid {
+		d, err := e.clipDuration(&resolved)
+		if err != nil {
+			errs.add(path, "%v", err)
+		} else {
+			duration = d
+		}
+	}
+	for j, layer := range resolved.Layers {
+		e.validateLayer(errs, fmt.Sprintf("%s.layers[%d]", path, j), layer, duration)
+	}
+	if clip.Subtitles != "" && clip.Subtitles != "auto" {
+		errs.add(path+".subtitles", "不支持的字幕模式: %s，只能为 auto", clip.Subtitles)
+	} else if clip.Subtitles == "auto" && duration >= 0 {
+		resolved.Duration = duration
+		if _, err := e.autoSubtitles(&resolved); err != nil {
+			errs.add(path+".subtitles", "%v", err)
+		}
+	}
+	return duration
+}
+
+// validateMedia 检查素材层的路径、截取范围和速度，并探测素材
+func (e *Editly) validateMedia(errs *ValidationErrors, path string, layer *Layer) {
+	if layer.Type == "voice" {
+		// 合成语音，检查文本和语音合成引擎
+		if _, _, err := e.speech(layer); err != nil {
+			errs.add(path, "%v", err)
+		}
+		return
+	}
+	if !mediaLayerTypes[layer.Type] {
+		return
+	}
+	if layer.Path == "" {
+		errs.add(path+".path", "不能为空")
+	} else if errs.checkFile(path+".path", layer.Path) {
+		if info, err := e.prober.get(layer.Path); err != nil {
+			errs.add(path+".path", "%v", err)
+		} else if !info.HasVideo {
+			errs.add(path+".path", "素材没有视频流: %s", layer.Path)
+		} else if isVideoLayer(layer.Type) && layer.CutFrom >= info.Duration && info.Duration > 0 {
+			errs.add(path+".cutFrom", "%s 超出素材时长 %s 秒", seconds(layer.CutFrom), seconds(info.Duration))
+		}
+	}
+	if !isVideoLayer(layer.Type) {
+		return
+	}
+	if layer.CutFrom < 0 {
+		errs.add(path+".cutFrom", "不能为负数")
+	}
+	if layer.CutTo < 0 {
+		errs.add(path+".cutTo", "不能为负数")
+	} else if layer.CutTo > 0 && layer.CutTo <= layer.CutFrom {
+		errs.add(path+".cutTo", "必须大于 cutFrom")
+	}
+	if layer.SpeedFactor < 0 {
+		errs.add(path+".speedFactor", "必须大于 0")
+	}
+}
+
+// containsString 判断列表中是否有指定的值
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// isVideoLayer 判断是否为视频素材层
+func isVideoLayer(layerType string) bool {
+	return layerType == "video" || layerType == "overlay-video"
+}
+
+// validateLayer 检查层类型和与类型相关的字段，duration 为片段时长，未知时为 -1
+func (e *Editly) validateLayer(errs *ValidationErrors, path string, layer *Layer, duration float64) {
+	if !isEditlyLayerType(layer.Type) {
+		errs.add(path+".type", "不支持的层类型: %s", layer.Type)
+		return
+	}
+	if mediaLayerTypes[layer.Type] {
+		if layer.ResizeMode != "" && !containsString(resizeModes, layer.ResizeMode) {
+			errs.add(path+".resizeMode", "不支持的缩放模式: %s", layer.ResizeMode)
+		}
+	}
+	if layer.ZoomDirection != "" {
+		if layer.Type != "image" {
+			errs.add(path+".zoomDirection", "只能用于图片层")
+		} else if !containsString(zoomDirections, layer.ZoomDirection) {
+			errs.add(path+".zoomDirection", "不支持的 zoomDirection: %s", layer.ZoomDirection)
+		}
+	}
+	if layer.ZoomAmount < 0 {
+		errs.add(path+".zoomAmount", "不能为负数")
+	}
+	if layer.Position != nil {
+		if layer.Position.Preset != "" {
+			if _, _, err := splitPreset(layer.Position.Preset); err != nil {
+				errs.add(path+".position", "%v", err)
+			}
+		} else if _, _, err := layer.Position.originFactors(); err != nil {
+			errs.add(path+".position", "%v", err)
+		}
+	}
+
+	if layer.Type == "overlay-video" || layer.Type == "overlay-image" {
+		if layer.Width < 0 {
+			errs.add(path+".width", "不能为负数")
+		}
+		if layer.Height < 0 {
+			errs.add(path+".height", "不能为负数")
+		}
+		if layer.Opacity < 0 || layer.Opacity > 1 {
+			errs.add(path+".opacity", "必须在 0~1 之间: %s", formatNumber(layer.Opacity))
+		}
+		if layer.CornerRadius < 0 {
+			errs.add(path+".cornerRadius", "不能为负数")
+		}
+		if layer.BorderWidth < 0 {
+			errs.add(path+".borderWidth", "不能为负数")
+		}
+	}
+
+	if layer.Type == "overlay-video" || layer.Type == "overlay-image" || isTextLayer(layer.Type) {
+		if layer.Start < 0 {
+			errs.add(path+".start", "不能为负数")
+		}
+		if layer.Stop < 0 || layer.Stop > 0 && layer.Stop <= layer.Start {
+			errs.add(path+".stop", "必须大于 start")
+		} else if duration >= 0 && (layer.Stop > duration || layer.Start >= duration) {
+			errs.add(path, "start(%s)~stop(%s) 超出片段时长 %s 秒", seconds(layer.Start), seconds(layer.Stop), seconds(duration))
+		}
+	}
+
+	if layer.Type == "voice" {
+		if layer.Start < 0 {
+			errs.add(path+".start", "不能为负数")
+		} else if duration >= 0 && layer.Start >= duration {
+			errs.add(path+".start", "%s 超出片段时长 %s 秒", seconds(layer.Start), seconds(duration))
+		}
+	}
+
+	if layer.Type == "linear-gradient" || layer.Type == "radial-gradient" {
+		if n := len(layer.Colors); n < 2 || n > maxGradientColors {
+			errs.add(path+".colors", "渐变需要 2~%d 种颜色，实际为 %d", maxGradientColors, n)
+		}
+	}
+
+	if isTextLayer(layer.Type) {
+		if strings.TrimSpace(layer.Text) == "" {
+			errs.add(path+".text", "文字层缺少文本")
+		}
+		if layer.FontPath != "" {
+			errs.checkFile(path+".fontPath", layer.FontPath)
+		}
+		if layer.FontSize <= 0 {
+			errs.add(path+".fontSize", "无效的字号: %d", layer.FontSize)
+		}
+		if layer.Align != "" {
+			if _, err := textX(layer.Align, 0); err != nil {
+				errs.add(path+".align", "%v", err)
+			}
+		}
+		if layer.Animation != "none" && layer.Animation != "fade" && layer.Animation != "slide" {
+			errs.add(path+".animation", "不支持的文字动画: %s", layer.Animation)
+		}
+	}
+}
+
+// validateTransition 检查转场的名称、缓动和时长，返回转场是否有效
+func validateTransition(errs *ValidationErrors, path string, t *Transition) bool {
+	count := len(*errs)
+	if t.Name != "" && t.Name != "none" && !xfadeTransitions[t.Name] {
+		errs.add(path+".name", "不支持的转场: %s", t.Name)
+	}
+	if _, ok := transitionCurves[t.Easing]; !ok {
+		errs.add(path+".easing", "不支持的缓动: %s", t.Easing)
+	} else if t.Name != "none" && !easedTransition(t.Name, t.Easing) {
+		errs.add(path+".easing", "转场 %s 不支持缓动 %s，只有 fade 支持非线性缓动", t.Name, t.Easing)
+	}
+	if t.Duration < 0 {
+		errs.add(path+".duration", "转场时长不能为负数: %s", seconds(t.Duration))
+	}
+	return len(*errs) == count
+}
+
+// validateAudioTrack 检查音轨的路径、时间参数和音量，并探测素材
+func (e *Editly) validateAudioTrack(errs *ValidationErrors, path string, track *AudioTrack) {
+	if track.Path == "" {
+		errs.add(path+".path", "不能为空")
+	} else if errs.checkFile(path+".path", track.Path) {
+		if info, err := e.prober.get(track.Path); err != nil {
+			errs.add(path+".path", "%v", err)
+		} else if !info.HasAudio {
+			errs.add(path+".path", "素材没有音频流: %s", track.Path)
+		}
+	}
+	if track.MixVolume != nil && *track.MixVolume < 0 {
+		errs.add(path+".mixVolume", "不能为负数")
+	}
+	for _, field := range []struct {
+		name  string
+		value float64
+	}{{"start", track.Start}, {"cutFrom", track.CutFrom}, {"fadeIn", track.FadeIn}, {"fadeOut", track.FadeOut}} {
+		if field.value < 0 {
+			errs.add(path+"."+field.name, "不能为负数")
+		}
+	}
+	if track.CutTo < 0 || track.CutTo > 0 && track.CutTo <= track.CutFrom {
+		errs.add(path+".cutTo", "必须大于 cutFrom")
+	}
+}
+
+// Validate 检查规范，见 Editly.Validate
+func (s *EditSpec) Validate() ValidationErrors {
+	return NewEditly(s).Validate()
+}
@@ -0,0 +1,77 @@
+package ffmpeg_go
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// touchFiles 在临时目录中创建空文件，返回文件路径
+func touchFiles(t *testing.T, names ...string) map[string]string {
+	dir := t.TempDir()
+	paths := map[string]string{}
+	for _, name := range names {
+		paths[name] = filepath.Join(dir, name)
+		assert.Nil(t, os.WriteFile(paths[name], nil, 0644))
+	}
+	return paths
+}
+
+func TestEditlyValidate(t *testing.T) {
+	files := touchFiles(t, "a.mp4", "b.png", "music.mp3")
+	spec := &EditSpec{
+		OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25,
+		Defaults: &Defaults{Transition: &Transition{Name: "dissolve", Duration: 0.5}},
+		Clips: []*Clip{
+			{Layers: []*Layer{{Type: "video", Path: files["a.mp4"], CutFrom: 1, CutTo: 3}}},
+			{Layers: []*Layer{{Type: "image", Path: files["b.png"], ZoomDirection: "in"}, {Type: "title", Text: "标题"}}},
+		},
+		AudioTracks: []*AudioTrack{{Path: files["music.mp3"]}},
+	}
+	media := map[string]*mediaInfo{files["music.mp3"]: {Duration: 30, HasAudio: true}}
+	assert.Empty(t, newTestEditly(spec, media).Validate())
+
+	// 返回所有错误，每个错误带字段路径
+	spec.Width = 641
+	spec.Clips[0].Layers[0].CutTo = 0.5
+	spec.Clips[1].Duration = 0.2
+	spec.Clips[1].Layers = append(spec.Clips[1].Layers,
+		&Layer{Type: "overlay-image", Path: "missing.png", Opacity: 2},
+		&Layer{Type: "subtitle", Text: " ", FontPath: "missing.ttf"},
+		&Layer{Type: "video", Path: files["a.mp4"], ZoomDirection: "left"},
+		&Layer{Type: "shape"})
+	spec.AudioTracks = append(spec.AudioTracks, &AudioTrack{Path: files["a.mp4"], FadeIn: -1})
+	errs := newTestEditly(spec, media).Validate()
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	assert.Equal(t, []string{
+		"width 必须为偶数: 641",
+		"clips[0].layers[0].cutTo 必须大于 cutFrom",
+		"clips[1].layers[2].path 文件不存在: missing.png",
+		"clips[1].layers[2].opacity 必须在 0~1 之间: 2",
+		"clips[1].layers[3].text 文字层缺少文本",
+		"clips[1].layers[3].fontPath 文件不存在: missing.ttf",
+		"clips[1].layers[4].zoomDirection 只能用于图片层",
+		"clips[1].layers[5].type 不支持的层类型: shape",
+		"clips[0].transition.duration 转场时长 0.5 秒超过片段 clips[1] 的时长 0.2 秒",
+		"audioTracks[1].path 素材没有音频流: " + files["a.mp4"],
+		"audioTracks[1].fadeIn 不能为负数",
+	}, messages)
+
+	// 转场时长按合并默认值后的片段时长检查
+	spec = &EditSpec{OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25,
+		Defaults: &Defaults{Transition: &Transition{Duration: 1, Easing: "bounce"}},
+		Clips: []*Clip{
+			{Duration: 2, Transition: &Transition{Easing: "easeIn"}, Layers: []*Layer{}},
+			{Duration: 0.5, Layers: []*Layer{}},
+			{Layers: []*Layer{}},
+		}}
+	assert.EqualError(t, newTestEditly(spec, nil).Validate(),
+		"defaults.transition.easing 不支持的缓动: bounce; "+
+			"clips[0].transition.duration 转场时长 1 秒超过片段 clips[1] 的时长 0.5 秒; "+
+			"clips[1].transition.easing 不支持的缓动: bounce")
+}
@@ -0,0 +1,104 @@
+package ffmpeg_go
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// SpeechOptions 语音合成参数
+type SpeechOptions struct {
+	Voice string  // 语音名称，由语音合成引擎解释，如 espeak-ng 的 zh、piper 的模型文件
+	Rate  float64 // 语速倍数，为空时为 1
+}
+
+// SpeechSynthesizer 语音合成引擎，把文本合成为音频文件并返回路径。
+// 计算片段时长、检查和渲染时同一段语音会被请求多次，实现应当缓存结果
+type SpeechSynthesizer interface {
+	Synthesize(text string, options SpeechOptions) (string, error)
+}
+
+var speechSynthesizer = struct {
+	sync.RWMutex
+	s SpeechSynthesizer
+}{}
+
+// SetSpeechSynthesizer 设置 voice 层使用的语音合成引擎，nil 表示不支持 voice 层
+func SetSpeechSynthesizer(s SpeechSynthesizer) {
+	speechSynthesizer.Lock()
+	defer speechSynthesizer.Unlock()
+	speechSynthesizer.s = s
+}
+
+func currentSpeechSynthesizer() SpeechSynthesizer {
+	speechSynthesizer.RLock()
+	defer speechSynthesizer.RUnlock()
+	return speechSynthesizer.s
+}
+
+// speech 合成 voice 层的语音，返回音频路径和时长
+func (e *Editly) speech(layer *Layer) (string, float64, error) {
+	synthesizer := currentSpeechSynthesizer()
+	if synthesizer == nil {
+		return "", 0, fmt.Errorf("未配置语音合成引擎，不能使用 voice 层")
+	}
+	if strings.TrimSpace(layer.Text) == "" {
+		return "", 0, fmt.Errorf("voice 层缺少文本")
+	}
+	if layer.SpeechRate < 0 {
+		return "", 0, fmt.Errorf("speechRate 必须大于 0")
+	}
+	path, err := synthesizer.Synthesize(layer.Text, SpeechOptions{Voice: layer.Voice, Rate: layer.SpeechRate})
+	if err != nil {
+		return "", 0, fmt.Errorf("语音合成失败: %w", err)
+	}
+	info, err := e.prober.get(path)
+	if err != nil {
+		return "", 0, err
+	}
+	return path, info.Duration, nil
+}
+
+// speechDuration 片段中各 voice 层语音结束的最晚时间，没有 voice 层时为 0
+func (e *Editly) speechDuration(clip *Clip) (float64, error) {
+	end := 0.0
+	for _, layer := range clip.Layers {
+		if layer.Type != "voice" {
+			continue
+		}
+		_, duration, err := e.speech(layer)
+		if err != nil {
+			return 0, err
+		}
+		if layer.Start+duration > end {
+			end = layer.Start + duration
+		}
+	}
+	return end, nil
+}
+
+// voiceTracks 把片段中的 voice 层转换为旁白音轨，返回音轨和其余的层。
+// clip 需要已经确定时长，start 为片段在成片中的开始时间；语音超出片段的部分被截掉
+func (e *Editly) voiceTracks(clip *Clip, start float64) ([]*AudioTrack, []*Layer, error) {
+	var tracks []*AudioTrack
+	var layers []*Layer
+	for j, layer := range clip.Layers {
+		if layer.Type != "voice" {
+			layers = append(layers, layer)
+			continue
+		}
+		if layer.Start < 0 || layer.Start >= clip.Duration {
+			return nil, nil, fmt.Errorf("layers[%d]: start %s 超出片段时长 %s 秒", j, seconds(layer.Start), seconds(clip.Duration))
+		}
+		path, duration, err := e.speech(layer)
+		if err != nil {
+			return nil, nil, fmt.Errorf("layers[%d]: %w", j, err)
+		}
+		track := &AudioTrack{Path: path, Start: start + layer.Start, VoiceOver: true}
+		if remain := clip.Duration - layer.Start; duration > remain {
+			track.CutTo = remain
+		}
+		tracks = append(tracks, track)
+	}
+	return tracks, layers, nil
+}
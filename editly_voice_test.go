@@ -0,0 +1,75 @@
+package ffmpeg_go
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeSynthesizer 把文本映射为固定的文件名，记录收到的参数
+type fakeSynthesizer struct {
+	options []SpeechOptions
+}
+
+func (s *fakeSynthesizer) Synthesize(text string, options SpeechOptions) (string, error) {
+	s.options = append(s.options, options)
+	return "speech-" + text + ".wav", nil
+}
+
+func TestEditlyVoice(t *testing.T) {
+	synthesizer := &fakeSynthesizer{}
+	SetSpeechSynthesizer(synthesizer)
+	defer SetSpeechSynthesizer(nil)
+
+	spec := &EditSpec{
+		OutPath: "out.mp4", Width: 640, Height: 360, Fps: 25,
+		Defaults: &Defaults{Duration: 5},
+		Clips: []*Clip{
+			{Transition: &Transition{Duration: 0.5}, Layers: []*Layer{
+				{Type: "title", Text: "开场"},
+				{Type: "voice", Text: "a", Start: 0.5, Voice: "zh", SpeechRate: 1.2},
+			}},
+			{Duration: 2, Layers: []*Layer{{Type: "fill-color"}, {Type: "voice", Text: "b"}}},
+		},
+		AudioTracks: []*AudioTrack{{Path: "https://example.com/music.mp3"}},
+	}
+	e := newTestEditly(spec, map[string]*mediaInfo{
+		"https://example.com/music.mp3": {Duration: 60, HasAudio: true},
+		"speech-a.wav":                  {Duration: 2, HasAudio: true},
+		"speech-b.wav":                  {Duration: 3, HasAudio: true},
+	})
+
+	// 第一个片段的时长跟随语音，第二个片段截掉超出的语音
+	resolved, err := e.Resolve()
+	assert.Nil(t, err)
+	assert.Equal(t, 2.5, resolved.Clips[0].Duration)
+	assert.Equal(t, 2.0, resolved.Clips[1].Duration)
+	assert.Len(t, resolved.Clips[0].Layers, 1)
+	assert.Len(t, resolved.Clips[1].Layers, 1)
+	assert.Equal(t, []*AudioTrack{
+		{Path: "https://example.com/music.mp3"},
+		{Path: "speech-a.wav", Start: 0.5, VoiceOver: true},
+		{Path: "speech-b.wav", Start: 2, CutTo: 2, VoiceOver: true},
+	}, resolved.AudioTracks)
+	assert.Equal(t, SpeechOptions{Voice: "zh", Rate: 1.2}, synthesizer.options[0])
+	assert.Len(t, spec.AudioTracks, 1)
+
+	stream, err := e.Build()
+	assert.Nil(t, err)
+	args := strings.Join(stream.GetArgs(), " ")
+	assert.Contains(t, args, "-i speech-a.wav")
+	assert.Contains(t, args, "-t 2 -i speech-b.wav")
+	assert.Empty(t, e.Validate())
+
+	spec.Clips[0].Layers[1].Start = -1
+	assert.Equal(t, ValidationErrors{{Path: "clips[0].layers[1].start", Message: "不能为负数"}}, e.Validate())
+
+	// 没有语音合成引擎时无法确定片段时长
+	SetSpeechSynthesizer(nil)
+	spec.Clips[0].Layers[1].Start = 0
+	assert.Equal(t, ValidationErrors{
+		{Path: "clips[0].layers[1]", Message: "未配置语音合成引擎，不能使用 voice 层"},
+		{Path: "clips[1].layers[1]", Message: "未配置语音合成引擎，不能使用 voice 层"},
+	}, e.Validate())
+}
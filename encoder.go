@@ -0,0 +1,608 @@
+package ffmpeg_go
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// RateControl the rate control mode of an encoder.
+type RateControl string
+
+const (
+	// RateControlCRF constant quality
+	RateControlCRF RateControl = "crf"
+	// RateControlCBR constant bitrate
+	RateControlCBR RateControl = "cbr"
+	// RateControlVBR average bitrate, optionally capped by MaxRate
+	RateControlVBR RateControl = "vbr"
+	// RateControlConstrained constant quality capped by MaxRate, e.g. for streaming
+	RateControlConstrained RateControl = "constrained"
+)
+
+// VideoRate rate control settings of a video encoder.  An empty Mode keeps the encoder defaults.
+type VideoRate struct {
+	Mode RateControl `json:"mode,omitempty"`
+	// CRF used by the crf and constrained modes
+	CRF int `json:"crf,omitempty"`
+	// Bitrate the target of the cbr and vbr modes, e.g. "4M"
+	Bitrate string `json:"bitrate,omitempty"`
+	MaxRate string `json:"maxRate,omitempty"`
+	BufSize string `json:"bufSize,omitempty"`
+}
+
+// ColorMetadata color properties written to the video stream, e.g. "bt709".
+type ColorMetadata struct {
+	Primaries string `json:"primaries,omitempty"`
+	Transfer  string `json:"transfer,omitempty"`
+	Space     string `json:"space,omitempty"`
+	// Range "tv" (limited) or "pc" (full)
+	Range string `json:"range,omitempty"`
+}
+
+// VideoEncoding settings shared by the video encoders.
+type VideoEncoding struct {
+	Rate VideoRate `json:"rate"`
+	// GOP the maximum keyframe interval in frames
+	GOP       int           `json:"gop,omitempty"`
+	KeyintMin int           `json:"keyintMin,omitempty"`
+	PixFmt    string        `json:"pixFmt,omitempty"`
+	Color     ColorMetadata `json:"color,omitempty"`
+}
+
+// EncoderProfile typed options of an encoder.
+type EncoderProfile interface {
+	// StreamType the type of the streams the encoder applies to
+	StreamType() StreamType
+	// Options returns the validated encoder options, keyed without stream specifier
+	Options() (KwArgs, error)
+}
+
+func checkOneOf(field, value string, allowed ...string) error {
+	if value == "" {
+		return nil
+	}
+	for _, a := range allowed {
+		if value == a {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid %s %q, must be one of %v", field, value, allowed)
+}
+
+func checkRange(field string, value, min, max int) error {
+	if value < min || value > max {
+		return fmt.Errorf("invalid %s %d, must be between %d and %d", field, value, min, max)
+	}
+	return nil
+}
+
+// options returns the rate control options, maxCRF is the upper bound of the crf scale of the encoder.
+func (r VideoRate) options(maxCRF int) (KwArgs, error) {
+	args := KwArgs{}
+	switch r.Mode {
+	case "":
+	case RateControlCRF:
+		if err := checkRange("crf", r.CRF, 0, maxCRF); err != nil {
+			return nil, err
+		}
+		args["crf"] = r.CRF
+	case RateControlCBR:
+		if r.Bitrate == "" {
+			return nil, fmt.Errorf("cbr rate control requires a bitrate")
+		}
+		args["b"], args["minrate"], args["maxrate"] = r.Bitrate, r.Bitrate, r.Bitrate
+		args["bufsize"] = r.Bitrate
+	case RateControlVBR:
+		if r.Bitrate == "" {
+			return nil, fmt.Errorf("vbr rate control requires a bitrate")
+		}
+		args["b"] = r.Bitrate
+		if r.MaxRate != "" {
+			args["maxrate"] = r.MaxRate
+		}
+	case RateControlConstrained:
+		if err := checkRange("crf", r.CRF, 0, maxCRF); err != nil {
+			return nil, err
+		}
+		if r.MaxRate == "" {
+			return nil, fmt.Errorf("constrained rate control requires a max rate")
+		}
+		args["crf"], args["maxrate"], args["bufsize"] = r.CRF, r.MaxRate, r.MaxRate
+	default:
+		return nil, fmt.Errorf("unknown rate control mode %q", r.Mode)
+	}
+	if r.BufSize != "" && r.Mode != "" {
+		args["bufsize"] = r.BufSize
+	}
+	return args, nil
+}
+
+// options returns the gop, pixel format and color options.
+func (v VideoEncoding) options(maxCRF int) (KwArgs, error) {
+	args, err := v.Rate.options(maxCRF)
+	if err != nil {
+		return nil, err
+	}
+	if v.GOP < 0 || v.KeyintMin < 0 {
+		return nil, fmt.Errorf("gop and keyint_min must not be negative")
+	}
+	if v.GOP > 0 {
+		args["g"] = v.GOP
+	}
+	if v.KeyintMin > 0 {
+		args["keyint_min"] = v.KeyintMin
+	}
+	if v.PixFmt != "" {
+		args["pix_fmt"] = v.PixFmt
+	}
+	if err := checkOneOf("color range", v.Color.Range, "tv", "pc"); err != nil {
+		return nil, err
+	}
+	for k, value := range map[string]string{
+		"color_primaries": v.Color.Primaries,
+		"color_trc":       v.Color.Transfer,
+		"colorspace":      v.Color.Space,
+		"color_range":     v.Color.Range,
+	} {
+		if value != "" {
+			args[k] = value
+		}
+	}
+	return args, nil
+}
+
+var x26xPresets = []string{"ultrafast", "superfast", "veryfast", "faster", "fast", "medium", "slow", "slower", "veryslow", "placebo"}
+
+// X264Profile options of libx264.
+type X264Profile struct {
+	Preset string `json:"preset,omitempty"`
+	Tune   string `json:"tune,omitempty"`
+	// Profile e.g. "high", Level e.g. "4.1"
+	Profile string `json:"profile,omitempty"`
+	Level   string `json:"level,omitempty"`
+	VideoEncoding
+}
+
+func (p *X264Profile) StreamType() StreamType { return StreamTypeVideo }
+
+func (p *X264Profile) Options() (KwArgs, error) {
+	for _, err := range []error{
+		checkOneOf("x264 preset", p.Preset, x26xPresets...),
+		checkOneOf("x264 tune", p.Tune, "film", "animation", "grain", "stillimage", "fastdecode", "zerolatency", "psnr", "ssim"),
+		checkOneOf("x264 profile", p.Profile, "baseline", "main", "high", "high10", "high422", "high444"),
+	} {
+		if err != nil {
+			return nil, err
+		}
+	}
+	args, err := p.VideoEncoding.options(51)
+	if err != nil {
+		return nil, err
+	}
+	args["c"] = "libx264"
+	setIfNotEmpty(args, "preset", p.Preset)
+	setIfNotEmpty(args, "tune", p.Tune)
+	setIfNotEmpty(args, "profile", p.Profile)
+	setIfNotEmpty(args, "level", p.Level)
+	return args, nil
+}
+
+// X265Profile options of libx265.
+type X265Profile struct {
+	Preset string `json:"preset,omitempty"`
+	Tune   string `json:"tune,omitempty"`
+	// Profile e.g. "main10", Level e.g. "5.1"
+	Profile string `json:"profile,omitempty"`
+	Level   string `json:"level,omitempty"`
+	VideoEncoding
+}
+
+func (p *X265Profile) StreamType() StreamType { return StreamTypeVideo }
+
+func (p *X265Profile) Options() (KwArgs, error) {
+	for _, err := range []error{
+		checkOneOf("x265 preset", p.Preset, x26xPresets...),
+		checkOneOf("x265 tune", p.Tune, "psnr", "ssim", "grain", "zerolatency", "fastdecode", "animation"),
+		checkOneOf("x265 profile", p.Profile, "main", "main10", "mainstillpicture", "main422-10", "main444-8", "main444-10"),
+	} {
+		if err != nil {
+			return nil, err
+		}
+	}
+	args, err := p.VideoEncoding.options(51)
+	if err != nil {
+		return nil, err
+	}
+	args["c"] = "libx265"
+	setIfNotEmpty(args, "preset", p.Preset)
+	setIfNotEmpty(args, "tune", p.Tune)
+	setIfNotEmpty(args, "profile", p.Profile)
+	if p.Level != "" {
+		args["x265-params"] = "level-idc=" + p.Level
+	}
+	// mp4 players such as Safari only recognize the hvc1 tag
+	args["tag"] = "hvc1"
+	return args, nil
+}
+
+// VP9Profile options of libvpx-vp9.
+type VP9Profile struct {
+	// Deadline "good", "best" or "realtime"
+	Deadline string `json:"deadline,omitempty"`
+	// CPUUsed speed of the encoder, from -8 to 8, higher is faster
+	CPUUsed int `json:"cpuUsed,omitempty"`
+	// Profile 0 to 3, 2 and 3 for high bit depth
+	Profile     int    `json:"profile,omitempty"`
+	TuneContent string `json:"tuneContent,omitempty"`
+	RowMT       bool   `json:"rowMT,omitempty"`
+	VideoEncoding
+}
+
+func (p *VP9Profile) StreamType() StreamType { return StreamTypeVideo }
+
+func (p *VP9Profile) Options() (KwArgs, error) {
+	for _, err := range []error{
+		checkOneOf("vp9 deadline", p.Deadline, "good", "best", "realtime"),
+		checkOneOf("vp9 tune content", p.TuneContent, "default", "screen", "film"),
+		checkRange("vp9 cpu-used", p.CPUUsed, -8, 8),
+		checkRange("vp9 profile", p.Profile, 0, 3),
+	} {
+		if err != nil {
+			return nil, err
+		}
+	}
+	args, err := p.VideoEncoding.options(63)
+	if err != nil {
+		return nil, err
+	}
+	switch p.Rate.Mode {
+	case RateControlCRF:
+		// without a zero bitrate libvpx treats crf as a quality floor of the default bitrate
+		args["b"] = "0"
+	case RateControlConstrained:
+		args["b"] = p.Rate.MaxRate
+		delete(args, "maxrate")
+		delete(args, "bufsize")
+	}
+	args["c"] = "libvpx-vp9"
+	setIfNotEmpty(args, "deadline", p.Deadline)
+	setIfNotEmpty(args, "tune-content", p.TuneContent)
+	if p.CPUUsed != 0 {
+		args["cpu-used"] = p.CPUUsed
+	}
+	if p.Profile != 0 {
+		args["profile"] = p.Profile
+	}
+	if p.RowMT {
+		args["row-mt"] = "1"
+	}
+	return args, nil
+}
+
+// SVTAV1Profile options of libsvtav1.
+type SVTAV1Profile struct {
+	// Preset 0 to 13, higher is faster, nil keeps the encoder default
+	Preset *int `json:"preset,omitempty"`
+	// Tune 0 for visual quality (vq), 1 for psnr
+	Tune    *int   `json:"tune,omitempty"`
+	Profile string `json:"profile,omitempty"`
+	Level   string `json:"level,omitempty"`
+	VideoEncoding
+}
+
+func (p *SVTAV1Profile) StreamType() StreamType { return StreamTypeVideo }
+
+func (p *SVTAV1Profile) Options() (KwArgs, error) {
+	if p.Rate.Mode == RateControlCBR {
+		return nil, fmt.Errorf("cbr rate control is not supported by libsvtav1")
+	}
+	if err := checkOneOf("svt-av1 profile", p.Profile, "main", "high", "professional"); err != nil {
+		return nil, err
+	}
+	args, err := p.VideoEncoding.options(63)
+	if err != nil {
+		return nil, err
+	}
+	args["c"] = "libsvtav1"
+	if p.Preset != nil {
+		if err := checkRange("svt-av1 preset", *p.Preset, 0, 13); err != nil {
+			return nil, err
+		}
+		args["preset"] = *p.Preset
+	}
+	if p.Tune != nil {
+		if err := checkRange("svt-av1 tune", *p.Tune, 0, 1); err != nil {
+			return nil, err
+		}
+		args["svtav1-params"] = "tune=" + strconv.Itoa(*p.Tune)
+	}
+	setIfNotEmpty(args, "profile", p.Profile)
+	setIfNotEmpty(args, "level", p.Level)
+	return args, nil
+}
+
+// AACProfile options of the native aac encoder, or libfdk_aac.
+type AACProfile struct {
+	// Encoder "aac" (default) or "libfdk_aac"
+	Encoder string `json:"encoder,omitempty"`
+	// Profile e.g. "aac_low", the HE profiles require libfdk_aac
+	Profile string `json:"profile,omitempty"`
+	Bitrate string `json:"bitrate,omitempty"`
+	// VBR libfdk_aac vbr mode from 1 to 5, replaces the bitrate
+	VBR        int `json:"vbr,omitempty"`
+	SampleRate int `json:"sampleRate,omitempty"`
+	Channels   int `json:"channels,omitempty"`
+}
+
+func (p *AACProfile) StreamType() StreamType { return StreamTypeAudio }
+
+func (p *AACProfile) Options() (KwArgs, error) {
+	encoder := p.Encoder
+	if encoder == "" {
+		encoder = "aac"
+	}
+	if err := checkOneOf("aac encoder", encoder, "aac", "libfdk_aac"); err != nil {
+		return nil, err
+	}
+	profiles := []string{"aac_low", "mpeg2_aac_low", "aac_ltp", "aac_main"}
+	if encoder == "libfdk_aac" {
+		profiles = []string{"aac_low", "aac_he", "aac_he_v2", "aac_ld", "aac_eld"}
+	}
+	if err := checkOneOf("aac profile", p.Profile, profiles...); err != nil {
+		return nil, err
+	}
+	args := KwArgs{"c": encoder}
+	if p.VBR != 0 {
+		if encoder != "libfdk_aac" {
+			return nil, fmt.Errorf("aac vbr mode requires libfdk_aac")
+		}
+		if err := checkRange("aac vbr", p.VBR, 1, 5); err != nil {
+			return nil, err
+		}
+		args["vbr"] = p.VBR
+	} else {
+		setIfNotEmpty(args, "b", p.Bitrate)
+	}
+	setIfNotEmpty(args, "profile", p.Profile)
+	setAudioFormat(args, p.SampleRate, p.Channels)
+	return args, nil
+}
+
+// OpusProfile options of libopus.
+type OpusProfile struct {
+	Bitrate string `json:"bitrate,omitempty"`
+	// VBR "on", "off" or "constrained"
+	VBR string `json:"vbr,omitempty"`
+	// Application "voip", "audio" or "lowdelay"
+	Application string `json:"application,omitempty"`
+	// CompressionLevel 0 to 10, nil keeps the encoder default (10)
+	CompressionLevel *int `json:"compressionLevel,omitempty"`
+	// FrameDuration in milliseconds
+	FrameDuration float64 `json:"frameDuration,omitempty"`
+	// SampleRate opus only supports 8000, 12000, 16000, 24000 and 48000
+	SampleRate int `json:"sampleRate,omitempty"`
+	Channels   int `json:"channels,omitempty"`
+}
+
+func (p *OpusProfile) StreamType() StreamType { return StreamTypeAudio }
+
+func (p *OpusProfile) Options() (KwArgs, error) {
+	for _, err := range []error{
+		checkOneOf("opus vbr", p.VBR, "on", "off", "constrained"),
+		checkOneOf("opus application", p.Application, "voip", "audio", "lowdelay"),
+		checkOneOf("opus frame duration", strconv.FormatFloat(p.FrameDuration, 'f', -1, 64),
+			"0", "2.5", "5", "10", "20", "40", "60", "80", "100", "120"),
+		checkOneOf("opus sample rate", strconv.Itoa(p.SampleRate), "0", "8000", "12000", "16000", "24000", "48000"),
+	} {
+		if err != nil {
+			return nil, err
+		}
+	}
+	args := KwArgs{"c": "libopus"}
+	setIfNotEmpty(args, "b", p.Bitrate)
+	setIfNotEmpty(args, "vbr", p.VBR)
+	setIfNotEmpty(args, "application", p.Application)
+	if p.CompressionLevel != nil {
+		if err := checkRange("opus compression level", *p.CompressionLevel, 0, 10); err != nil {
+			return nil, err
+		}
+		args["compression_level"] = *p.CompressionLevel
+	}
+	if p.FrameDuration != 0 {
+		args["frame_duration"] = strconv.FormatFloat(p.FrameDuration, 'f', -1, 64)
+	}
+	setAudioFormat(args, p.SampleRate, p.Channels)
+	return args, nil
+}
+
+func setIfNotEmpty(args KwArgs, k, v string) {
+	if v != "" {
+		args[k] = v
+	}
+}
+
+func setAudioFormat(args KwArgs, sampleRate, channels int) {
+	if sampleRate > 0 {
+		args["ar"] = sampleRate
+	}
+	if channels > 0 {
+		args["ac"] = channels
+	}
+}
+
+// encoderOutputArgs returns the options of the profile bound to its stream type, e.g. “-crf:v“.
+func encoderOutputArgs(profile EncoderProfile) (KwArgs, error) {
+	options, err := profile.Options()
+	if err != nil {
+		return nil, err
+	}
+	specifier := SpecType(profile.StreamType())
+	args := KwArgs{}
+	for k, v := range options {
+		args[streamOptionKey(k, specifier)] = v
+	}
+	return args, nil
+}
+
+// WithEncoder applies an encoder profile to the output streams of its type.
+func (s *Stream) WithEncoder(profile EncoderProfile) *Stream {
+	args, err := encoderOutputArgs(profile)
+	if err != nil {
+		panic(err)
+	}
+	return s.withOutputStreams(nil, args)
+}
+
+// EncodingPreset a named combination of encoder profiles and container options, e.g. "web-1080p".
+type EncodingPreset struct {
+	Name  string         `json:"name"`
+	Video EncoderProfile `json:"-"`
+	Audio EncoderProfile `json:"-"`
+	// KwArgs container options, e.g. KwArgs{"movflags": "+faststart"}
+	KwArgs KwArgs `json:"kwargs,omitempty"`
+	// Resolution the largest short side of the frame the rates are tuned for, e.g. 1080, 0 for no limit.
+	// Output options can't resize, so it is applied by the code building the filter graph with FitSize or ScaleVideo.
+	Resolution int `json:"resolution,omitempty"`
+}
+
+// FitSize returns the frame size scaled down to the resolution of the preset, keeping the aspect ratio and even
+// dimensions.  Frames within the limit are returned unchanged.
+func (p *EncodingPreset) FitSize(width, height int) (int, int) {
+	short := width
+	if height < short {
+		short = height
+	}
+	if p.Resolution <= 0 || short <= p.Resolution {
+		return width, height
+	}
+	scale := float64(p.Resolution) / float64(short)
+	even := func(v int) int { return int(math.Round(float64(v)*scale/2)) * 2 }
+	return even(width), even(height)
+}
+
+// ScaleVideo scales a video stream of unknown size down to the resolution of the preset, like FitSize.
+func (p *EncodingPreset) ScaleVideo(video *Stream) *Stream {
+	if p.Resolution <= 0 {
+		return video
+	}
+	portrait := Lte(Var("iw"), Var("ih"))
+	return video.Filter("scale", nil, KwArgs{
+		"w": If(portrait, Min(Var("iw"), p.Resolution), -2),
+		"h": If(portrait, -2, Min(Var("ih"), p.Resolution)),
+	})
+}
+
+// OutputArgs returns the validated output options of the preset.
+func (p *EncodingPreset) OutputArgs() (KwArgs, error) {
+	args := p.KwArgs.Copy()
+	for _, profile := range []EncoderProfile{p.Video, p.Audio} {
+		if profile == nil {
+			continue
+		}
+		options, err := encoderOutputArgs(profile)
+		if err != nil {
+			return nil, fmt.Errorf("encoding preset %s: %w", p.Name, err)
+		}
+		for k, v := range options {
+			args[k] = v
+		}
+	}
+	return args, nil
+}
+
+var encodingPresets = struct {
+	sync.RWMutex
+	m map[string]*EncodingPreset
+}{m: map[string]*EncodingPreset{}}
+
+// RegisterEncodingPreset validates and registers a preset, replacing any preset with the same name.
+func RegisterEncodingPreset(preset *EncodingPreset) error {
+	if preset.Name == "" {
+		return fmt.Errorf("encoding preset must have a name")
+	}
+	if _, err := preset.OutputArgs(); err != nil {
+		return err
+	}
+	encodingPresets.Lock()
+	defer encodingPresets.Unlock()
+	encodingPresets.m[preset.Name] = preset
+	return nil
+}
+
+// GetEncodingPreset returns the preset registered with the name.
+func GetEncodingPreset(name string) (*EncodingPreset, bool) {
+	encodingPresets.RLock()
+	defer encodingPresets.RUnlock()
+	p, ok := encodingPresets.m[name]
+	return p, ok
+}
+
+// EncodingPresetNames returns the names of all registered presets, sorted.
+func EncodingPresetNames() []string {
+	encodingPresets.RLock()
+	defer encodingPresets.RUnlock()
+	var names []string
+	for name := range encodingPresets.m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WithEncodingPreset applies a registered preset to the output.  The resolution of the preset is not applied, scale
+// the video with ScaleVideo before the output.
+func (s *Stream) WithEncodingPreset(name string) *Stream {
+	preset, ok := GetEncodingPreset(name)
+	if !ok {
+		panic(fmt.Sprintf("unknown encoding preset %q", name))
+	}
+	args, err := preset.OutputArgs()
+	if err != nil {
+		panic(err)
+	}
+	return s.withOutputStreams(nil, args)
+}
+
+// DefaultEditlyEncoding the preset used by Editly when the spec doesn't name one.
+const DefaultEditlyEncoding = "editly-default"
+
+func init() {
+	h264 := func(preset string, crf int, maxRate string) *X264Profile {
+		rate := VideoRate{Mode: RateControlCRF, CRF: crf}
+		if maxRate != "" {
+			rate = VideoRate{Mode: RateControlConstrained, CRF: crf, MaxRate: maxRate, BufSize: maxRate}
+		}
+		return &X264Profile{Preset: preset, Profile: "high", VideoEncoding: VideoEncoding{
+			Rate: rate, GOP: 60, PixFmt: "yuv420p",
+			Color: ColorMetadata{Primaries: "bt709", Transfer: "bt709", Space: "bt709", Range: "tv"},
+		}}
+	}
+	aac := &AACProfile{Bitrate: "128k", SampleRate: 48000, Channels: 2}
+	faststart := KwArgs{"movflags": "+faststart"}
+	for _, preset := range []*EncodingPreset{
+		{Name: "web-1080p", Video: h264("medium", 23, "6M"), Audio: aac, KwArgs: faststart, Resolution: 1080},
+		{Name: "web-720p", Video: h264("medium", 23, "3M"), Audio: aac, KwArgs: faststart, Resolution: 720},
+		{Name: "hevc-1080p", Video: &X265Profile{Preset: "medium", Profile: "main", VideoEncoding: VideoEncoding{
+			Rate: VideoRate{Mode: RateControlCRF, CRF: 26}, GOP: 60, PixFmt: "yuv420p"}}, Audio: aac, KwArgs: faststart, Resolution: 1080},
+		{Name: "vp9-1080p", Video: &VP9Profile{Deadline: "good", CPUUsed: 2, RowMT: true, VideoEncoding: VideoEncoding{
+			Rate: VideoRate{Mode: RateControlCRF, CRF: 31}, GOP: 240, PixFmt: "yuv420p"}},
+			Audio: &OpusProfile{Bitrate: "128k", SampleRate: 48000}, Resolution: 1080},
+		{Name: "av1-1080p", Video: &SVTAV1Profile{Preset: intPtr(8), VideoEncoding: VideoEncoding{
+			Rate: VideoRate{Mode: RateControlCRF, CRF: 35}, GOP: 240, PixFmt: "yuv420p"}}, Audio: aac, KwArgs: faststart, Resolution: 1080},
+		{Name: "preview", Video: &X264Profile{Preset: "ultrafast", Tune: "fastdecode", VideoEncoding: VideoEncoding{
+			Rate: VideoRate{Mode: RateControlCRF, CRF: 30}, PixFmt: "yuv420p"}}, Audio: &AACProfile{Bitrate: "96k"}},
+		{Name: DefaultEditlyEncoding, Video: &X264Profile{Preset: "ultrafast", VideoEncoding: VideoEncoding{
+			Rate: VideoRate{Mode: RateControlCRF, CRF: 23}, PixFmt: "yuv420p"}}, Audio: &AACProfile{Bitrate: "128k"}},
+	} {
+		if err := RegisterEncodingPreset(preset); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,76 @@
+package ffmpeg_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoderProfiles(t *testing.T) {
+	args := Input("in.mp4").Output("out.mp4").
+		WithEncoder(&X264Profile{Preset: "slow", Tune: "film", Profile: "high", Level: "4.1", VideoEncoding: VideoEncoding{
+			Rate: VideoRate{Mode: RateControlConstrained, CRF: 20, MaxRate: "5M", BufSize: "10M"},
+			GOP:  50, PixFmt: "yuv420p", Color: ColorMetadata{Primaries: "bt709"},
+		}}).
+		WithEncoder(&OpusProfile{Bitrate: "96k", VBR: "on"}).
+		GetArgs()
+	assert.Equal(t, []string{"-i", "in.mp4",
+		"-b:a", "96k", "-bufsize:v", "10M", "-c:a", "libopus", "-c:v", "libx264", "-color_primaries:v", "bt709",
+		"-crf:v", "20", "-g:v", "50", "-level:v", "4.1", "-maxrate:v", "5M", "-pix_fmt:v", "yuv420p",
+		"-preset:v", "slow", "-profile:v", "high", "-tune:v", "film", "-vbr:a", "on",
+		"out.mp4"}, args)
+
+	options, err := (&VP9Profile{VideoEncoding: VideoEncoding{Rate: VideoRate{Mode: RateControlCRF, CRF: 31}}}).Options()
+	assert.Nil(t, err)
+	assert.Equal(t, KwArgs{"c": "libvpx-vp9", "crf": 31, "b": "0"}, options)
+
+	options, err = (&AACProfile{Bitrate: "128k", SampleRate: 44100, Channels: 2}).Options()
+	assert.Nil(t, err)
+	assert.Equal(t, KwArgs{"c": "aac", "b": "128k", "ar": 44100, "ac": 2}, options)
+}
+
+func TestEncoderProfileValidation(t *testing.T) {
+	for _, profile := range []EncoderProfile{
+		&X264Profile{Preset: "turbo"},
+		&X264Profile{VideoEncoding: VideoEncoding{Rate: VideoRate{Mode: RateControlCRF, CRF: 60}}},
+		&X265Profile{VideoEncoding: VideoEncoding{Rate: VideoRate{Mode: RateControlCBR}}},
+		&SVTAV1Profile{VideoEncoding: VideoEncoding{Rate: VideoRate{Mode: RateControlCBR, Bitrate: "2M"}}},
+		&SVTAV1Profile{Preset: intPtr(14)},
+		&AACProfile{Profile: "aac_he"},
+		&OpusProfile{SampleRate: 44100},
+	} {
+		_, err := profile.Options()
+		assert.NotNil(t, err, "%#v", profile)
+	}
+}
+
+func TestEncodingPresets(t *testing.T) {
+	assert.Contains(t, EncodingPresetNames(), "web-1080p")
+	for _, name := range EncodingPresetNames() {
+		preset, _ := GetEncodingPreset(name)
+		_, err := preset.OutputArgs()
+		assert.Nil(t, err, name)
+	}
+	assert.Nil(t, RegisterEncodingPreset(&EncodingPreset{Name: "test-audio", Audio: &AACProfile{Bitrate: "64k"}}))
+	args := Input("in.wav").Output("out.m4a").WithEncodingPreset("test-audio").GetArgs()
+	assert.Equal(t, []string{"-i", "in.wav", "-b:a", "64k", "-c:a", "aac", "out.m4a"}, args)
+	assert.NotNil(t, RegisterEncodingPreset(&EncodingPreset{Name: "broken", Video: &X264Profile{Tune: "none"}}))
+	assert.Panics(t, func() { Input("in.wav").Output("out.m4a").WithEncodingPreset("missing") })
+}
+
+func TestEncodingPresetResolution(t *testing.T) {
+	preset, _ := GetEncodingPreset("web-720p")
+	w, h := preset.FitSize(1920, 1080)
+	assert.Equal(t, []int{1280, 720}, []int{w, h})
+	w, h = preset.FitSize(1080, 1920)
+	assert.Equal(t, []int{720, 1280}, []int{w, h})
+	w, h = preset.FitSize(640, 360)
+	assert.Equal(t, []int{640, 360}, []int{w, h})
+
+	args := preset.ScaleVideo(Input("in.mp4").Video()).Output("out.mp4").GetArgs()
+	assert.Contains(t, args, "[0:v]scale=h=if(lte(iw\\,ih)\\,(-2)\\,min(ih\\,720)):w=if(lte(iw\\,ih)\\,min(iw\\,720)\\,(-2))[s0]")
+
+	unlimited, _ := GetEncodingPreset("preview")
+	video := Input("in.mp4").Video()
+	assert.Equal(t, video, unlimited.ScaleVideo(video))
+}
@@ -0,0 +1,243 @@
+package ffmpeg_go
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Expr is a node of an ffmpeg arithmetic expression (https://ffmpeg.org/ffmpeg-utils.html#Expression-Evaluation).
+//
+// String renders the expression unescaped, so an Expr can be used as a KwArgs value directly: the commas are
+// escaped when the filter graph is compiled.  Use RenderExpr when the expression is placed in a string by hand.
+type Expr interface {
+	String() string
+}
+
+// ExprContext the place where a rendered expression is going to be used, which decides how it is escaped.
+type ExprContext int
+
+const (
+	// ExprContextRaw the expression as evaluated by libavutil, e.g. in a sendcmd file argument
+	ExprContextRaw ExprContext = iota
+	// ExprContextFilterOption the expression as the value of a filter option, e.g. “enable=...“
+	ExprContextFilterOption
+	// ExprContextFilterGraph the expression as the value of a filter option inside a “-vf“ or “-filter_complex“ string
+	ExprContextFilterGraph
+)
+
+// RenderExpr renders the expression escaped for the given context.
+func RenderExpr(e Expr, ctx ExprContext) string {
+	s := e.String()
+	switch ctx {
+	case ExprContextFilterOption:
+		return escapeChars(s, "\\'=:")
+	case ExprContextFilterGraph:
+		return escapeChars(escapeChars(s, "\\'=:"), "\\'[],;")
+	default:
+		return s
+	}
+}
+
+type exprNumber float64
+
+func (e exprNumber) String() string {
+	if e < 0 {
+		return fmt.Sprintf("(%s)", strconv.FormatFloat(float64(e), 'f', -1, 64))
+	}
+	return strconv.FormatFloat(float64(e), 'f', -1, 64)
+}
+
+type exprRaw string
+
+func (e exprRaw) String() string {
+	return string(e)
+}
+
+type exprCall struct {
+	name string
+	args []Expr
+}
+
+func (e exprCall) String() string {
+	var args []string
+	for _, a := range e.args {
+		args = append(args, a.String())
+	}
+	return fmt.Sprintf("%s(%s)", e.name, strings.Join(args, ","))
+}
+
+type exprBinary struct {
+	op          string
+	left, right Expr
+}
+
+func (e exprBinary) String() string {
+	return fmt.Sprintf("(%s%s%s)", e.left.String(), e.op, e.right.String())
+}
+
+// Variables commonly available in filter expressions, see the documentation of each filter for the full list.
+var (
+	// T timestamp in seconds
+	T = Var("t")
+	// N frame number, starting from 0
+	N = Var("n")
+	// W, H width and height of the input (or of the main input for overlay)
+	W = Var("W")
+	H = Var("H")
+	// OverlayW, OverlayH width and height of the overlay input (overlay) or of the output (zoompan)
+	OverlayW = Var("w")
+	OverlayH = Var("h")
+	// TextW, TextH the rendered text size (drawtext)
+	TextW = Var("text_w")
+	TextH = Var("text_h")
+	// InputW, InputH the input size (scale, crop, pad, zoompan)
+	InputW = Var("iw")
+	InputH = Var("ih")
+	// Zoom the current zoom factor (zoompan)
+	Zoom = Var("zoom")
+	// On the output frame number, starting from 0 (zoompan)
+	On = Var("on")
+)
+
+// Var refers to a variable by name, e.g. “Var("main_w")“.
+func Var(name string) Expr {
+	return exprRaw(name)
+}
+
+// Raw wraps an already written expression.
+func Raw(expr string) Expr {
+	return exprRaw(expr)
+}
+
+// Num is a numeric constant.
+func Num(v float64) Expr {
+	return exprNumber(v)
+}
+
+// toExpr accepts an Expr, a number or a raw expression string.
+func toExpr(v interface{}) Expr {
+	switch a := v.(type) {
+	case Expr:
+		return a
+	case int:
+		return exprNumber(a)
+	case int64:
+		return exprNumber(a)
+	case float32:
+		return exprNumber(a)
+	case float64:
+		return exprNumber(a)
+	case string:
+		return exprRaw(a)
+	default:
+		panic(fmt.Sprintf("unsupported expression operand %T", v))
+	}
+}
+
+func call(name string, args ...interface{}) Expr {
+	c := exprCall{name: name}
+	for _, a := range args {
+		c.args = append(c.args, toExpr(a))
+	}
+	return c
+}
+
+// Add returns a+b.
+func Add(a, b interface{}) Expr { return exprBinary{"+", toExpr(a), toExpr(b)} }
+
+// Sub returns a-b.
+func Sub(a, b interface{}) Expr { return exprBinary{"-", toExpr(a), toExpr(b)} }
+
+// Mul returns a*b.
+func Mul(a, b interface{}) Expr { return exprBinary{"*", toExpr(a), toExpr(b)} }
+
+// Div returns a/b.
+func Div(a, b interface{}) Expr { return exprBinary{"/", toExpr(a), toExpr(b)} }
+
+// Pow returns a^b.
+func Pow(a, b interface{}) Expr { return call("pow", a, b) }
+
+// Between returns 1 if min <= x <= max, 0 otherwise, e.g. “KwArgs{"enable": Between(T, 2, 5)}“.
+func Between(x, min, max interface{}) Expr { return call("between", x, min, max) }
+
+// If returns then if cond is not zero, otherwise els.
+func If(cond, then, els interface{}) Expr { return call("if", cond, then, els) }
+
+// Min returns the smaller of a and b.
+func Min(a, b interface{}) Expr { return call("min", a, b) }
+
+// Max returns the larger of a and b.
+func Max(a, b interface{}) Expr { return call("max", a, b) }
+
+// Clamp clamps x to the range [min, max].
+func Clamp(x, min, max interface{}) Expr { return call("clip", x, min, max) }
+
+// Lt returns 1 if a < b.
+func Lt(a, b interface{}) Expr { return call("lt", a, b) }
+
+// Lte returns 1 if a <= b.
+func Lte(a, b interface{}) Expr { return call("lte", a, b) }
+
+// Gt returns 1 if a > b.
+func Gt(a, b interface{}) Expr { return call("gt", a, b) }
+
+// Gte returns 1 if a >= b.
+func Gte(a, b interface{}) Expr { return call("gte", a, b) }
+
+// Eq returns 1 if a == b.
+func Eq(a, b interface{}) Expr { return call("eq", a, b) }
+
+// Not returns 1 if x is zero.
+func Not(x interface{}) Expr { return call("not", x) }
+
+// And returns a non zero value if both a and b are not zero.
+func And(a, b interface{}) Expr { return Mul(Not(Not(a)), Not(Not(b))) }
+
+// Or returns 1 if a or b is not zero.
+func Or(a, b interface{}) Expr { return Gt(Add(Not(Not(a)), Not(Not(b))), 0) }
+
+// Lerp interpolates linearly from a to b as p goes from 0 to 1.
+func Lerp(a, b, p interface{}) Expr { return Add(a, Mul(Sub(b, a), p)) }
+
+// Progress maps x linearly from [start, end] to [0, 1], clamped at both ends.
+func Progress(x, start, end interface{}) Expr {
+	return Clamp(Div(Sub(x, start), Sub(end, start)), 0, 1)
+}
+
+// Easing the name of an easing curve.
+type Easing string
+
+const (
+	EasingLinear    Easing = "linear"
+	EasingEaseIn    Easing = "easeIn"
+	EasingEaseOut   Easing = "easeOut"
+	EasingEaseInOut Easing = "easeInOut"
+)
+
+// Ease applies the easing curve to a progress p in the range [0, 1].
+func Ease(easing Easing, p interface{}) Expr {
+	x := toExpr(p)
+	switch easing {
+	case EasingEaseIn:
+		return Mul(x, x)
+	case EasingEaseOut:
+		return Mul(x, Sub(2, x))
+	case EasingEaseInOut:
+		return Mul(Mul(x, x), Sub(3, Mul(2, x)))
+	case EasingLinear, "":
+		return x
+	default:
+		panic(fmt.Sprintf("unknown easing: %s", easing))
+	}
+}
+
+// EaseInOut is a shortcut of Ease(EasingEaseInOut, p).
+func EaseInOut(p interface{}) Expr { return Ease(EasingEaseInOut, p) }
+
+// Tween animates from `from` to `to` while x goes from start to end, holding the end values outside of the range.
+//
+//	Tween(T, 1, 2, 0, 1, EasingEaseInOut) // fade in between 1s and 2s
+func Tween(x, start, end, from, to interface{}, easing Easing) Expr {
+	return Lerp(from, to, Ease(easing, Progress(x, start, end)))
+}
@@ -0,0 +1,34 @@
+package ffmpeg_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExprRender(t *testing.T) {
+	assert.Equal(t, "between(t,2,5)", Between(T, 2, 5).String())
+	assert.Equal(t, "if(lt(t,1),t,1)", If(Lt(T, 1), T, 1).String())
+	assert.Equal(t, "(0+((100-0)*t))", Lerp(0, 100, T).String())
+	assert.Equal(t, "max(min(W,(-1.5)),0)", Max(Min(W, -1.5), 0).String())
+	assert.Equal(t, "((x*x)*(3-(2*x)))", EaseInOut(Var("x")).String())
+	assert.Equal(t, "clip(((t-1)/(3-1)),0,1)", Progress(T, 1, 3).String())
+}
+
+func TestExprEscape(t *testing.T) {
+	e := If(Between(T, 2, 5), Raw("'a:b'"), 0)
+	assert.Equal(t, "if(between(t,2,5),'a:b',0)", RenderExpr(e, ExprContextRaw))
+	assert.Equal(t, `if(between(t,2,5),\'a\:b\',0)`, RenderExpr(e, ExprContextFilterOption))
+	assert.Equal(t, `if(between(t\,2\,5)\,\\\'a\\:b\\\'\,0)`, RenderExpr(e, ExprContextFilterGraph))
+}
+
+func TestExprAsFilterOption(t *testing.T) {
+	args := Input("in.mp4").
+		Drawtext("hello", 0, 0, false, KwArgs{"enable": Between(T, 2, 5), "alpha": Tween(T, 2, 3, 0, 1, EasingLinear)}).
+		Output("out.mp4").GetArgs()
+	assert.Equal(t, []string{
+		"-i", "in.mp4",
+		"-filter_complex", `[0]drawtext=alpha=(0+((1-0)*clip(((t-2)/(3-2))\,0\,1))):enable=between(t\,2\,5):text=hello[s0]`,
+		"-map", "[s0]", "out.mp4",
+	}, args)
+}
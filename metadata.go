@@ -0,0 +1,218 @@
+package ffmpeg_go
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Chapter a chapter marker.  End may be left zero for all but the last chapter, it then ends where the next
+// chapter starts.
+type Chapter struct {
+	Start    time.Duration     `json:"start"`
+	End      time.Duration     `json:"end"`
+	Title    string            `json:"title"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// FFMetadata a document in ffmpeg's metadata format.
+//
+// Official documentation: `Metadata <https://ffmpeg.org/ffmpeg-formats.html#Metadata-2>`__
+type FFMetadata struct {
+	Global   map[string]string
+	Chapters []Chapter
+}
+
+// escapeFFMetadata escapes the characters with a special meaning in ffmetadata files.
+func escapeFFMetadata(s string) string {
+	return strings.ReplaceAll(escapeChars(s, "\\=;#"), "\n", "\\\n")
+}
+
+func writeFFMetadataTags(b *strings.Builder, tags map[string]string) {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(b, "%s=%s\n", escapeFFMetadata(k), escapeFFMetadata(tags[k]))
+	}
+}
+
+// resolvedChapters returns the chapters sorted by start with missing ends filled in.
+func (m *FFMetadata) resolvedChapters() ([]Chapter, error) {
+	chapters := append([]Chapter{}, m.Chapters...)
+	sort.SliceStable(chapters, func(i, j int) bool { return chapters[i].Start < chapters[j].Start })
+	for i := range chapters {
+		if chapters[i].End == 0 && i+1 < len(chapters) {
+			chapters[i].End = chapters[i+1].Start
+		}
+		if chapters[i].End <= chapters[i].Start {
+			return nil, fmt.Errorf("chapter %d (%q) must end after it starts", i, chapters[i].Title)
+		}
+	}
+	return chapters, nil
+}
+
+// Encode renders the document, chapter times are written in milliseconds.
+func (m *FFMetadata) Encode() (string, error) {
+	chapters, err := m.resolvedChapters()
+	if err != nil {
+		return "", err
+	}
+	b := &strings.Builder{}
+	b.WriteString(";FFMETADATA1\n")
+	writeFFMetadataTags(b, m.Global)
+	for _, c := range chapters {
+		b.WriteString("\n[CHAPTER]\nTIMEBASE=1/1000\n")
+		fmt.Fprintf(b, "START=%d\nEND=%d\n", c.Start.Milliseconds(), c.End.Milliseconds())
+		tags := map[string]string{}
+		for k, v := range c.Metadata {
+			tags[k] = v
+		}
+		if c.Title != "" {
+			tags["title"] = c.Title
+		}
+		writeFFMetadataTags(b, tags)
+	}
+	return b.String(), nil
+}
+
+// WriteFile writes the document to path.
+func (m *FFMetadata) WriteFile(path string) error {
+	content, err := m.Encode()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// inputFile a file generated for the command, written right before it runs and removed afterwards.
+type inputFile struct {
+	path    string
+	content string
+}
+
+// inputFilesKey the context key of the generated input files of an output.
+const inputFilesKey = "inputFiles"
+
+// ffmetadataCount makes the names of generated ffmetadata files unique within the process.
+var ffmetadataCount int64
+
+// ffmetadataInput returns the document as an input stream, the file is only named here and written when the command
+// runs, so building a command that never runs leaves nothing behind.
+func ffmetadataInput(m *FFMetadata) (*Stream, inputFile) {
+	content, err := m.Encode()
+	if err != nil {
+		panic(err)
+	}
+	name := fmt.Sprintf("ffmpeg-go-%d-%d.ffmetadata", os.Getpid(), atomic.AddInt64(&ffmetadataCount, 1))
+	file := inputFile{path: filepath.Join(os.TempDir(), name), content: content}
+	return Input(file.path, KwArgs{"f": "ffmetadata"}), file
+}
+
+// WriteInputFiles writes the files generated for the command, such as the ffmetadata documents of WithMetadata and
+// WithChapters, remove deletes them again.  Run does this itself, call it when running the command from Compile.
+func (s *Stream) WriteInputFiles() (remove func(), err error) {
+	files, _ := s.Context.Value(inputFilesKey).([]inputFile)
+	remove = func() {
+		for _, f := range files {
+			_ = os.Remove(f.path)
+		}
+	}
+	for _, f := range files {
+		if err := os.WriteFile(f.path, []byte(f.content), 0644); err != nil {
+			remove()
+			return nil, err
+		}
+	}
+	return remove, nil
+}
+
+// withOutputStreams returns a copy of the output with extra streams, options and generated input files.
+func (s *Stream) withOutputStreams(streams []*Stream, kwargs KwArgs, files ...inputFile) *Stream {
+	if s.Type != "OutputStream" || s.Node.nodeType != "OutputNode" {
+		panic("output options can only be set on a stream returned by Output")
+	}
+	args := s.Node.kwargs.Copy()
+	for k, v := range kwargs {
+		args[k] = v
+	}
+	all := append(append([]*Stream{}, s.Node.streamSpec...), streams...)
+	out := NewOutputNode(s.Node.name, all, s.Node.args, args).Stream("", "")
+	out.Context = s.Context
+	if len(files) > 0 {
+		existing, _ := s.Context.Value(inputFilesKey).([]inputFile)
+		out.Context = context.WithValue(s.Context, inputFilesKey, append(append([]inputFile{}, existing...), files...))
+	}
+	out.FfmpegPath = s.FfmpegPath
+	return out
+}
+
+// WithMetadata replaces the global metadata of the output (title, artist, comment, ...).  The tags are written to an
+// ffmetadata file which is mapped with “-map_metadata“, the file is created when the command runs and removed after.
+func (s *Stream) WithMetadata(metadata map[string]string) *Stream {
+	input, file := ffmetadataInput(&FFMetadata{Global: metadata})
+	return s.withOutputStreams([]*Stream{input}, KwArgs{"map_metadata": input}, file)
+}
+
+// WithChapters writes chapter markers to the output, mapped with “-map_chapters“ from a generated ffmetadata file.
+func (s *Stream) WithChapters(chapters []Chapter) *Stream {
+	input, file := ffmetadataInput(&FFMetadata{Chapters: chapters})
+	return s.withOutputStreams([]*Stream{input}, KwArgs{"map_chapters": input}, file)
+}
+
+// WithStreamMetadata sets metadata tags such as language or title on the output streams matching the specifier,
+// e.g. "a:0" for the first audio stream.
+func (s *Stream) WithStreamMetadata(specifier string, tags map[string]string) *Stream {
+	return s.withOutputStreams(nil, KwArgs{"metadata:s:" + specifier: metadataArgs(tags)})
+}
+
+// metadataArgs returns the tags as "key=value" values of repeated “-metadata“ options.
+func metadataArgs(tags map[string]string) []string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var metadata []string
+	for _, k := range keys {
+		metadata = append(metadata, fmt.Sprintf("%s=%s", k, tags[k]))
+	}
+	return metadata
+}
+
+// coverArtIndex counts the video streams mapped before the cover art.  Only streams selected as video count, so
+// map the video of inputs with Video() when they also carry audio.
+func coverArtIndex(node *Node) int {
+	index := 0
+	for _, stream := range node.streamSpec {
+		if strings.HasPrefix(string(stream.Selector), "v") {
+			index++
+		}
+	}
+	return index
+}
+
+// WithCoverArt attaches an image (jpeg or png) as cover art of the output, the image is copied as a video stream
+// with the attached_pic disposition.
+func (s *Stream) WithCoverArt(imagePath string) *Stream {
+	switch strings.ToLower(filepath.Ext(imagePath)) {
+	case ".jpg", ".jpeg", ".png":
+	default:
+		panic(fmt.Sprintf("cover art must be a jpeg or png image: %s", imagePath))
+	}
+	if s.Type != "OutputStream" || s.Node.nodeType != "OutputNode" {
+		panic("output options can only be set on a stream returned by Output")
+	}
+	index := coverArtIndex(s.Node)
+	return s.withOutputStreams([]*Stream{Input(imagePath).Get("v")}, KwArgs{
+		fmt.Sprintf("c:v:%d", index):           "copy",
+		fmt.Sprintf("disposition:v:%d", index): "attached_pic",
+	})
+}
@@ -0,0 +1,97 @@
+package ffmpeg_go
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFFMetadataEncode(t *testing.T) {
+	m := &FFMetadata{
+		Global: map[string]string{"title": "Intro; part=1", "artist": "A#B"},
+		Chapters: []Chapter{
+			{Start: 90 * time.Second, End: 2 * time.Minute, Title: "Outro"},
+			{Start: 0, Title: "Start\\"},
+		},
+	}
+	content, err := m.Encode()
+	assert.Nil(t, err)
+	assert.Equal(t, ";FFMETADATA1\nartist=A\\#B\ntitle=Intro\\; part\\=1\n"+
+		"\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=0\nEND=90000\ntitle=Start\\\\\n"+
+		"\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=90000\nEND=120000\ntitle=Outro\n", content)
+
+	_, err = (&FFMetadata{Chapters: []Chapter{{Start: time.Second}}}).Encode()
+	assert.NotNil(t, err)
+}
+
+// argAfter returns the argument following flag.
+func argAfter(args []string, flag string) string {
+	for i := range args[:len(args)-1] {
+		if args[i] == flag {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+func TestOutputMetadata(t *testing.T) {
+	in := Input("in.mp4")
+	out := Output([]*Stream{in.Video(), in.Audio()}, "out.mp4", KwArgs{"c": "copy"}).
+		WithMetadata(map[string]string{"title": "Lesson 1"}).
+		WithChapters([]Chapter{{Title: "One"}, {Start: time.Minute, End: 2 * time.Minute, Title: "Two"}}).
+		WithStreamMetadata("a:0", map[string]string{"language": "chi", "title": "Mandarin"}).
+		WithCoverArt("cover.jpg")
+	args := out.GetArgs()
+
+	var inputs, maps []string
+	for i := 0; i < len(args)-1; i++ {
+		switch args[i] {
+		case "-i":
+			inputs = append(inputs, args[i+1])
+		case "-map":
+			maps = append(maps, args[i+1])
+		}
+	}
+	assert.Equal(t, 4, len(inputs))
+	index := func(file string) string {
+		for i, f := range inputs {
+			if f == file {
+				return strconv.Itoa(i)
+			}
+		}
+		return ""
+	}
+	metadataFile := inputs[mustAtoi(argAfter(args, "-map_metadata"))]
+	chaptersFile := inputs[mustAtoi(argAfter(args, "-map_chapters"))]
+	// the ffmetadata files are only written when the command runs
+	_, err := os.Stat(metadataFile)
+	assert.True(t, os.IsNotExist(err))
+	remove, err := out.WriteInputFiles()
+	assert.Nil(t, err)
+	content, _ := os.ReadFile(metadataFile)
+	assert.Equal(t, ";FFMETADATA1\ntitle=Lesson 1\n", string(content))
+	content, _ = os.ReadFile(chaptersFile)
+	assert.Contains(t, string(content), "START=0\nEND=60000\ntitle=One\n")
+	remove()
+	_, err = os.Stat(chaptersFile)
+	assert.True(t, os.IsNotExist(err))
+
+	// the ffmetadata inputs are not mapped as streams
+	assert.Equal(t, []string{index("in.mp4") + ":v", index("in.mp4") + ":a", index("cover.jpg") + ":v"}, maps)
+	assert.Equal(t, "attached_pic", argAfter(args, "-disposition:v:1"))
+	assert.Equal(t, "copy", argAfter(args, "-c:v:1"))
+	assert.Equal(t, "language=chi", argAfter(args, "-metadata:s:a:0"))
+	assert.Contains(t, args, "title=Mandarin")
+	assert.Equal(t, "out.mp4", args[len(args)-1])
+}
+
+func mustAtoi(s string) int {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		panic(err)
+	}
+	return i
+}
@@ -56,12 +56,14 @@ func (s *Stream) Get(index string) *Stream {
 	return s.Node.Stream(s.Label, Selector(index))
 }
 
-func (s *Stream) Audio() *Stream {
-	return s.Get("a")
+// Audio selects the audio streams, or the index-th one.
+func (s *Stream) Audio(index ...int) *Stream {
+	return s.typeStream(StreamTypeAudio, index)
 }
 
-func (s *Stream) Video() *Stream {
-	return s.Get("v")
+// Video selects the video streams, or the index-th one.
+func (s *Stream) Video(index ...int) *Stream {
+	return s.typeStream(StreamTypeVideo, index)
 }
 
 func getStreamMap(streamSpec []*Stream) map[int]*Stream {
@@ -217,6 +219,7 @@ func (n *Node) Hash() int {
 	for downStreamLabel, upStreamInfo := range n.IncomingEdgeMap() {
 		b += getHash(fmt.Sprintf("%s%d%s%s", downStreamLabel, upStreamInfo.Node.Hash(), upStreamInfo.Label, upStreamInfo.Selector))
 	}
+	b += getHash(n.name)
 	b += getHash(n.args)
 	b += getHash(n.kwargs)
 	return b
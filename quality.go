@@ -0,0 +1,298 @@
+package ffmpeg_go
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// QualityMetric an objective video quality metric.
+type QualityMetric string
+
+const (
+	MetricPSNR QualityMetric = "psnr"
+	MetricSSIM QualityMetric = "ssim"
+	// MetricVMAF requires ffmpeg built with libvmaf
+	MetricVMAF QualityMetric = "vmaf"
+)
+
+// QualityOptions how the distorted video is aligned with the reference before comparing.
+type QualityOptions struct {
+	// Width and Height the size both videos are scaled to, by default the distorted video is scaled to the reference
+	Width  int
+	Height int
+	// Fps resamples both videos to a common frame rate
+	Fps float64
+	// DistortedOffset the distorted video is ahead by this duration, e.g. by a trimmed intro; negative if it lags
+	DistortedOffset time.Duration
+	// PixFmt the pixel format compared, defaults to yuv420p
+	PixFmt string
+	// VMAFModel e.g. "version=vmaf_4k_v0.6.1", the libvmaf default model otherwise
+	VMAFModel  string
+	FfmpegPath string
+	Context    context.Context
+}
+
+// FrameScore the score of a frame, Components holds the individual values (e.g. psnr_y, or the vmaf features).
+type FrameScore struct {
+	Frame      int                `json:"frame"`
+	Score      float64            `json:"score"`
+	Components map[string]float64 `json:"components,omitempty"`
+}
+
+// MetricResult per-frame and aggregate scores of a metric.  Identical frames have an infinite PSNR.
+type MetricResult struct {
+	Metric       QualityMetric `json:"metric"`
+	Mean         float64       `json:"mean"`
+	Min          float64       `json:"min"`
+	Max          float64       `json:"max"`
+	HarmonicMean float64       `json:"harmonicMean"`
+	Frames       []FrameScore  `json:"frames"`
+}
+
+// QualityReport the result of a comparison, Skipped lists the metrics the ffmpeg binary doesn't support.
+type QualityReport struct {
+	Reference string                          `json:"reference"`
+	Distorted string                          `json:"distorted"`
+	Metrics   map[QualityMetric]*MetricResult `json:"metrics"`
+	Skipped   []QualityMetric                 `json:"skipped,omitempty"`
+}
+
+// qualityFilterName returns the filter computing the metric.
+func qualityFilterName(metric QualityMetric) string {
+	if metric == MetricVMAF {
+		return "libvmaf"
+	}
+	return string(metric)
+}
+
+// qualityAlign normalizes timestamps, frame rate and pixel format of a compared video.
+func qualityAlign(s *Stream, opts *QualityOptions) *Stream {
+	s = s.Filter("setpts", Args{"PTS-STARTPTS"})
+	if opts.Fps > 0 {
+		s = s.Filter("fps", Args{strconv.FormatFloat(opts.Fps, 'f', -1, 64)})
+	}
+	if opts.Width > 0 && opts.Height > 0 {
+		s = s.Filter("scale", Args{strconv.Itoa(opts.Width), strconv.Itoa(opts.Height)}, KwArgs{"flags": "bicubic"})
+	}
+	pixFmt := opts.PixFmt
+	if pixFmt == "" {
+		pixFmt = "yuv420p"
+	}
+	return s.Filter("format", Args{pixFmt})
+}
+
+// qualityStream builds the comparison graph, the metric logs are written to logs.
+func qualityStream(reference, distorted string, metrics []QualityMetric, logs map[QualityMetric]string, opts *QualityOptions) *Stream {
+	refArgs, distArgs := KwArgs{}, KwArgs{}
+	if opts.DistortedOffset > 0 {
+		refArgs["ss"] = strconv.FormatFloat(opts.DistortedOffset.Seconds(), 'f', -1, 64)
+	} else if opts.DistortedOffset < 0 {
+		distArgs["ss"] = strconv.FormatFloat(-opts.DistortedOffset.Seconds(), 'f', -1, 64)
+	}
+	ref := qualityAlign(Input(reference, refArgs).Video(), opts)
+	dist := qualityAlign(Input(distorted, distArgs).Video(), opts)
+	if opts.Width <= 0 || opts.Height <= 0 {
+		scaled := FilterMultiOutput([]*Stream{dist, ref}, "scale2ref", nil, KwArgs{"flags": "bicubic"})
+		dist, ref = scaled.Get("0"), scaled.Get("1")
+	}
+	var refs, dists []*Stream
+	if len(metrics) == 1 {
+		refs, dists = []*Stream{ref}, []*Stream{dist}
+	} else {
+		refSplit, distSplit := ref.Split(), dist.Split()
+		for i := range metrics {
+			refs = append(refs, refSplit.Get(strconv.Itoa(i)))
+			dists = append(dists, distSplit.Get(strconv.Itoa(i)))
+		}
+	}
+	var outputs []*Stream
+	for i, metric := range metrics {
+		// log paths are option values, which are not escaped when the graph is compiled
+		logPath := escapeChars(filepath.ToSlash(logs[metric]), "\\':")
+		args := KwArgs{"stats_file": logPath}
+		if metric == MetricVMAF {
+			args = KwArgs{"log_path": logPath, "log_fmt": "json"}
+			if opts.VMAFModel != "" {
+				args["model"] = escapeChars(opts.VMAFModel, "\\':")
+			}
+		}
+		outputs = append(outputs, Filter([]*Stream{dists[i], refs[i]}, qualityFilterName(metric), nil, args))
+	}
+	out := Output(outputs, "-", KwArgs{"f": "null"})
+	if opts.Context != nil {
+		out.Context = opts.Context
+	}
+	if opts.FfmpegPath != "" {
+		out.FfmpegPath = opts.FfmpegPath
+	}
+	return out
+}
+
+// CompareQuality scores the distorted video against the reference with the metrics, by default PSNR and SSIM.
+//
+// The videos are aligned to start together (see QualityOptions.DistortedOffset), resampled and scaled to a common
+// size.  VMAF is skipped and listed in the report when ffmpeg is built without libvmaf.
+func CompareQuality(reference, distorted string, metrics []QualityMetric, options ...QualityOptions) (*QualityReport, error) {
+	opts := &QualityOptions{}
+	if len(options) > 0 {
+		opts = &options[0]
+	}
+	if len(metrics) == 0 {
+		metrics = []QualityMetric{MetricPSNR, MetricSSIM}
+	}
+	report := &QualityReport{Reference: reference, Distorted: distorted, Metrics: map[QualityMetric]*MetricResult{}}
+	var run []QualityMetric
+	for _, metric := range metrics {
+		switch metric {
+		case MetricPSNR, MetricSSIM:
+			run = append(run, metric)
+		case MetricVMAF:
+			if HasFilter(opts.FfmpegPath, "libvmaf") {
+				run = append(run, metric)
+			} else {
+				report.Skipped = append(report.Skipped, metric)
+			}
+		default:
+			return nil, fmt.Errorf("unknown quality metric %q", metric)
+		}
+	}
+	if len(run) == 0 {
+		return report, nil
+	}
+
+	dir, err := os.MkdirTemp("", "ffmpeg-go-quality-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	logs := map[QualityMetric]string{}
+	for _, metric := range run {
+		logs[metric] = filepath.Join(dir, string(metric)+".log")
+	}
+	stderr := bytes.NewBuffer(nil)
+	if err := qualityStream(reference, distorted, run, logs, opts).WithErrorOutput(stderr).Run(); err != nil {
+		return nil, fmt.Errorf("compare quality: %w: %s", err, stderr.String())
+	}
+
+	for _, metric := range run {
+		f, err := os.Open(logs[metric])
+		if err != nil {
+			return nil, err
+		}
+		var result *MetricResult
+		switch metric {
+		case MetricPSNR:
+			result, err = ParsePSNRStats(f)
+		case MetricSSIM:
+			result, err = ParseSSIMStats(f)
+		case MetricVMAF:
+			result, err = ParseVMAFLog(f)
+		}
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("parse %s log: %w", metric, err)
+		}
+		report.Metrics[metric] = result
+	}
+	return report, nil
+}
+
+// parseStatsLine parses the "key:value" fields of a psnr or ssim stats line.
+func parseStatsLine(line string) map[string]float64 {
+	values := map[string]float64{}
+	for _, field := range strings.Fields(line) {
+		kv := strings.SplitN(field, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseFloat(kv[1], 64); err == nil {
+			values[kv[0]] = v
+		}
+	}
+	return values
+}
+
+// parseStats reads a stats file, the score of a frame is the value of scoreKey.
+func parseStats(r io.Reader, metric QualityMetric, scoreKey string) (*MetricResult, error) {
+	result := &MetricResult{Metric: metric}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		values := parseStatsLine(scanner.Text())
+		score, ok := values[scoreKey]
+		if !ok {
+			continue
+		}
+		frame := int(values["n"])
+		delete(values, "n")
+		result.Frames = append(result.Frames, FrameScore{Frame: frame, Score: score, Components: values})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(result.Frames) == 0 {
+		return nil, fmt.Errorf("no %s scores found", metric)
+	}
+	result.aggregate()
+	return result, nil
+}
+
+// ParsePSNRStats parses the stats_file of the psnr filter, the frame score is psnr_avg.
+func ParsePSNRStats(r io.Reader) (*MetricResult, error) {
+	return parseStats(r, MetricPSNR, "psnr_avg")
+}
+
+// ParseSSIMStats parses the stats_file of the ssim filter, the frame score is the "All" value.
+func ParseSSIMStats(r io.Reader) (*MetricResult, error) {
+	return parseStats(r, MetricSSIM, "All")
+}
+
+// ParseVMAFLog parses the json log of the libvmaf filter, the frame score is vmaf.
+func ParseVMAFLog(r io.Reader) (*MetricResult, error) {
+	var log struct {
+		Frames []struct {
+			FrameNum int                `json:"frameNum"`
+			Metrics  map[string]float64 `json:"metrics"`
+		} `json:"frames"`
+	}
+	if err := json.NewDecoder(r).Decode(&log); err != nil {
+		return nil, err
+	}
+	result := &MetricResult{Metric: MetricVMAF}
+	for _, f := range log.Frames {
+		score, ok := f.Metrics["vmaf"]
+		if !ok {
+			continue
+		}
+		result.Frames = append(result.Frames, FrameScore{Frame: f.FrameNum, Score: score, Components: f.Metrics})
+	}
+	if len(result.Frames) == 0 {
+		return nil, fmt.Errorf("no vmaf scores found")
+	}
+	result.aggregate()
+	return result, nil
+}
+
+func (m *MetricResult) aggregate() {
+	m.Min, m.Max = math.Inf(1), math.Inf(-1)
+	sum, inverseSum := 0.0, 0.0
+	for _, f := range m.Frames {
+		m.Min = math.Min(m.Min, f.Score)
+		m.Max = math.Max(m.Max, f.Score)
+		sum += f.Score
+		// shifted by one like libvmaf, so that zero scores are defined
+		inverseSum += 1 / (f.Score + 1)
+	}
+	n := float64(len(m.Frames))
+	m.Mean = sum / n
+	m.HarmonicMean = n/inverseSum - 1
+}
@@ -0,0 +1,69 @@
+package ffmpeg_go
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQualityStream(t *testing.T) {
+	logs := map[QualityMetric]string{MetricPSNR: "/tmp/q/psnr.log", MetricSSIM: "C:/q/ssim.log"}
+	args := qualityStream("ref.mp4", "dist.mp4", []QualityMetric{MetricPSNR}, logs,
+		&QualityOptions{Width: 1280, Height: 720, Fps: 25, DistortedOffset: -2 * time.Second}).GetArgs()
+	assert.Equal(t, []string{
+		"-ss", "2", "-i", "dist.mp4", "-i", "ref.mp4",
+		"-filter_complex", "[0:v]setpts=PTS-STARTPTS[s0];[s0]fps=25[s1];[s1]scale=1280:720:flags=bicubic[s2];" +
+			"[s2]format=yuv420p[s3];[1:v]setpts=PTS-STARTPTS[s4];[s4]fps=25[s5];[s5]scale=1280:720:flags=bicubic[s6];" +
+			"[s6]format=yuv420p[s7];[s3][s7]psnr=stats_file=/tmp/q/psnr.log[s8]",
+		"-map", "[s8]", "-f", "null", "-",
+	}, args)
+
+	args = qualityStream("ref.mp4", "dist.mp4", []QualityMetric{MetricPSNR, MetricSSIM}, logs, &QualityOptions{}).GetArgs()
+	graph := args[5]
+	assert.Contains(t, graph, "scale2ref=flags=bicubic")
+	assert.Contains(t, graph, `ssim=stats_file=C\\:/q/ssim.log`)
+	assert.Equal(t, 2, strings.Count(graph, "split=2"))
+}
+
+func TestParseQualityLogs(t *testing.T) {
+	psnr, err := ParsePSNRStats(strings.NewReader(
+		"n:1 mse_avg:0.50 mse_y:0.60 psnr_avg:40.00 psnr_y:39.00\n" +
+			"n:2 mse_avg:0.00 mse_y:0.00 psnr_avg:inf psnr_y:inf\n" +
+			"n:3 mse_avg:1.00 mse_y:1.20 psnr_avg:30.00 psnr_y:29.00\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(psnr.Frames))
+	assert.Equal(t, 39.0, psnr.Frames[0].Components["psnr_y"])
+	assert.Equal(t, 30.0, psnr.Min)
+	assert.True(t, math.IsInf(psnr.Max, 1))
+
+	ssim, err := ParseSSIMStats(strings.NewReader(
+		"n:1 Y:0.990000 U:0.980000 V:0.970000 All:0.980000 (16.989700)\n" +
+			"n:2 Y:0.970000 U:0.960000 V:0.950000 All:0.960000 (13.979400)\n"))
+	assert.Nil(t, err)
+	assert.InDelta(t, 0.97, ssim.Mean, 1e-9)
+	assert.Equal(t, 2, ssim.Frames[1].Frame)
+
+	vmaf, err := ParseVMAFLog(strings.NewReader(`{"frames": [
+		{"frameNum": 0, "metrics": {"integer_adm2": 0.98, "vmaf": 90.0}},
+		{"frameNum": 1, "metrics": {"integer_adm2": 0.97, "vmaf": 80.0}}
+	], "pooled_metrics": {"vmaf": {"mean": 85.0}}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, 85.0, vmaf.Mean)
+	assert.InDelta(t, 2/(1/91.0+1/81.0)-1, vmaf.HarmonicMean, 1e-9)
+
+	_, err = ParseSSIMStats(strings.NewReader(""))
+	assert.NotNil(t, err)
+}
+
+func TestParseFilterList(t *testing.T) {
+	filters := parseFilterList([]byte("Filters:\n  T.. = Timeline support\n  ... = Slice threading\n" +
+		"  A = Audio input/output\n  --- \n" +
+		" TS. psnr              VV->V      Calculate the PSNR between two video streams.\n" +
+		" ... libvmaf           VV->V      Calculate the VMAF between two video streams.\n"))
+	assert.True(t, filters["psnr"])
+	assert.True(t, filters["libvmaf"])
+	assert.False(t, filters["A"])
+}
@@ -121,13 +121,24 @@ func _getOutputArgs(node *Node, streamNameMap map[string]string) []string {
 	if len(node.GetInComingEdges()) == 0 {
 		panic("Output node has no mapped streams")
 	}
+	kwargs := node.kwargs.Copy()
+	// metadata and chapter sources are referenced by their input index instead of being mapped
+	sources := map[int]bool{}
+	for _, k := range []string{"map_metadata", "map_chapters"} {
+		if source, ok := kwargs[k].(*Stream); ok {
+			sources[source.Node.Hash()] = true
+			kwargs[k] = streamNameMap[fmt.Sprintf("%d", source.Node.Hash())]
+		}
+	}
 	for _, e := range node.GetInComingEdges() {
+		if sources[e.UpStreamNode.Hash()] {
+			continue
+		}
 		streamName := formatInputStreamName(streamNameMap, e, true)
 		if streamName != "0" || len(node.GetInComingEdges()) > 1 {
 			args = append(args, "-map", streamName)
 		}
 	}
-	kwargs := node.kwargs.Copy()
 
 	filename := kwargs.PopString("filename")
 	if kwargs.HasKey("format") {
@@ -275,6 +286,11 @@ func (s *Stream) Compile(options ...CompilationOption) *exec.Cmd {
 }
 
 func (s *Stream) Run(options ...CompilationOption) error {
+	remove, err := s.WriteInputFiles()
+	if err != nil {
+		return err
+	}
+	defer remove()
 	if s.Context.Value("run_hook") != nil {
 		hook := s.Context.Value("run_hook").(*RunHook)
 		go hook.f()
@@ -122,6 +122,11 @@ func (s *Stream) RunLinux() error {
 		}
 	}
 
+	remove, err := s.WriteInputFiles()
+	if err != nil {
+		return err
+	}
+	defer remove()
 	cmd := s.Compile()
 	err = cmd.Start()
 	if err != nil {
@@ -193,11 +193,21 @@ type CallbackRequest struct {
 
 // MaterialPreprocessorService 素材预处理器服务
 type MaterialPreprocessorService struct {
+	videoInfoCache *VideoInfoCache
+}
+
+// tsTarget 转换为 TS 的目标格式，h264 + aac 可直接复制
+var tsTarget = &TranscodeTarget{
+	Format:     "mpegts",
+	VideoCodec: "h264",
+	AudioCodec: "aac",
 }
 
 // NewMaterialPreprocessorService 创建素材预处理器服务实例
 func NewMaterialPreprocessorService() MaterialPreprocessor {
-	return &MaterialPreprocessorService{}
+	return &MaterialPreprocessorService{
+		videoInfoCache: NewVideoInfoCache(),
+	}
 }
 
 // Process 处理素材预处理任务
@@ -339,29 +349,33 @@ func (s *MaterialPreprocessorService) Process(task *queue.Task) error {
 	return nil
 }
 
-// convertToTS 使用FFmpeg将视频文件转换为TS格式
+// convertToTS 使用FFmpeg将视频文件转换为TS格式，只保留第一条视频流和音频流，丢弃的流记录在日志的决策中
 func (s *MaterialPreprocessorService) convertToTS(inputFile, outputFile string, taskLogger *TaskLogger) error {
 	// 记录FFmpeg命令构建时间
 	buildCmdStart := time.Now()
 
-	ffmpeg := ffmpeg_go.Input(inputFile).
-		Output(outputFile, ffmpeg_go.KwArgs{
-			"c":     "copy",             // 直接复制编解码器
-			"bsf:v": "h264_mp4toannexb", // 视频比特流过滤器
-			"f":     "mpegts",           // 输出格式为MPEG-TS
-		}).
-		OverWriteOutput()
+	// 根据源文件的编码决定每条流是直接复制还是转码
+	info, err := s.videoInfoCache.AnalyzeVideo(inputFile)
+	if err != nil {
+		return err
+	}
+	decision := DecideTranscode(info, tsTarget)
+	ffmpeg, err := decision.BuildStream(inputFile, outputFile, info, tsTarget)
+	if err != nil {
+		return err
+	}
 
 	buildCmdDuration := time.Since(buildCmdStart).Seconds()
 	if taskLogger != nil {
 		taskLogger.Log("INFO", "FFmpeg命令构建完成", map[string]interface{}{
 			"duration": buildCmdDuration,
+			"decision": decision.String(),
 		})
 	}
 
 	// 记录FFmpeg执行时间
 	execStart := time.Now()
-	err := ffmpeg.Run()
+	err = ffmpeg.Run()
 	execDuration := time.Since(execStart).Seconds()
 
 	if taskLogger != nil {
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	ffmpeg_go "github.com/u2takey/ffmpeg-go"
+	"github.com/u2takey/ffmpeg-go/subtitles"
+)
+
+// SubtitleOptions 字幕生成参数
+type SubtitleOptions struct {
+	MaxLineWidth int              `json:"maxLineWidth,omitempty"` // 每条字幕最多的列数，中日韩字符占两列，默认 42
+	Style        *subtitles.Style `json:"style,omitempty"`        // 字幕样式，写入 ASS 文件
+}
+
+// SubtitleService 根据旁白文本生成字幕：按标点和行宽把文本拆分为字幕条，
+// 再按字数分配到片段或语音的时长内，或者按 TTS 返回的逐词时间对齐
+type SubtitleService struct {
+	options SubtitleOptions
+}
+
+// NewSubtitleService 创建字幕服务
+func NewSubtitleService(options SubtitleOptions) *SubtitleService {
+	return &SubtitleService{options: options}
+}
+
+// track 由字幕条创建字幕文档
+func (s *SubtitleService) track(cues []*subtitles.Cue) *subtitles.Track {
+	track := &subtitles.Track{Cues: cues}
+	if s.options.Style != nil {
+		track.Styles = []*subtitles.Style{s.options.Style}
+	}
+	return track
+}
+
+// FromText 把旁白文本拆分为字幕条，按字数分配到第 start 秒开始、时长 duration 秒的时间内
+func (s *SubtitleService) FromText(text string, start, duration float64) *subtitles.Track {
+	texts := subtitles.SplitText(text, s.options.MaxLineWidth)
+	begin := time.Duration(math.Round(start * float64(time.Second)))
+	end := time.Duration(math.Round((start + duration) * float64(time.Second)))
+	return s.track(subtitles.LayoutCues(texts, begin, end))
+}
+
+// FromTimings 按 TTS 返回的逐词时间生成字幕，逐词时间与文本对不上时按字数分配
+func (s *SubtitleService) FromTimings(text string, timings []subtitles.WordTiming) *subtitles.Track {
+	texts := subtitles.SplitText(text, s.options.MaxLineWidth)
+	return s.track(subtitles.TimeCues(texts, timings))
+}
+
+// FromAudio 按语音文件的实际时长生成字幕
+func (s *SubtitleService) FromAudio(text, audioPath string) (*subtitles.Track, error) {
+	duration, err := probeDuration(audioPath)
+	if err != nil {
+		return nil, err
+	}
+	return s.FromText(text, 0, duration), nil
+}
+
+// Narrate 用 TTS 合成旁白并生成与语音对齐的字幕，返回音频文件路径和字幕
+func (s *SubtitleService) Narrate(tts TTSService, text string, options TTSOptions) (string, *subtitles.Track, error) {
+	audioPath, err := tts.GenerateAudio(text, options)
+	if err != nil {
+		return "", nil, fmt.Errorf("语音合成失败: %w", err)
+	}
+	track, err := s.FromAudio(text, audioPath)
+	if err != nil {
+		return "", nil, err
+	}
+	return audioPath, track, nil
+}
+
+// WriteFile 按扩展名写入 SRT、ASS 或 WebVTT 字幕文件
+func (s *SubtitleService) WriteFile(track *subtitles.Track, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建字幕目录失败: %w", err)
+	}
+	if err := subtitles.WriteFile(path, track); err != nil {
+		return fmt.Errorf("写入字幕文件失败: %w", err)
+	}
+	return nil
+}
+
+// probeDuration 使用ffprobe获取媒体文件的时长（秒）
+func probeDuration(path string) (float64, error) {
+	probeData, err := ffmpeg_go.Probe(path)
+	if err != nil {
+		return 0, fmt.Errorf("无法探测文件时长: %v", err)
+	}
+	var probeResult struct {
+		Format struct {
+			Duration string `json:"duration"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal([]byte(probeData), &probeResult); err != nil {
+		return 0, fmt.Errorf("解析文件属性失败: %v", err)
+	}
+	duration, err := strconv.ParseFloat(probeResult.Format.Duration, 64)
+	if err != nil {
+		return 0, fmt.Errorf("无法获取文件时长: %s", path)
+	}
+	return duration, nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	ffmpeg_go "github.com/u2takey/ffmpeg-go"
+)
+
+// TemplateStore 编辑模板存储，设置了数据目录时每个模板保存为 {name}.json，启动时重新加载
+type TemplateStore struct {
+	templates map[string]*ffmpeg_go.EditTemplate
+	dataDir   string
+	mutex     sync.RWMutex
+}
+
+// NewTemplateStore 创建模板存储，dataDir 为空时只保存在内存中
+func NewTemplateStore(dataDir string) (*TemplateStore, error) {
+	s := &TemplateStore{
+		templates: make(map[string]*ffmpeg_go.EditTemplate),
+		dataDir:   dataDir,
+	}
+	if dataDir == "" {
+		return s, nil
+	}
+
+	// 确保数据目录存在
+	if err := os.MkdirAll(dataDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建模板目录失败: %w", err)
+	}
+	files, err := filepath.Glob(filepath.Join(dataDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("读取模板失败: %w", err)
+		}
+		template, err := ffmpeg_go.ParseEditTemplate(data)
+		if err != nil {
+			// 无效的模板不影响其他模板加载
+			fmt.Printf("Warning: 加载模板 %s 失败: %v\n", file, err)
+			continue
+		}
+		s.templates[template.Name] = template
+	}
+	return s, nil
+}
+
+// Register 检查并注册模板，同名模板会被替换
+func (s *TemplateStore) Register(template *ffmpeg_go.EditTemplate) error {
+	if errs := template.Validate(); len(errs) > 0 {
+		return errs
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.dataDir != "" {
+		data, err := json.MarshalIndent(template, "", "  ")
+		if err != nil {
+			return fmt.Errorf("序列化模板失败: %w", err)
+		}
+		// 先写临时文件再重命名，避免写到一半的模板
+		file := filepath.Join(s.dataDir, template.Name+".json")
+		if err := os.WriteFile(file+".tmp", data, 0644); err != nil {
+			return fmt.Errorf("保存模板失败: %w", err)
+		}
+		if err := os.Rename(file+".tmp", file); err != nil {
+			return fmt.Errorf("保存模板失败: %w", err)
+		}
+	}
+	s.templates[template.Name] = template
+	return nil
+}
+
+// Get 获取模板
+func (s *TemplateStore) Get(name string) (*ffmpeg_go.EditTemplate, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	template, exists := s.templates[name]
+	return template, exists
+}
+
+// List 按名称列出所有模板
+func (s *TemplateStore) List() []*ffmpeg_go.EditTemplate {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	templates := make([]*ffmpeg_go.EditTemplate, 0, len(s.templates))
+	for _, template := range s.templates {
+		templates = append(templates, template)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+	return templates
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	ffmpeg_go "github.com/u2takey/ffmpeg-go"
+)
+
+// StreamAction 单条流的处理方式
+type StreamAction string
+
+const (
+	// ActionCopy 直接复制，容器也不变
+	ActionCopy StreamAction = "copy"
+	// ActionRemux 不重新编码，只更换容器（必要时加比特流过滤器）
+	ActionRemux StreamAction = "remux"
+	// ActionTranscode 重新编码
+	ActionTranscode StreamAction = "transcode"
+)
+
+// TranscodeTarget 目标格式，零值字段表示不限制
+type TranscodeTarget struct {
+	Format     string  `json:"format,omitempty"`     // 输出容器，如 mpegts、mp4
+	VideoCodec string  `json:"videoCodec,omitempty"` // ffprobe 的编码名，如 h264、hevc
+	Width      int     `json:"width,omitempty"`
+	Height     int     `json:"height,omitempty"`
+	FPS        float64 `json:"fps,omitempty"`
+	PixFmt     string  `json:"pixFmt,omitempty"`
+	AudioCodec string  `json:"audioCodec,omitempty"`
+	SampleRate int     `json:"sampleRate,omitempty"`
+	Channels   int     `json:"channels,omitempty"`
+	Encoding   string  `json:"encoding,omitempty"` // 转码时使用的编码预设，为空时按编码名选择编码器
+}
+
+// DefaultPreprocessTarget 预处理素材的默认目标：h264/yuv420p + aac，便于后续拼接
+var DefaultPreprocessTarget = &TranscodeTarget{
+	VideoCodec: "h264",
+	PixFmt:     "yuv420p",
+	AudioCodec: "aac",
+}
+
+// StreamDecision 单条流的决策及原因
+type StreamDecision struct {
+	Action  StreamAction `json:"action"`
+	Reasons []string     `json:"reasons,omitempty"`
+}
+
+// TranscodeDecision 一个文件的转码决策
+type TranscodeDecision struct {
+	Video *StreamDecision `json:"video,omitempty"`
+	Audio *StreamDecision `json:"audio,omitempty"`
+	// Dropped 不会写入输出的流：BuildStream 只处理第一条视频流和音频流，额外的音轨、字幕和数据流被丢弃
+	Dropped []string `json:"dropped,omitempty"`
+	// 以下字段记录视频转码时需要调整的属性
+	scale  bool
+	fps    bool
+	pixFmt bool
+}
+
+// NeedsProcessing 所有流都可直接复制时，源文件可以原样使用
+func (d *TranscodeDecision) NeedsProcessing() bool {
+	for _, s := range []*StreamDecision{d.Video, d.Audio} {
+		if s != nil && s.Action != ActionCopy {
+			return true
+		}
+	}
+	return false
+}
+
+// String 返回便于记录日志的决策描述
+func (d *TranscodeDecision) String() string {
+	var parts []string
+	for _, stream := range []struct {
+		name     string
+		decision *StreamDecision
+	}{{"video", d.Video}, {"audio", d.Audio}} {
+		if stream.decision == nil {
+			continue
+		}
+		part := fmt.Sprintf("%s=%s", stream.name, stream.decision.Action)
+		if len(stream.decision.Reasons) > 0 {
+			part += "(" + strings.Join(stream.decision.Reasons, "; ") + ")"
+		}
+		parts = append(parts, part)
+	}
+	if len(d.Dropped) > 0 {
+		parts = append(parts, "dropped("+strings.Join(d.Dropped, "; ")+")")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatMatches 判断 ffprobe 的 format_name（如 "mov,mp4,m4a,3gp,3g2,mj2"）是否包含目标容器
+func formatMatches(formatName, target string) bool {
+	for _, name := range strings.Split(formatName, ",") {
+		if name == target {
+			return true
+		}
+	}
+	return false
+}
+
+// DecideTranscode 比较探测到的视频信息与目标格式，为每条流选择复制、换容器或转码
+func DecideTranscode(info *VideoInfo, target *TranscodeTarget) *TranscodeDecision {
+	d := &TranscodeDecision{Dropped: info.ExtraStreams}
+	remux := target.Format != "" && !formatMatches(info.FormatName, target.Format)
+	copyAction := ActionCopy
+	var remuxReason string
+	if remux {
+		copyAction = ActionRemux
+		remuxReason = fmt.Sprintf("容器 %s 需要转换为 %s", info.FormatName, target.Format)
+	}
+
+	if info.Codec != "" {
+		var reasons []string
+		if target.VideoCodec != "" && info.Codec != target.VideoCodec {
+			reasons = append(reasons, fmt.Sprintf("视频编码 %s 与目标 %s 不一致", info.Codec, target.VideoCodec))
+		}
+		if (target.Width > 0 && info.Width != target.Width) || (target.Height > 0 && info.Height != target.Height) {
+			d.scale = true
+			reasons = append(reasons, fmt.Sprintf("分辨率 %dx%d 与目标 %dx%d 不一致", info.Width, info.Height, target.Width, target.Height))
+		}
+		if target.FPS > 0 && math.Abs(info.FPS-target.FPS) > 0.01 {
+			d.fps = true
+			reasons = append(reasons, fmt.Sprintf("帧率 %.3f 与目标 %.3f 不一致", info.FPS, target.FPS))
+		}
+		if target.PixFmt != "" && info.PixFmt != target.PixFmt {
+			d.pixFmt = true
+			reasons = append(reasons, fmt.Sprintf("像素格式 %s 与目标 %s 不一致", info.PixFmt, target.PixFmt))
+		}
+		if len(reasons) > 0 {
+			d.Video = &StreamDecision{Action: ActionTranscode, Reasons: reasons}
+		} else if remux {
+			d.Video = &StreamDecision{Action: copyAction, Reasons: []string{remuxReason}}
+		} else {
+			d.Video = &StreamDecision{Action: ActionCopy, Reasons: []string{"视频流已符合目标"}}
+		}
+	}
+
+	if info.AudioCodec != "" {
+		var reasons []string
+		if target.AudioCodec != "" && info.AudioCodec != target.AudioCodec {
+			reasons = append(reasons, fmt.Sprintf("音频编码 %s 与目标 %s 不一致", info.AudioCodec, target.AudioCodec))
+		}
+		if target.SampleRate > 0 && info.SampleRate != target.SampleRate {
+			reasons = append(reasons, fmt.Sprintf("采样率 %d 与目标 %d 不一致", info.SampleRate, target.SampleRate))
+		}
+		if target.Channels > 0 && info.Channels != target.Channels {
+			reasons = append(reasons, fmt.Sprintf("声道数 %d 与目标 %d 不一致", info.Channels, target.Channels))
+		}
+		if len(reasons) > 0 {
+			d.Audio = &StreamDecision{Action: ActionTranscode, Reasons: reasons}
+		} else if remux {
+			d.Audio = &StreamDecision{Action: copyAction, Reasons: []string{remuxReason}}
+		} else {
+			d.Audio = &StreamDecision{Action: ActionCopy, Reasons: []string{"音频流已符合目标"}}
+		}
+	}
+	return d
+}
+
+// videoEncoders ffprobe 编码名对应的默认编码器
+var videoEncoders = map[string]string{
+	"h264": "libx264",
+	"hevc": "libx265",
+	"vp9":  "libvpx-vp9",
+	"av1":  "libsvtav1",
+}
+
+// audioEncoders ffprobe 编码名对应的默认编码器，未列出的编码名与编码器同名
+var audioEncoders = map[string]string{
+	"opus": "libopus",
+	"mp3":  "libmp3lame",
+}
+
+// BuildStream 按决策构建最小的 FFmpeg 命令：复制的流不经过滤镜，转码的流只加入需要的滤镜。
+// 只映射第一条视频流和音频流，其余的流记录在 Dropped 中，不会写入输出
+func (d *TranscodeDecision) BuildStream(inputFile, outputFile string, info *VideoInfo, target *TranscodeTarget) (*ffmpeg_go.Stream, error) {
+	input := ffmpeg_go.Input(inputFile)
+	var streams []*ffmpeg_go.Stream
+	args := ffmpeg_go.KwArgs{}
+	if target.Format != "" {
+		args["f"] = target.Format
+	}
+
+	var encoding *ffmpeg_go.EncodingPreset
+	if target.Encoding != "" {
+		preset, ok := ffmpeg_go.GetEncodingPreset(target.Encoding)
+		if !ok {
+			return nil, fmt.Errorf("未知的编码预设: %s", target.Encoding)
+		}
+		encoding = preset
+	}
+
+	if d.Video != nil {
+		video := input.Video(0)
+		switch d.Video.Action {
+		case ActionCopy, ActionRemux:
+			args["c:v"] = "copy"
+			// mp4 中的 h264/hevc 写入 mpegts 需要转换为 Annex B
+			if d.Video.Action == ActionRemux && target.Format == "mpegts" && (info.Codec == "h264" || info.Codec == "hevc") {
+				args["bsf:v"] = info.Codec + "_mp4toannexb"
+			}
+		case ActionTranscode:
+			if d.scale {
+				video = video.Filter("scale", ffmpeg_go.Args{strconv.Itoa(target.Width), strconv.Itoa(target.Height)})
+			}
+			if d.fps {
+				video = video.Filter("fps", ffmpeg_go.Args{strconv.FormatFloat(target.FPS, 'f', -1, 64)})
+			}
+			if encoding != nil {
+				video = encoding.ScaleVideo(video)
+			}
+			if d.pixFmt {
+				video = video.Filter("format", ffmpeg_go.Args{target.PixFmt})
+			}
+			if encoding == nil || encoding.Video == nil {
+				codec := target.VideoCodec
+				if codec == "" {
+					codec = info.Codec
+				}
+				encoder, ok := videoEncoders[codec]
+				if !ok {
+					return nil, fmt.Errorf("不支持的视频编码: %s", codec)
+				}
+				args["c:v"] = encoder
+			}
+		}
+		streams = append(streams, video)
+	}
+
+	if d.Audio != nil {
+		audio := input.Audio(0)
+		switch d.Audio.Action {
+		case ActionCopy, ActionRemux:
+			args["c:a"] = "copy"
+			// mpegts 中的 ADTS 格式 aac 写入 mp4 需要转换
+			if d.Audio.Action == ActionRemux && info.AudioCodec == "aac" && formatMatches(info.FormatName, "mpegts") {
+				args["bsf:a"] = "aac_adtstoasc"
+			}
+		case ActionTranscode:
+			if encoding == nil || encoding.Audio == nil {
+				codec := target.AudioCodec
+				if codec == "" {
+					codec = info.AudioCodec
+				}
+				if encoder, ok := audioEncoders[codec]; ok {
+					codec = encoder
+				}
+				args["c:a"] = codec
+			}
+			if target.SampleRate > 0 {
+				args["ar"] = target.SampleRate
+			}
+			if target.Channels > 0 {
+				args["ac"] = target.Channels
+			}
+		}
+		streams = append(streams, audio)
+	}
+	if len(streams) == 0 {
+		return nil, fmt.Errorf("没有可处理的音视频流: %s", inputFile)
+	}
+
+	out := ffmpeg_go.Output(streams, outputFile, args)
+	if encoding != nil && encoding.Video != nil && d.Video != nil && d.Video.Action == ActionTranscode {
+		out = out.WithEncoder(encoding.Video)
+	}
+	if encoding != nil && encoding.Audio != nil && d.Audio != nil && d.Audio.Action == ActionTranscode {
+		out = out.WithEncoder(encoding.Audio)
+	}
+	return out.OverWriteOutput(), nil
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mp4Info h264/aac 的 mp4 素材
+func mp4Info() *VideoInfo {
+	return &VideoInfo{
+		Codec: "h264", Width: 1920, Height: 1080, FPS: 30, PixFmt: "yuv420p", FormatName: "mov,mp4,m4a,3gp,3g2,mj2",
+		AudioCodec: "aac", SampleRate: 48000, Channels: 2,
+	}
+}
+
+func TestDecideTranscodeRemux(t *testing.T) {
+	// mp4 中的 h264/aac 写入 mpegts 只需要换容器，h264 转换为 Annex B
+	info := mp4Info()
+	d := DecideTranscode(info, tsTarget)
+	assert.Equal(t, ActionRemux, d.Video.Action)
+	assert.Equal(t, ActionRemux, d.Audio.Action)
+	assert.True(t, d.NeedsProcessing())
+	assert.Equal(t, "video=remux(容器 mov,mp4,m4a,3gp,3g2,mj2 需要转换为 mpegts), "+
+		"audio=remux(容器 mov,mp4,m4a,3gp,3g2,mj2 需要转换为 mpegts)", d.String())
+
+	stream, err := d.BuildStream("in.mp4", "out.ts", info, tsTarget)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"-i", "in.mp4", "-map", "0:v:0", "-map", "0:a:0",
+		"-bsf:v", "h264_mp4toannexb", "-c:a", "copy", "-c:v", "copy", "-f", "mpegts", "out.ts", "-y",
+	}, stream.GetArgs())
+}
+
+func TestDecideTranscodeCopy(t *testing.T) {
+	// 已符合目标的文件原样使用
+	d := DecideTranscode(mp4Info(), DefaultPreprocessTarget)
+	assert.Equal(t, ActionCopy, d.Video.Action)
+	assert.Equal(t, ActionCopy, d.Audio.Action)
+	assert.False(t, d.NeedsProcessing())
+	assert.Equal(t, "video=copy(视频流已符合目标), audio=copy(音频流已符合目标)", d.String())
+
+	stream, err := d.BuildStream("in.mp4", "out.mp4", mp4Info(), DefaultPreprocessTarget)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"-i", "in.mp4", "-map", "0:v:0", "-map", "0:a:0", "-c:a", "copy", "-c:v", "copy", "out.mp4", "-y",
+	}, stream.GetArgs())
+}
+
+func TestDecideTranscodeFilters(t *testing.T) {
+	// 只有不一致的属性会加入滤镜，音频仍然直接复制
+	info := mp4Info()
+	target := &TranscodeTarget{Width: 1280, Height: 720, VideoCodec: "h264", AudioCodec: "aac"}
+	d := DecideTranscode(info, target)
+	assert.Equal(t, ActionTranscode, d.Video.Action)
+	assert.Equal(t, []string{"分辨率 1920x1080 与目标 1280x720 不一致"}, d.Video.Reasons)
+	assert.Equal(t, ActionCopy, d.Audio.Action)
+	stream, err := d.BuildStream("in.mp4", "out.mp4", info, target)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"-i", "in.mp4", "-filter_complex", "[0:v:0]scale=1280:720[s0]", "-map", "[s0]", "-map", "0:a:0",
+		"-c:a", "copy", "-c:v", "libx264", "out.mp4", "-y",
+	}, stream.GetArgs())
+
+	target = &TranscodeTarget{FPS: 25, PixFmt: "yuv420p"}
+	info.PixFmt = "yuv444p"
+	d = DecideTranscode(info, target)
+	assert.Equal(t, []string{"帧率 30.000 与目标 25.000 不一致", "像素格式 yuv444p 与目标 yuv420p 不一致"}, d.Video.Reasons)
+	stream, err = d.BuildStream("in.mp4", "out.mp4", info, target)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"-i", "in.mp4", "-filter_complex", "[0:v:0]fps=25[s0];[s0]format=yuv420p[s1]", "-map", "[s1]", "-map", "0:a:0",
+		"-c:a", "copy", "-c:v", "libx264", "out.mp4", "-y",
+	}, stream.GetArgs())
+}
+
+func TestDecideTranscodeADTS(t *testing.T) {
+	// mpegts 中的 ADTS aac 写入 mp4 需要 aac_adtstoasc
+	info := mp4Info()
+	info.FormatName = "mpegts"
+	target := &TranscodeTarget{Format: "mp4"}
+	d := DecideTranscode(info, target)
+	assert.Equal(t, ActionRemux, d.Audio.Action)
+	stream, err := d.BuildStream("in.ts", "out.mp4", info, target)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"-i", "in.ts", "-map", "0:v:0", "-map", "0:a:0",
+		"-bsf:a", "aac_adtstoasc", "-c:a", "copy", "-c:v", "copy", "-f", "mp4", "out.mp4", "-y",
+	}, stream.GetArgs())
+}
+
+func TestDecideTranscodeDropped(t *testing.T) {
+	// 只处理第一条视频流和音频流，其余的流记录在决策中
+	info := mp4Info()
+	info.ExtraStreams = []string{"audio:ac3", "subtitle:mov_text"}
+	d := DecideTranscode(info, tsTarget)
+	assert.Equal(t, []string{"audio:ac3", "subtitle:mov_text"}, d.Dropped)
+	assert.Contains(t, d.String(), ", dropped(audio:ac3; subtitle:mov_text)")
+}
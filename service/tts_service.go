@@ -0,0 +1,282 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	ffmpeg_go "github.com/u2takey/ffmpeg-go"
+)
+
+// TTSOptions 语音合成参数
+type TTSOptions struct {
+	Voice string  `json:"voice,omitempty"` // 语音名称，由引擎解释：espeak-ng 的语音如 zh，piper 的模型文件
+	Speed float64 `json:"speed,omitempty"` // 语速倍数，为空时为 1
+}
+
+// speed 语速倍数
+func (o TTSOptions) speed() float64 {
+	if o.Speed > 0 {
+		return o.Speed
+	}
+	return 1
+}
+
+// TTSService 文字转语音服务
+type TTSService interface {
+	// 将文本转换为音频文件，返回文件路径
+	GenerateAudio(text string, options TTSOptions) (string, error)
+	// 获取服务名称
+	Name() string
+}
+
+// TTSEngine 语音合成引擎，把文本合成为 WAV 文件
+type TTSEngine interface {
+	Synthesize(text string, options TTSOptions, outPath string) error
+	Name() string
+}
+
+// TTSConfig TTS 配置
+type TTSConfig struct {
+	Provider string `json:"provider"`           // espeak-ng、piper、sine、silence
+	Binary   string `json:"binary,omitempty"`   // 命令行程序，为空时使用 espeak-ng 或 piper
+	Model    string `json:"model,omitempty"`    // piper 的默认模型文件
+	CacheDir string `json:"cacheDir,omitempty"` // 生成音频的缓存目录，默认 ./cache/tts
+}
+
+// NewTTSEngine 按配置创建语音合成引擎
+func NewTTSEngine(config TTSConfig) (TTSEngine, error) {
+	switch config.Provider {
+	case "", "espeak", "espeak-ng":
+		return &EspeakEngine{Binary: config.Binary}, nil
+	case "piper":
+		return &PiperEngine{Binary: config.Binary, Model: config.Model}, nil
+	case "sine":
+		return &ToneEngine{Frequency: 440}, nil
+	case "silence":
+		return &ToneEngine{}, nil
+	}
+	return nil, fmt.Errorf("不支持的 TTS 引擎: %s", config.Provider)
+}
+
+// NewTTSServiceFromConfig 按配置创建带缓存的 TTS 服务
+func NewTTSServiceFromConfig(config TTSConfig) (*LocalTTSService, error) {
+	engine, err := NewTTSEngine(config)
+	if err != nil {
+		return nil, err
+	}
+	cacheDir := config.CacheDir
+	if cacheDir == "" {
+		cacheDir = "./cache/tts"
+	}
+	return NewTTSService(engine, cacheDir)
+}
+
+// runTTSCommand 执行命令行语音合成程序，文本从标准输入传入
+func runTTSCommand(binary string, args []string, text string) error {
+	cmd := exec.Command(binary, args...)
+	cmd.Stdin = strings.NewReader(text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s 执行失败: %v: %s", binary, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// EspeakEngine 使用 espeak-ng 命令行合成语音
+type EspeakEngine struct {
+	Binary string // 为空时为 espeak-ng
+}
+
+// Name 引擎名称
+func (e *EspeakEngine) Name() string {
+	return "espeak-ng"
+}
+
+// Synthesize 合成语音，语速按 espeak-ng 默认的每分钟 175 词换算
+func (e *EspeakEngine) Synthesize(text string, options TTSOptions, outPath string) error {
+	binary := e.Binary
+	if binary == "" {
+		binary = "espeak-ng"
+	}
+	args := []string{"-b", "1", "-s", strconv.Itoa(int(math.Round(175 * options.speed()))), "-w", outPath, "--stdin"}
+	if options.Voice != "" {
+		args = append([]string{"-v", options.Voice}, args...)
+	}
+	return runTTSCommand(binary, args, text)
+}
+
+// PiperEngine 使用 piper 命令行合成语音
+type PiperEngine struct {
+	Binary string // 为空时为 piper
+	Model  string // 默认模型文件（.onnx），TTSOptions.Voice 不为空时使用 Voice
+}
+
+// Name 引擎名称
+func (e *PiperEngine) Name() string {
+	return "piper"
+}
+
+// Synthesize 合成语音，语速通过 length_scale 调整
+func (e *PiperEngine) Synthesize(text string, options TTSOptions, outPath string) error {
+	binary := e.Binary
+	if binary == "" {
+		binary = "piper"
+	}
+	model := e.Model
+	if options.Voice != "" {
+		model = options.Voice
+	}
+	if model == "" {
+		return fmt.Errorf("piper 需要模型文件")
+	}
+	args := []string{"--model", model, "--output_file", outPath,
+		"--length_scale", strconv.FormatFloat(1/options.speed(), 'f', -1, 64)}
+	return runTTSCommand(binary, args, text)
+}
+
+// ToneEngine 不依赖外部程序的确定性引擎，按文本估算的时长生成正弦波或静音，用于测试
+type ToneEngine struct {
+	Frequency float64 // 正弦波频率（Hz），为 0 时生成静音
+}
+
+// Name 引擎名称
+func (e *ToneEngine) Name() string {
+	if e.Frequency > 0 {
+		return "sine"
+	}
+	return "silence"
+}
+
+// toneSampleRate ToneEngine 生成音频的采样率
+const toneSampleRate = 16000
+
+// Synthesize 生成 16 位单声道 WAV，时长见 EstimateSpeechDuration
+func (e *ToneEngine) Synthesize(text string, options TTSOptions, outPath string) error {
+	samples := int(math.Round(EstimateSpeechDuration(text, options.Speed) * toneSampleRate))
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+samples*2))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, []uint32{16})
+	binary.Write(&buf, binary.LittleEndian, []uint16{1, 1})
+	binary.Write(&buf, binary.LittleEndian, []uint32{toneSampleRate, toneSampleRate * 2})
+	binary.Write(&buf, binary.LittleEndian, []uint16{2, 16})
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(samples*2))
+	for i := 0; i < samples; i++ {
+		v := 0.3 * math.Sin(2*math.Pi*e.Frequency*float64(i)/toneSampleRate)
+		binary.Write(&buf, binary.LittleEndian, int16(v*math.MaxInt16))
+	}
+	return os.WriteFile(outPath, buf.Bytes(), 0644)
+}
+
+// EstimateSpeechDuration 估算朗读文本的时长（秒）：每个汉字等 CJK 字符 0.25 秒，
+// 每个其他语言的单词 0.4 秒，每个标点停顿 0.2 秒，至少 0.5 秒，再除以语速倍数
+func EstimateSpeechDuration(text string, speed float64) float64 {
+	duration := 0.0
+	inWord := false
+	for _, r := range text {
+		switch {
+		case isCJK(r):
+			duration += 0.25
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				duration += 0.4
+			}
+			inWord = true
+		case unicode.IsPunct(r):
+			duration += 0.2
+			inWord = false
+		default:
+			inWord = false
+		}
+	}
+	duration = math.Max(duration, 0.5)
+	if speed > 0 {
+		duration /= speed
+	}
+	return duration
+}
+
+// isCJK 是否为中日韩文字，这些文字每个字单独朗读
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// LocalTTSService 使用本地引擎的 TTS 服务，生成的音频按引擎、语音、语速和文本的哈希缓存
+type LocalTTSService struct {
+	engine   TTSEngine
+	cacheDir string
+}
+
+// NewTTSService 创建 TTS 服务
+func NewTTSService(engine TTSEngine, cacheDir string) (*LocalTTSService, error) {
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return nil, fmt.Errorf("创建 TTS 缓存目录失败: %w", err)
+	}
+	return &LocalTTSService{engine: engine, cacheDir: cacheDir}, nil
+}
+
+// Name 服务名称，与引擎名称相同
+func (s *LocalTTSService) Name() string {
+	return s.engine.Name()
+}
+
+// cachePath 缓存文件路径
+func (s *LocalTTSService) cachePath(text string, options TTSOptions) string {
+	key := strings.Join([]string{s.engine.Name(), options.Voice, strconv.FormatFloat(options.speed(), 'f', -1, 64), text}, "\x00")
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(s.cacheDir, hex.EncodeToString(hash[:])+".wav")
+}
+
+// GenerateAudio 将文本转换为 WAV 文件，相同的文本和参数直接返回缓存的文件
+func (s *LocalTTSService) GenerateAudio(text string, options TTSOptions) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		return "", fmt.Errorf("文本不能为空")
+	}
+	path := s.cachePath(text, options)
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+
+	// 先生成到临时文件再重命名，并发生成同一段语音时不会读到写了一半的文件
+	tmp, err := os.CreateTemp(s.cacheDir, ".tts-*.wav")
+	if err != nil {
+		return "", fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	if err := s.engine.Synthesize(text, options, tmp.Name()); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("保存语音失败: %w", err)
+	}
+	return path, nil
+}
+
+// editlySpeech 把 TTSService 适配为 Editly voice 层的语音合成引擎
+type editlySpeech struct {
+	tts TTSService
+}
+
+func (s editlySpeech) Synthesize(text string, options ffmpeg_go.SpeechOptions) (string, error) {
+	return s.tts.GenerateAudio(text, TTSOptions{Voice: options.Voice, Speed: options.Rate})
+}
+
+// UseTTSForEditly 让 Editly 的 voice 层使用指定的 TTS 服务合成语音
+func UseTTSForEditly(tts TTSService) {
+	ffmpeg_go.SetSpeechSynthesizer(editlySpeech{tts: tts})
+}
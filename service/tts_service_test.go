@@ -0,0 +1,97 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEstimateSpeechDuration(t *testing.T) {
+	// 两个单词、一个标点、两个汉字
+	assert.InDelta(t, 0.4*2+0.2+0.25*2, EstimateSpeechDuration("Hello world,你好", 0), 1e-9)
+	assert.InDelta(t, (0.4*2+0.2+0.25*2)/2, EstimateSpeechDuration("Hello world,你好", 2), 1e-9)
+	// 至少 0.5 秒
+	assert.Equal(t, 0.5, EstimateSpeechDuration("好", 1))
+}
+
+func TestToneEngine(t *testing.T) {
+	dir := t.TempDir()
+	text, options := "测试语音合成", TTSOptions{Speed: 1.5}
+	for _, engine := range []*ToneEngine{{Frequency: 440}, {}} {
+		path := filepath.Join(dir, engine.Name()+".wav")
+		assert.Nil(t, engine.Synthesize(text, options, path))
+		data, err := os.ReadFile(path)
+		assert.Nil(t, err)
+
+		// 16 位单声道 PCM，数据长度与估算的时长一致
+		assert.Equal(t, "RIFF", string(data[0:4]))
+		assert.Equal(t, uint32(len(data)-8), binary.LittleEndian.Uint32(data[4:8]))
+		assert.Equal(t, "WAVEfmt ", string(data[8:16]))
+		assert.Equal(t, uint16(1), binary.LittleEndian.Uint16(data[20:22]))
+		assert.Equal(t, uint16(1), binary.LittleEndian.Uint16(data[22:24]))
+		assert.Equal(t, uint32(toneSampleRate), binary.LittleEndian.Uint32(data[24:28]))
+		assert.Equal(t, uint16(16), binary.LittleEndian.Uint16(data[34:36]))
+		assert.Equal(t, "data", string(data[36:40]))
+		size := binary.LittleEndian.Uint32(data[40:44])
+		assert.Equal(t, len(data)-44, int(size))
+		assert.InDelta(t, EstimateSpeechDuration(text, options.Speed), float64(size)/2/toneSampleRate, 1.0/toneSampleRate)
+
+		// 相同的输入生成相同的文件
+		again := filepath.Join(dir, engine.Name()+"-again.wav")
+		assert.Nil(t, engine.Synthesize(text, options, again))
+		second, _ := os.ReadFile(again)
+		assert.True(t, bytes.Equal(data, second))
+	}
+}
+
+// countingEngine 记录合成次数
+type countingEngine struct {
+	ToneEngine
+	calls int
+}
+
+func (e *countingEngine) Synthesize(text string, options TTSOptions, outPath string) error {
+	e.calls++
+	return e.ToneEngine.Synthesize(text, options, outPath)
+}
+
+func TestLocalTTSServiceCache(t *testing.T) {
+	engine := &countingEngine{}
+	tts, err := NewTTSService(engine, t.TempDir())
+	assert.Nil(t, err)
+
+	path, err := tts.GenerateAudio("你好", TTSOptions{Voice: "zh"})
+	assert.Nil(t, err)
+	assert.FileExists(t, path)
+	assert.Equal(t, 1, engine.calls)
+
+	// 相同的文本和参数直接返回缓存
+	cached, err := tts.GenerateAudio("你好", TTSOptions{Voice: "zh", Speed: 1})
+	assert.Nil(t, err)
+	assert.Equal(t, path, cached)
+	assert.Equal(t, 1, engine.calls)
+
+	// 语音、语速或文本不同时重新合成
+	paths := map[string]bool{path: true}
+	for _, c := range []struct {
+		text    string
+		options TTSOptions
+	}{
+		{"你好", TTSOptions{Voice: "en"}},
+		{"你好", TTSOptions{Voice: "zh", Speed: 1.2}},
+		{"再见", TTSOptions{Voice: "zh"}},
+	} {
+		p, err := tts.GenerateAudio(c.text, c.options)
+		assert.Nil(t, err)
+		assert.False(t, paths[p], "%+v", c)
+		paths[p] = true
+	}
+	assert.Equal(t, 4, engine.calls)
+
+	_, err = tts.GenerateAudio("  ", TTSOptions{})
+	assert.EqualError(t, err, "文本不能为空")
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
@@ -20,7 +21,14 @@ type VideoInfo struct {
 	Height     int     `json:"height"`
 	FPS        float64 `json:"fps"`
 	Bitrate    int     `json:"bitrate"`
-	AnalyzedAt time.Time `json:"analyzedAt"`
+	PixFmt     string  `json:"pixFmt,omitempty"`
+	FormatName string  `json:"formatName,omitempty"` // 容器格式，如 mov,mp4,m4a,3gp,3g2,mj2
+	AudioCodec string  `json:"audioCodec,omitempty"` // 为空表示没有音频流
+	SampleRate int     `json:"sampleRate,omitempty"`
+	Channels   int     `json:"channels,omitempty"`
+	// ExtraStreams 第一条视频流和音频流以外的流，如 audio:ac3、subtitle:mov_text
+	ExtraStreams []string  `json:"extraStreams,omitempty"`
+	AnalyzedAt   time.Time `json:"analyzedAt"`
 }
 
 // VideoInfoCache 视频信息缓存
@@ -114,13 +122,34 @@ func (vic *VideoInfoCache) AnalyzeVideo(filePath string) (*VideoInfo, error) {
 		if bitRateStr, ok := format["bit_rate"].(string); ok {
 			fmt.Sscanf(bitRateStr, "%d", &videoInfo.Bitrate)
 		}
+
+		if formatName, ok := format["format_name"].(string); ok {
+			videoInfo.FormatName = formatName
+		}
 	}
 	
-	// 获取视频流信息
+	// 获取视频流和音频流信息，只取第一条，其余的流记录在 ExtraStreams 中
 	if streams, ok := probeData["streams"].([]interface{}); ok {
 		for _, stream := range streams {
 			if streamMap, ok := stream.(map[string]interface{}); ok {
-				if codecType, ok := streamMap["codec_type"].(string); ok && codecType == "video" {
+				codecType, _ := streamMap["codec_type"].(string)
+				if (codecType != "audio" || videoInfo.AudioCodec != "") && (codecType != "video" || videoInfo.Codec != "") {
+					codecName, _ := streamMap["codec_name"].(string)
+					videoInfo.ExtraStreams = append(videoInfo.ExtraStreams, codecType+":"+codecName)
+					continue
+				}
+				if codecType == "audio" {
+					if codecName, ok := streamMap["codec_name"].(string); ok {
+						videoInfo.AudioCodec = codecName
+					}
+					if sampleRate, ok := streamMap["sample_rate"].(string); ok {
+						fmt.Sscanf(sampleRate, "%d", &videoInfo.SampleRate)
+					}
+					if channels, ok := streamMap["channels"].(float64); ok {
+						videoInfo.Channels = int(channels)
+					}
+				}
+				if codecType == "video" {
 					// 获取编码
 					if codecName, ok := streamMap["codec_name"].(string); ok {
 						videoInfo.Codec = codecName
@@ -141,7 +170,11 @@ func (vic *VideoInfoCache) AnalyzeVideo(filePath string) (*VideoInfo, error) {
 							videoInfo.FPS = float64(num) / float64(den)
 						}
 					}
-					break
+
+					// 获取像素格式
+					if pixFmt, ok := streamMap["pix_fmt"].(string); ok {
+						videoInfo.PixFmt = pixFmt
+					}
 				}
 			}
 		}
@@ -153,25 +186,77 @@ func (vic *VideoInfoCache) AnalyzeVideo(filePath string) (*VideoInfo, error) {
 	return videoInfo, nil
 }
 
-// PreprocessInputFiles 预处理输入文件
+// PreprocessInputFiles 预处理输入文件，只有不符合 DefaultPreprocessTarget 的文件才会转码
 func (vic *VideoInfoCache) PreprocessInputFiles(inputFiles []string, workDir string) ([]string, error) {
+	return vic.PreprocessInputFilesWithTarget(inputFiles, workDir, DefaultPreprocessTarget)
+}
+
+// PreprocessInputFilesWithTarget 按目标格式预处理输入文件：已符合的文件原样使用，
+// 其余文件按转码决策复制、换容器或转码到 workDir/preprocessed 目录
+func (vic *VideoInfoCache) PreprocessInputFilesWithTarget(inputFiles []string, workDir string, target *TranscodeTarget) ([]string, error) {
 	processedFiles := make([]string, len(inputFiles))
-	
+
 	for i, file := range inputFiles {
 		// 构造完整文件路径
 		fullPath := filepath.Join(workDir, "video", file)
-		
+
 		// 分析视频信息
-		_, err := vic.AnalyzeVideo(fullPath)
+		info, err := vic.AnalyzeVideo(fullPath)
 		if err != nil {
 			return nil, fmt.Errorf("分析视频文件失败 %s: %w", file, err)
 		}
-		
-		// 检查是否需要预处理
-		// 如果视频编码不是h264，或者分辨率不匹配，或者fps不匹配，则需要预处理
-		// 这里我们简化处理，只在必要时进行转码
-		processedFiles[i] = fullPath
+
+		// 检查是否需要预处理，编码、分辨率、帧率等都符合目标时直接使用源文件
+		decision := DecideTranscode(info, target)
+		if !decision.NeedsProcessing() {
+			processedFiles[i] = fullPath
+			continue
+		}
+
+		outputDir := filepath.Join(workDir, "preprocessed")
+		if err := os.MkdirAll(outputDir, 0755); err != nil {
+			return nil, fmt.Errorf("创建预处理目录失败: %w", err)
+		}
+		outputFile := filepath.Join(outputDir, strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))+preprocessExt(file, target))
+
+		stream, err := decision.BuildStream(fullPath, outputFile, info, target)
+		if err != nil {
+			return nil, fmt.Errorf("构建预处理命令失败 %s: %w", file, err)
+		}
+		if err := stream.Run(); err != nil {
+			return nil, fmt.Errorf("预处理视频文件失败 %s (%s): %w", file, decision, err)
+		}
+		processedFiles[i] = outputFile
 	}
-	
+
 	return processedFiles, nil
-}
\ No newline at end of file
+}
+
+// h264Containers 可以容纳 h264/hevc 视频和 aac 音频的容器扩展名
+var h264Containers = map[string]bool{".mp4": true, ".m4v": true, ".mov": true, ".mkv": true, ".ts": true}
+
+// preprocessExt 预处理输出文件的扩展名：指定了容器时按容器选择；未指定时保留源文件的扩展名，
+// 源容器无法容纳目标编码时（如 webm、avi 中的 h264/aac）改用 .mp4，其他编码使用 .mkv
+func preprocessExt(file string, target *TranscodeTarget) string {
+	switch target.Format {
+	case "":
+	case "mpegts":
+		return ".ts"
+	case "matroska":
+		return ".mkv"
+	default:
+		return "." + target.Format
+	}
+	ext := strings.ToLower(filepath.Ext(file))
+	h264 := target.VideoCodec == "" || target.VideoCodec == "h264" || target.VideoCodec == "hevc"
+	aac := target.AudioCodec == "" || target.AudioCodec == "aac"
+	switch {
+	case target.VideoCodec == "" && target.AudioCodec == "":
+		return filepath.Ext(file)
+	case h264 && aac && h264Containers[ext]:
+		return filepath.Ext(file)
+	case h264 && aac:
+		return ".mp4"
+	}
+	return ".mkv"
+}
@@ -0,0 +1,17 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreprocessExt(t *testing.T) {
+	// 默认目标转码为 h264/aac，webm 和 avi 容器不能容纳，改用 mp4
+	assert.Equal(t, ".mp4", preprocessExt("a.webm", DefaultPreprocessTarget))
+	assert.Equal(t, ".mp4", preprocessExt("a.avi", DefaultPreprocessTarget))
+	assert.Equal(t, ".mov", preprocessExt("a.mov", DefaultPreprocessTarget))
+	assert.Equal(t, ".ts", preprocessExt("a.webm", tsTarget))
+	assert.Equal(t, ".mkv", preprocessExt("a.webm", &TranscodeTarget{VideoCodec: "vp9", AudioCodec: "aac"}))
+	assert.Equal(t, ".webm", preprocessExt("a.webm", &TranscodeTarget{FPS: 30}))
+}
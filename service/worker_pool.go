@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -13,6 +14,7 @@ import (
 	"sync"
 	"time"
 	
+	ffmpeg_go "github.com/u2takey/ffmpeg-go"
 	"github.com/u2takey/ffmpeg-go/queue"
 	"github.com/u2takey/ffmpeg-go/utils"
 	"github.com/google/uuid"
@@ -283,6 +285,8 @@ func (w *Worker) executeTask(task *queue.Task) (string, error) {
         return w.executeMaterialPreprocess(task)
     case "videoEdit":
         return w.executeVideoEdit(task)
+    case "editly":
+        return w.executeEditly(task)
     default:
         return "", fmt.Errorf("unsupported task type: %s", taskType)
     }
@@ -301,6 +305,24 @@ func (w *Worker) executeMaterialPreprocess(task *queue.Task) (string, error) {
 	return task.Result, nil
 }
 
+// executeEditly 执行 Editly 编辑任务，spec 为已验证的编辑规范，如模板渲染出的规范
+func (w *Worker) executeEditly(task *queue.Task) (string, error) {
+	data, err := json.Marshal(task.Spec.(map[string]interface{})["spec"])
+	if err != nil {
+		return "", fmt.Errorf("invalid task spec: %v", err)
+	}
+	var spec ffmpeg_go.EditSpec
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return "", fmt.Errorf("invalid task spec: %v", err)
+	}
+	spec.Verbose = spec.Verbose || task.Verbose
+
+	if err := ffmpeg_go.NewEditly(&spec).Edit(); err != nil {
+		return "", err
+	}
+	return spec.OutPath, nil
+}
+
 // executeVideoEdit 执行视频编辑任务
 func (w *Worker) executeVideoEdit(task *queue.Task) (string, error) {
 	spec, ok := task.Spec.(map[string]interface{})
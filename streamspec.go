@@ -0,0 +1,205 @@
+package ffmpeg_go
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StreamType the media type of a stream in a stream specifier.
+type StreamType string
+
+const (
+	StreamTypeVideo StreamType = "v"
+	// StreamTypeVideoOnly video streams which are not attached pictures, video thumbnails or cover arts
+	StreamTypeVideoOnly  StreamType = "V"
+	StreamTypeAudio      StreamType = "a"
+	StreamTypeSubtitle   StreamType = "s"
+	StreamTypeData       StreamType = "d"
+	StreamTypeAttachment StreamType = "t"
+)
+
+// StreamSpecifier selects streams of an input or an output, built from the parts ffmpeg supports: program, type,
+// index and metadata match.  The zero value matches all streams.
+//
+//	SpecType(StreamTypeAudio)                                   // "a", all audio streams
+//	SpecType(StreamTypeSubtitle).Metadata("language", "eng")    // "s:m:language:eng"
+//	SpecProgram(1).Type(StreamTypeVideo).Index(0).Optional()   // "p:1:v:0?"
+//
+// Official documentation: `Stream specifiers <https://ffmpeg.org/ffmpeg.html#Stream-specifiers-1>`__
+type StreamSpecifier struct {
+	parts    []string
+	optional bool
+}
+
+// SpecIndex matches the stream with the given index.
+func SpecIndex(index int) StreamSpecifier {
+	return StreamSpecifier{}.Index(index)
+}
+
+// SpecType matches the streams of the given type.
+func SpecType(streamType StreamType) StreamSpecifier {
+	return StreamSpecifier{}.Type(streamType)
+}
+
+// SpecProgram matches the streams of the program with the given id.
+func SpecProgram(id int) StreamSpecifier {
+	return StreamSpecifier{}.Program(id)
+}
+
+// SpecMetadata matches the streams with the metadata tag key, and value if it's not empty.
+func SpecMetadata(key, value string) StreamSpecifier {
+	return StreamSpecifier{}.Metadata(key, value)
+}
+
+func (s StreamSpecifier) with(parts ...string) StreamSpecifier {
+	return StreamSpecifier{parts: append(append([]string{}, s.parts...), parts...), optional: s.optional}
+}
+
+// Index narrows the specifier to the index-th matching stream.
+func (s StreamSpecifier) Index(index int) StreamSpecifier {
+	if index < 0 {
+		panic(fmt.Sprintf("invalid stream index %d", index))
+	}
+	return s.with(strconv.Itoa(index))
+}
+
+// Type narrows the specifier to streams of the given type.
+func (s StreamSpecifier) Type(streamType StreamType) StreamSpecifier {
+	return s.with(string(streamType))
+}
+
+// Program narrows the specifier to streams of the given program.
+func (s StreamSpecifier) Program(id int) StreamSpecifier {
+	return s.with("p", strconv.Itoa(id))
+}
+
+// Metadata narrows the specifier to streams with the metadata tag key, and value if it's not empty.
+func (s StreamSpecifier) Metadata(key, value string) StreamSpecifier {
+	if strings.Contains(key, ":") {
+		panic(fmt.Sprintf("invalid metadata key %q", key))
+	}
+	if value == "" {
+		return s.with("m", key)
+	}
+	return s.with("m", key, value)
+}
+
+// Optional marks a map as optional, the output is created even if no stream matches.  Only meaningful for maps.
+func (s StreamSpecifier) Optional() StreamSpecifier {
+	return StreamSpecifier{parts: s.parts, optional: true}
+}
+
+// String returns the specifier without the optional mark, as used in per-stream options.
+func (s StreamSpecifier) String() string {
+	return strings.Join(s.parts, ":")
+}
+
+// selector returns the specifier as used after an input index in maps.
+func (s StreamSpecifier) selector() string {
+	if s.optional {
+		return s.String() + "?"
+	}
+	return s.String()
+}
+
+// Select returns the streams of the input matching the specifier.
+func (s *Stream) Select(specifier StreamSpecifier) *Stream {
+	return s.Get(specifier.selector())
+}
+
+// typeStream selects the streams of a type, or the index-th one of them.
+func (s *Stream) typeStream(streamType StreamType, index []int) *Stream {
+	specifier := SpecType(streamType)
+	if len(index) > 0 {
+		specifier = specifier.Index(index[0])
+	}
+	return s.Select(specifier)
+}
+
+// Subtitle selects the subtitle streams, or the index-th one.
+func (s *Stream) Subtitle(index ...int) *Stream {
+	return s.typeStream(StreamTypeSubtitle, index)
+}
+
+// Data selects the data streams, or the index-th one.
+func (s *Stream) Data(index ...int) *Stream {
+	return s.typeStream(StreamTypeData, index)
+}
+
+// Disposition a flag of the output stream disposition.
+type Disposition string
+
+const (
+	// DispositionNone clears all dispositions, e.g. the default flag copied from the input
+	DispositionNone            Disposition = "0"
+	DispositionDefault         Disposition = "default"
+	DispositionDub             Disposition = "dub"
+	DispositionOriginal        Disposition = "original"
+	DispositionComment         Disposition = "comment"
+	DispositionLyrics          Disposition = "lyrics"
+	DispositionKaraoke         Disposition = "karaoke"
+	DispositionForced          Disposition = "forced"
+	DispositionHearingImpaired Disposition = "hearing_impaired"
+	DispositionVisualImpaired  Disposition = "visual_impaired"
+	DispositionCleanEffects    Disposition = "clean_effects"
+	DispositionAttachedPic     Disposition = "attached_pic"
+	DispositionCaptions        Disposition = "captions"
+	DispositionDescriptions    Disposition = "descriptions"
+	DispositionMetadata        Disposition = "metadata"
+)
+
+// OutputStreamOptions per-stream options of an output.
+type OutputStreamOptions struct {
+	// Codec e.g. "libx264", "aac" or "copy"
+	Codec string
+	// Bitrate e.g. "128k"
+	Bitrate     string
+	Disposition []Disposition
+	Metadata    map[string]string
+	// KwArgs other per-stream options, e.g. KwArgs{"crf": 20}
+	KwArgs KwArgs
+}
+
+func streamOptionKey(option string, specifier StreamSpecifier) string {
+	if specifier.String() == "" {
+		return option
+	}
+	return option + ":" + specifier.String()
+}
+
+// WithStreamOptions sets options on the output streams matching the specifier, e.g. SpecType(StreamTypeAudio).Index(1)
+// with a codec is passed as “-c:a:1“.
+func (s *Stream) WithStreamOptions(specifier StreamSpecifier, options OutputStreamOptions) *Stream {
+	kwargs := KwArgs{}
+	if options.Codec != "" {
+		kwargs[streamOptionKey("c", specifier)] = options.Codec
+	}
+	if options.Bitrate != "" {
+		kwargs[streamOptionKey("b", specifier)] = options.Bitrate
+	}
+	if len(options.Disposition) > 0 {
+		flags := make([]string, len(options.Disposition))
+		for i, d := range options.Disposition {
+			flags[i] = string(d)
+		}
+		kwargs[streamOptionKey("disposition", specifier)] = strings.Join(flags, "+")
+	}
+	if len(options.Metadata) > 0 {
+		kwargs[streamOptionKey("metadata:s", specifier)] = metadataArgs(options.Metadata)
+	}
+	for k, v := range options.KwArgs {
+		kwargs[streamOptionKey(k, specifier)] = v
+	}
+	return s.withOutputStreams(nil, kwargs)
+}
+
+// WithDisposition sets the disposition of the output streams matching the specifier.
+func (s *Stream) WithDisposition(specifier StreamSpecifier, dispositions ...Disposition) *Stream {
+	return s.WithStreamOptions(specifier, OutputStreamOptions{Disposition: dispositions})
+}
+
+// Map adds streams to an output, e.g. all audio streams of another input.
+func (s *Stream) Map(streams ...*Stream) *Stream {
+	return s.withOutputStreams(streams, nil)
+}
@@ -0,0 +1,39 @@
+package ffmpeg_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamSpecifier(t *testing.T) {
+	assert.Equal(t, "", StreamSpecifier{}.String())
+	assert.Equal(t, "a:1", SpecType(StreamTypeAudio).Index(1).String())
+	assert.Equal(t, "s:m:language:eng", SpecType(StreamTypeSubtitle).Metadata("language", "eng").String())
+	assert.Equal(t, "p:1:v:0", SpecProgram(1).Type(StreamTypeVideo).Index(0).String())
+	assert.Equal(t, "m:title", SpecMetadata("title", "").String())
+	assert.Equal(t, "2?", SpecIndex(2).Optional().selector())
+}
+
+func TestStreamMapping(t *testing.T) {
+	video := Input("video.mp4")
+	dub := Input("dub.mkv")
+	args := Output([]*Stream{video.Video(), video.Audio(0)}, "out.mkv").
+		Map(dub.Audio(), dub.Select(SpecType(StreamTypeSubtitle).Metadata("language", "eng").Optional())).
+		WithStreamOptions(SpecType(StreamTypeAudio).Index(1), OutputStreamOptions{
+			Codec:       "aac",
+			Bitrate:     "128k",
+			Disposition: []Disposition{DispositionDefault, DispositionDub},
+			Metadata:    map[string]string{"language": "chi"},
+		}).
+		WithStreamOptions(SpecType(StreamTypeVideo).Index(0), OutputStreamOptions{Codec: "libx264", KwArgs: KwArgs{"crf": 20}}).
+		WithDisposition(SpecType(StreamTypeAudio).Index(0), DispositionNone).
+		GetArgs()
+	assert.Equal(t, []string{
+		"-i", "video.mp4", "-i", "dub.mkv",
+		"-map", "0:v", "-map", "0:a:0", "-map", "1:a", "-map", "1:s:m:language:eng?",
+		"-b:a:1", "128k", "-c:a:1", "aac", "-c:v:0", "libx264", "-crf:v:0", "20",
+		"-disposition:a:0", "0", "-disposition:a:1", "default+dub", "-metadata:s:a:1", "language=chi",
+		"out.mkv",
+	}, args)
+}
@@ -0,0 +1,91 @@
+package ffmpeg_go
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/u2takey/ffmpeg-go/subtitles"
+)
+
+// BurnSubtitles renders a subtitle file onto the video.
+//
+// ASS files without a style override are rendered with the ass filter, everything else with the subtitles filter,
+// the style is passed as “force_style“.  Extra options such as “fontsdir“ (a directory with fonts that are not
+// installed, e.g. CJK fonts) or “charenc“ are passed with kwargs, “fontsdir“ is escaped like the file name.
+//
+// Official documentation: `subtitles <https://ffmpeg.org/ffmpeg-filters.html#subtitles-1>`__
+func (s *Stream) BurnSubtitles(file string, style *subtitles.Style, kwargs ...KwArgs) *Stream {
+	AssertType(s.Type, "FilterableStream", "subtitles")
+	args := MergeKwArgs(kwargs)
+	// option values are not escaped by GetFilter, a windows drive letter would end the option
+	args["filename"] = escapeChars(file, "\\':")
+	if dir, ok := args["fontsdir"]; ok {
+		args["fontsdir"] = escapeChars(fmt.Sprint(dir), "\\':")
+	}
+	format, _ := subtitles.FormatFromPath(file)
+	if style == nil && format == subtitles.FormatASS {
+		return NewFilterNode("ass", []*Stream{s}, 1, nil, args).Stream("", "")
+	}
+	if style != nil {
+		forceStyle, err := style.ForceStyle()
+		if err != nil {
+			panic(err)
+		}
+		if forceStyle != "" {
+			args["force_style"] = escapeChars(forceStyle, "\\':")
+		}
+	}
+	return NewFilterNode("subtitles", []*Stream{s}, 1, nil, args).Stream("", "")
+}
+
+// SubtitleTrack a subtitle file muxed as a soft subtitle stream.
+type SubtitleTrack struct {
+	File string
+	// ISO 639-2 language code, e.g. "eng" or "chi"
+	Language string
+	Title    string
+	Default  bool
+}
+
+// subtitleCodecForOutput returns the subtitle codec supported by the output container, "" to keep the default.
+func subtitleCodecForOutput(fileName string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".mp4", ".m4v", ".mov":
+		return "mov_text"
+	case ".webm":
+		return "webvtt"
+	}
+	return ""
+}
+
+// MuxSubtitles outputs the streams together with subtitle files as soft subtitle tracks.
+//
+// The codec is chosen by the container (mov_text for mp4/mov, webvtt for webm), language and title are written as
+// stream metadata.  Subtitle stream indexes assume the given streams carry no subtitles themselves.
+func MuxSubtitles(streams []*Stream, fileName string, tracks []SubtitleTrack, kwargs ...KwArgs) *Stream {
+	args := MergeKwArgs(kwargs)
+	all := append([]*Stream{}, streams...)
+	for i, track := range tracks {
+		all = append(all, Input(track.File).Get("s"))
+		var metadata []string
+		if track.Language != "" {
+			metadata = append(metadata, "language="+track.Language)
+		}
+		if track.Title != "" {
+			metadata = append(metadata, "title="+track.Title)
+		}
+		if len(metadata) > 0 {
+			args[fmt.Sprintf("metadata:s:s:%d", i)] = metadata
+		}
+		if track.Default {
+			args[fmt.Sprintf("disposition:s:%d", i)] = "default"
+		} else {
+			args[fmt.Sprintf("disposition:s:%d", i)] = "0"
+		}
+	}
+	if codec := subtitleCodecForOutput(fileName); codec != "" && !args.HasKey("c:s") && !args.HasKey("scodec") {
+		args["c:s"] = codec
+	}
+	return Output(all, fileName, args)
+}
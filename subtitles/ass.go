@@ -0,0 +1,391 @@
+package subtitles
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// the resolution assumed by libass when a script has no PlayResX/PlayResY
+	defaultASSPlayResX = 384
+	defaultASSPlayResY = 288
+)
+
+// DefaultStyle the style written for ASS documents without any style.
+var DefaultStyle = Style{
+	Name:         "Default",
+	FontName:     "Arial",
+	FontSize:     48,
+	PrimaryColor: "#FFFFFF",
+	OutlineColor: "#000000",
+	BackColor:    "#00000080",
+	BorderStyle:  1,
+	Outline:      2,
+	Alignment:    AlignBottomCenter,
+	MarginL:      20,
+	MarginR:      20,
+	MarginV:      40,
+}
+
+// FormatASSTimestamp formats d as "h:mm:ss.cc".
+func FormatASSTimestamp(d time.Duration) string {
+	h, m, s, ms := splitDuration(d)
+	return fmt.Sprintf("%d:%02d:%02d.%02d", h, m, s, ms/10)
+}
+
+// ASSColor converts "#RRGGBB" or "#RRGGBBAA" (AA is the opacity) to the ASS "&HAABBGGRR" notation.
+func ASSColor(color string) (string, error) {
+	c := strings.TrimPrefix(color, "#")
+	if len(c) != 6 && len(c) != 8 {
+		return "", fmt.Errorf("invalid color: %q", color)
+	}
+	if _, err := strconv.ParseUint(c, 16, 32); err != nil {
+		return "", fmt.Errorf("invalid color: %q", color)
+	}
+	alpha := "00"
+	if len(c) == 8 {
+		opacity, _ := strconv.ParseUint(c[6:], 16, 8)
+		alpha = fmt.Sprintf("%02X", 255-opacity)
+	}
+	return strings.ToUpper(fmt.Sprintf("&H%s%s%s%s", alpha, c[4:6], c[2:4], c[0:2])), nil
+}
+
+// parseASSColor converts "&HAABBGGRR" or "&HBBGGRR" to "#RRGGBB" or "#RRGGBBAA".
+func parseASSColor(color string) string {
+	c := strings.TrimSuffix(strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(color)), "&H"), "&")
+	if len(c) < 6 {
+		c = strings.Repeat("0", 6-len(c)) + c
+	}
+	alpha := uint64(0)
+	if len(c) == 8 {
+		alpha, _ = strconv.ParseUint(c[:2], 16, 8)
+		c = c[2:]
+	}
+	rgb := fmt.Sprintf("#%s%s%s", c[4:6], c[2:4], c[0:2])
+	if alpha != 0 {
+		return fmt.Sprintf("%s%02X", rgb, 255-alpha)
+	}
+	return rgb
+}
+
+func assBool(b bool) string {
+	if b {
+		return "-1"
+	}
+	return "0"
+}
+
+func formatASSNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// ForceStyle renders the style as the “force_style“ option of the ffmpeg subtitles filter, only fields which
+// are set are written.
+func (s *Style) ForceStyle() (string, error) {
+	var fields []string
+	if s.FontName != "" {
+		fields = append(fields, "FontName="+s.FontName)
+	}
+	if s.FontSize > 0 {
+		fields = append(fields, "FontSize="+formatASSNumber(s.FontSize))
+	}
+	for _, c := range []struct{ name, value string }{
+		{"PrimaryColour", s.PrimaryColor}, {"OutlineColour", s.OutlineColor}, {"BackColour", s.BackColor},
+	} {
+		if c.value == "" {
+			continue
+		}
+		v, err := ASSColor(c.value)
+		if err != nil {
+			return "", err
+		}
+		fields = append(fields, c.name+"="+v)
+	}
+	if s.Bold {
+		fields = append(fields, "Bold=-1")
+	}
+	if s.Italic {
+		fields = append(fields, "Italic=-1")
+	}
+	if s.BorderStyle > 0 {
+		fields = append(fields, fmt.Sprintf("BorderStyle=%d", s.BorderStyle))
+	}
+	if s.Outline > 0 {
+		fields = append(fields, "Outline="+formatASSNumber(s.Outline))
+	}
+	if s.Shadow > 0 {
+		fields = append(fields, "Shadow="+formatASSNumber(s.Shadow))
+	}
+	if s.Alignment != AlignDefault {
+		fields = append(fields, fmt.Sprintf("Alignment=%d", s.Alignment))
+	}
+	if s.MarginL > 0 {
+		fields = append(fields, fmt.Sprintf("MarginL=%d", s.MarginL))
+	}
+	if s.MarginR > 0 {
+		fields = append(fields, fmt.Sprintf("MarginR=%d", s.MarginR))
+	}
+	if s.MarginV > 0 {
+		fields = append(fields, fmt.Sprintf("MarginV=%d", s.MarginV))
+	}
+	return strings.Join(fields, ","), nil
+}
+
+// splitFormatLine splits "Key: a, b, c" into its fields, at most n fields (the last one keeps its commas).
+func splitFormatLine(value string, n int) []string {
+	fields := strings.SplitN(value, ",", n)
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+var (
+	assTagBlockRegexp = regexp.MustCompile(`\{[^}]*\}`)
+	assAnRegexp       = regexp.MustCompile(`\\an([1-9])`)
+	assPosRegexp      = regexp.MustCompile(`\\pos\(\s*([-\d.]+)\s*,\s*([-\d.]+)\s*\)`)
+)
+
+// parseASSText converts the dialogue text to a cue text, “\an“ and “\pos“ tags are moved to the cue.
+func parseASSText(c *Cue, text string, playResX, playResY int) {
+	text = assTagBlockRegexp.ReplaceAllStringFunc(text, func(block string) string {
+		if m := assAnRegexp.FindStringSubmatch(block); m != nil {
+			a, _ := strconv.Atoi(m[1])
+			c.Alignment = Alignment(a)
+			block = assAnRegexp.ReplaceAllString(block, "")
+		}
+		if m := assPosRegexp.FindStringSubmatch(block); m != nil {
+			x, _ := strconv.ParseFloat(m[1], 64)
+			y, _ := strconv.ParseFloat(m[2], 64)
+			c.Position = &Position{X: x / float64(playResX), Y: y / float64(playResY)}
+			block = assPosRegexp.ReplaceAllString(block, "")
+		}
+		if block == "{}" {
+			return ""
+		}
+		return block
+	})
+	text = strings.NewReplacer(`\N`, "\n", `\n`, "\n", `\h`, " ").Replace(text)
+	c.Text = text
+}
+
+// ReadASS parses an Advanced SubStation Alpha (or SSA) document.
+func ReadASS(r io.Reader) (*Track, error) {
+	t := &Track{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	section := ""
+	var styleFormat, eventFormat []string
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.ToLower(line)
+			continue
+		}
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		switch section {
+		case "[script info]":
+			switch key {
+			case "Title":
+				t.Title = value
+			case "PlayResX":
+				t.PlayResX, _ = strconv.Atoi(value)
+			case "PlayResY":
+				t.PlayResY, _ = strconv.Atoi(value)
+			}
+		case "[v4+ styles]", "[v4 styles]":
+			if key == "Format" {
+				styleFormat = splitFormatLine(value, -1)
+			} else if key == "Style" {
+				t.Styles = append(t.Styles, parseASSStyle(styleFormat, splitFormatLine(value, len(styleFormat))))
+			}
+		case "[events]":
+			if key == "Format" {
+				eventFormat = splitFormatLine(value, -1)
+			} else if key == "Dialogue" {
+				c, err := parseASSDialogue(t, eventFormat, splitFormatLine(value, len(eventFormat)))
+				if err != nil {
+					return nil, err
+				}
+				t.Cues = append(t.Cues, c)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func parseASSStyle(format, fields []string) *Style {
+	s := &Style{}
+	for i, name := range format {
+		if i >= len(fields) {
+			break
+		}
+		v := fields[i]
+		switch name {
+		case "Name":
+			s.Name = v
+		case "Fontname":
+			s.FontName = v
+		case "Fontsize":
+			s.FontSize, _ = strconv.ParseFloat(v, 64)
+		case "PrimaryColour":
+			s.PrimaryColor = parseASSColor(v)
+		case "OutlineColour":
+			s.OutlineColor = parseASSColor(v)
+		case "BackColour":
+			s.BackColor = parseASSColor(v)
+		case "Bold":
+			s.Bold = v != "0"
+		case "Italic":
+			s.Italic = v != "0"
+		case "BorderStyle":
+			s.BorderStyle, _ = strconv.Atoi(v)
+		case "Outline":
+			s.Outline, _ = strconv.ParseFloat(v, 64)
+		case "Shadow":
+			s.Shadow, _ = strconv.ParseFloat(v, 64)
+		case "Alignment":
+			a, _ := strconv.Atoi(v)
+			s.Alignment = Alignment(a)
+		case "MarginL":
+			s.MarginL, _ = strconv.Atoi(v)
+		case "MarginR":
+			s.MarginR, _ = strconv.Atoi(v)
+		case "MarginV":
+			s.MarginV, _ = strconv.Atoi(v)
+		}
+	}
+	return s
+}
+
+func parseASSDialogue(t *Track, format, fields []string) (*Cue, error) {
+	c := &Cue{}
+	playResX, playResY := t.PlayResX, t.PlayResY
+	if playResX == 0 || playResY == 0 {
+		playResX, playResY = defaultASSPlayResX, defaultASSPlayResY
+	}
+	var err error
+	for i, name := range format {
+		if i >= len(fields) {
+			break
+		}
+		switch name {
+		case "Start":
+			c.Start, err = parseTimestamp(fields[i])
+		case "End":
+			c.End, err = parseTimestamp(fields[i])
+		case "Style":
+			c.Style = fields[i]
+		case "Text":
+			parseASSText(c, fields[i], playResX, playResY)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func writeASSStyle(bw *bufio.Writer, s *Style) error {
+	var colors []string
+	for i, c := range []string{s.PrimaryColor, s.PrimaryColor, s.OutlineColor, s.BackColor} {
+		if c == "" {
+			c = []string{"#FFFFFF", "#FFFFFF", "#000000", "#00000080"}[i]
+		}
+		v, err := ASSColor(c)
+		if err != nil {
+			return err
+		}
+		colors = append(colors, v)
+	}
+	alignment := s.Alignment
+	if alignment == AlignDefault {
+		alignment = AlignBottomCenter
+	}
+	fontName, fontSize := s.FontName, s.FontSize
+	if fontName == "" {
+		fontName = DefaultStyle.FontName
+	}
+	if fontSize == 0 {
+		fontSize = DefaultStyle.FontSize
+	}
+	borderStyle := s.BorderStyle
+	if borderStyle == 0 {
+		borderStyle = 1
+	}
+	_, err := fmt.Fprintf(bw, "Style: %s,%s,%s,%s,%s,%s,%s,%s,%s,0,0,100,100,0,0,%d,%s,%s,%d,%d,%d,%d,1\n",
+		s.Name, fontName, formatASSNumber(fontSize), colors[0], colors[1], colors[2], colors[3],
+		assBool(s.Bold), assBool(s.Italic), borderStyle, formatASSNumber(s.Outline), formatASSNumber(s.Shadow),
+		alignment, s.MarginL, s.MarginR, s.MarginV)
+	return err
+}
+
+// WriteASS writes the track as an Advanced SubStation Alpha document, DefaultStyle is written when the track
+// has no styles.  The play resolution defaults to 1920x1080.
+func WriteASS(w io.Writer, t *Track) error {
+	playResX, playResY := t.PlayResX, t.PlayResY
+	if playResX == 0 || playResY == 0 {
+		playResX, playResY = 1920, 1080
+	}
+	bw := bufio.NewWriter(w)
+	_, _ = bw.WriteString("[Script Info]\n")
+	if t.Title != "" {
+		_, _ = fmt.Fprintf(bw, "Title: %s\n", t.Title)
+	}
+	_, _ = fmt.Fprintf(bw, "ScriptType: v4.00+\nWrapStyle: 0\nScaledBorderAndShadow: yes\nPlayResX: %d\nPlayResY: %d\n\n",
+		playResX, playResY)
+
+	_, _ = bw.WriteString("[V4+ Styles]\n")
+	_, _ = bw.WriteString("Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, " +
+		"Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, " +
+		"Alignment, MarginL, MarginR, MarginV, Encoding\n")
+	styles := t.Styles
+	if len(styles) == 0 {
+		styles = []*Style{&DefaultStyle}
+	}
+	for _, s := range styles {
+		if err := writeASSStyle(bw, s); err != nil {
+			return err
+		}
+	}
+
+	_, _ = bw.WriteString("\n[Events]\n")
+	_, _ = bw.WriteString("Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text\n")
+	for _, c := range t.Cues {
+		style := c.Style
+		if style == "" {
+			style = styles[0].Name
+		}
+		tags := ""
+		if c.Alignment != AlignDefault {
+			tags += fmt.Sprintf("\\an%d", c.Alignment)
+		}
+		if c.Position != nil {
+			tags += fmt.Sprintf("\\pos(%d,%d)",
+				int(math.Round(c.Position.X*float64(playResX))), int(math.Round(c.Position.Y*float64(playResY))))
+		}
+		if tags != "" {
+			tags = "{" + tags + "}"
+		}
+		text := strings.ReplaceAll(c.Text, "\n", `\N`)
+		_, _ = fmt.Fprintf(bw, "Dialogue: 0,%s,%s,%s,,0,0,0,,%s%s\n",
+			FormatASSTimestamp(c.Start), FormatASSTimestamp(c.End), style, tags, text)
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,52 @@
+package subtitles
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatSRTTimestamp formats d as "hh:mm:ss,mmm".
+func FormatSRTTimestamp(d time.Duration) string {
+	h, m, s, ms := splitDuration(d)
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", h, m, s, ms)
+}
+
+// ReadSRT parses a SubRip document.  A leading “{\anN}“ tag is read as the cue alignment.
+func ReadSRT(r io.Reader) (*Track, error) {
+	blocks, err := readBlocks(r)
+	if err != nil {
+		return nil, err
+	}
+	t := &Track{}
+	for _, block := range blocks {
+		// the counter line is optional in practice
+		if _, err := strconv.Atoi(strings.TrimSpace(block[0])); err == nil && len(block) > 1 {
+			block = block[1:]
+		}
+		start, end, _, err := parseCueTimes(block[0])
+		if err != nil {
+			return nil, err
+		}
+		text, alignment := extractAlignmentTag(strings.Join(block[1:], "\n"))
+		t.Cues = append(t.Cues, &Cue{Start: start, End: end, Text: text, Alignment: alignment})
+	}
+	return t, nil
+}
+
+// WriteSRT writes the track as a SubRip document.  Styles and positions can not be represented and are dropped,
+// alignments are written as “{\anN}“ tags.  Blank lines in cue text are dropped.
+func WriteSRT(w io.Writer, t *Track) error {
+	bw := bufio.NewWriter(w)
+	for i, c := range t.Cues {
+		text := cueText(c.Text)
+		if c.Alignment != AlignDefault && c.Alignment != AlignBottomCenter {
+			text = fmt.Sprintf("{\\an%d}%s", c.Alignment, text)
+		}
+		_, _ = fmt.Fprintf(bw, "%d\n%s --> %s\n%s\n\n", i+1, FormatSRTTimestamp(c.Start), FormatSRTTimestamp(c.End), text)
+	}
+	return bw.Flush()
+}
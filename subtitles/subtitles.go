@@ -0,0 +1,281 @@
+// Package subtitles provides a model of subtitle cues and styles, with readers and writers for SRT, WebVTT and ASS.
+package subtitles
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format a subtitle file format
+type Format string
+
+const (
+	FormatSRT Format = "srt"
+	FormatVTT Format = "vtt"
+	FormatASS Format = "ass"
+)
+
+// FormatFromPath returns the format of a file by its extension.
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".srt":
+		return FormatSRT, nil
+	case ".vtt":
+		return FormatVTT, nil
+	case ".ass", ".ssa":
+		return FormatASS, nil
+	}
+	return "", fmt.Errorf("unknown subtitle format: %s", path)
+}
+
+// Alignment the anchor of a cue, numbered like the numpad (ASS “\an“): 1 is bottom left, 5 is the center and
+// 9 is top right.  Zero means the default, bottom center.
+type Alignment int
+
+const (
+	AlignDefault      Alignment = 0
+	AlignBottomLeft   Alignment = 1
+	AlignBottomCenter Alignment = 2
+	AlignBottomRight  Alignment = 3
+	AlignMiddleLeft   Alignment = 4
+	AlignMiddleCenter Alignment = 5
+	AlignMiddleRight  Alignment = 6
+	AlignTopLeft      Alignment = 7
+	AlignTopCenter    Alignment = 8
+	AlignTopRight     Alignment = 9
+)
+
+// Position an explicit position of the cue anchor, as fractions (0-1) of the video width and height.
+type Position struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Style the look of a cue, modelled after ASS styles.
+//
+// Colors are "#RRGGBB" or "#RRGGBBAA" where AA is the opacity.
+type Style struct {
+	Name         string    `json:"name"`
+	FontName     string    `json:"fontName,omitempty"`
+	FontSize     float64   `json:"fontSize,omitempty"`
+	PrimaryColor string    `json:"primaryColor,omitempty"`
+	OutlineColor string    `json:"outlineColor,omitempty"`
+	BackColor    string    `json:"backColor,omitempty"`
+	Bold         bool      `json:"bold,omitempty"`
+	Italic       bool      `json:"italic,omitempty"`
+	Outline      float64   `json:"outline,omitempty"`
+	Shadow       float64   `json:"shadow,omitempty"`
+	BorderStyle  int       `json:"borderStyle,omitempty"`
+	Alignment    Alignment `json:"alignment,omitempty"`
+	MarginL      int       `json:"marginL,omitempty"`
+	MarginR      int       `json:"marginR,omitempty"`
+	MarginV      int       `json:"marginV,omitempty"`
+}
+
+// Cue a piece of text shown from Start to End, lines are separated by "\n".
+type Cue struct {
+	Start     time.Duration `json:"start"`
+	End       time.Duration `json:"end"`
+	Text      string        `json:"text"`
+	Style     string        `json:"style,omitempty"`
+	Alignment Alignment     `json:"alignment,omitempty"`
+	Position  *Position     `json:"position,omitempty"`
+}
+
+// Track a subtitle document.  PlayResX and PlayResY are the reference resolution of ASS styles and positions.
+type Track struct {
+	Title    string   `json:"title,omitempty"`
+	PlayResX int      `json:"playResX,omitempty"`
+	PlayResY int      `json:"playResY,omitempty"`
+	Styles   []*Style `json:"styles,omitempty"`
+	Cues     []*Cue   `json:"cues"`
+}
+
+// Style returns the style with the given name, or nil.
+func (t *Track) Style(name string) *Style {
+	for _, s := range t.Styles {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Duration returns the end of the last cue.
+func (t *Track) Duration() time.Duration {
+	var d time.Duration
+	for _, c := range t.Cues {
+		if c.End > d {
+			d = c.End
+		}
+	}
+	return d
+}
+
+// Shift moves all cues by offset.
+func (t *Track) Shift(offset time.Duration) {
+	for _, c := range t.Cues {
+		c.Start += offset
+		c.End += offset
+	}
+}
+
+// Read parses a subtitle document in the given format.
+func Read(r io.Reader, format Format) (*Track, error) {
+	switch format {
+	case FormatSRT:
+		return ReadSRT(r)
+	case FormatVTT:
+		return ReadVTT(r)
+	case FormatASS:
+		return ReadASS(r)
+	}
+	return nil, fmt.Errorf("unknown subtitle format: %s", format)
+}
+
+// Write writes the track in the given format.
+func Write(w io.Writer, t *Track, format Format) error {
+	switch format {
+	case FormatSRT:
+		return WriteSRT(w, t)
+	case FormatVTT:
+		return WriteVTT(w, t)
+	case FormatASS:
+		return WriteASS(w, t)
+	}
+	return fmt.Errorf("unknown subtitle format: %s", format)
+}
+
+// ReadFile parses a subtitle file, the format is detected by its extension.
+func ReadFile(path string) (*Track, error) {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Read(f, format)
+}
+
+// WriteFile writes the track to a file, the format is detected by its extension.
+func WriteFile(path string, t *Track) error {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+	buf := bytes.NewBuffer(nil)
+	if err := Write(buf, t, format); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
+
+// readBlocks splits the input into blocks separated by blank lines.
+func readBlocks(r io.Reader) ([][]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text := strings.TrimPrefix(string(data), "\ufeff")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var blocks [][]string
+	var block []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			if len(block) > 0 {
+				blocks = append(blocks, block)
+				block = nil
+			}
+			continue
+		}
+		block = append(block, line)
+	}
+	if len(block) > 0 {
+		blocks = append(blocks, block)
+	}
+	return blocks, nil
+}
+
+var timestampRegexp = regexp.MustCompile(`^(?:(\d+):)?(\d{1,2}):(\d{1,2})[,.](\d{1,3})$`)
+
+// parseTimestamp parses "hh:mm:ss,mmm", "hh:mm:ss.mmm", "mm:ss.mmm" and "h:mm:ss.cc".
+func parseTimestamp(s string) (time.Duration, error) {
+	m := timestampRegexp.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("invalid timestamp: %q", s)
+	}
+	var h, mins, sec int
+	if m[1] != "" {
+		h, _ = strconv.Atoi(m[1])
+	}
+	mins, _ = strconv.Atoi(m[2])
+	sec, _ = strconv.Atoi(m[3])
+	// the fraction may be centiseconds (ASS) or milliseconds
+	frac, _ := strconv.Atoi((m[4] + "00")[:3])
+	return time.Duration(h)*time.Hour + time.Duration(mins)*time.Minute + time.Duration(sec)*time.Second +
+		time.Duration(frac)*time.Millisecond, nil
+}
+
+func splitDuration(d time.Duration) (h, m, s, ms int) {
+	if d < 0 {
+		d = 0
+	}
+	ms = int(d / time.Millisecond)
+	h = ms / 3600000
+	m = ms / 60000 % 60
+	s = ms / 1000 % 60
+	return h, m, s, ms % 1000
+}
+
+// parseCueTimes parses the "start --> end" line, returning the remaining settings.
+func parseCueTimes(line string) (start, end time.Duration, settings string, err error) {
+	parts := strings.SplitN(line, "-->", 2)
+	if len(parts) != 2 {
+		return 0, 0, "", fmt.Errorf("invalid cue timing: %q", line)
+	}
+	if start, err = parseTimestamp(parts[0]); err != nil {
+		return
+	}
+	fields := strings.Fields(parts[1])
+	if len(fields) == 0 {
+		return 0, 0, "", fmt.Errorf("invalid cue timing: %q", line)
+	}
+	if end, err = parseTimestamp(fields[0]); err != nil {
+		return
+	}
+	return start, end, strings.Join(fields[1:], " "), nil
+}
+
+var alignmentTagRegexp = regexp.MustCompile(`\{\\an([1-9])\}`)
+
+// extractAlignmentTag removes a leading "{\anN}" tag, as used in SRT files.
+func extractAlignmentTag(text string) (string, Alignment) {
+	m := alignmentTagRegexp.FindStringSubmatchIndex(text)
+	if m == nil {
+		return text, AlignDefault
+	}
+	a, _ := strconv.Atoi(text[m[2]:m[3]])
+	return text[:m[0]] + text[m[1]:], Alignment(a)
+}
+
+// cueText prepares cue text for the SRT and WebVTT writers: blank lines would end the cue and are dropped, "-->"
+// would be read as a timing line and is written as "->".
+func cueText(text string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, strings.ReplaceAll(line, "-->", "->"))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
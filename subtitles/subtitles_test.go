@@ -0,0 +1,163 @@
+package subtitles
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testSRT = "1\r\n00:00:01,000 --> 00:00:02,500\r\nHello\r\nworld\r\n\r\n2\r\n00:00:03,000 --> 00:00:04,000\r\n{\\an8}顶部字幕\r\n"
+
+func TestSRT(t *testing.T) {
+	track, err := ReadSRT(strings.NewReader(testSRT))
+	assert.Nil(t, err)
+	assert.Equal(t, []*Cue{
+		{Start: time.Second, End: 2500 * time.Millisecond, Text: "Hello\nworld"},
+		{Start: 3 * time.Second, End: 4 * time.Second, Text: "顶部字幕", Alignment: AlignTopCenter},
+	}, track.Cues)
+
+	buf := bytes.NewBuffer(nil)
+	assert.Nil(t, WriteSRT(buf, track))
+	assert.Equal(t, strings.ReplaceAll(testSRT, "\r\n", "\n")+"\n", buf.String())
+}
+
+func TestVTT(t *testing.T) {
+	input := `WEBVTT - test
+
+NOTE a comment
+
+intro
+00:01.000 --> 00:02.000 line:0 align:start
+<i>Hello</i>
+
+00:00:03.000 --> 00:00:04.000 position:25% line:75%
+Positioned
+`
+	track, err := ReadVTT(strings.NewReader(input))
+	assert.Nil(t, err)
+	assert.Equal(t, []*Cue{
+		{Start: time.Second, End: 2 * time.Second, Text: "<i>Hello</i>", Alignment: AlignTopLeft},
+		{Start: 3 * time.Second, End: 4 * time.Second, Text: "Positioned", Alignment: AlignBottomCenter,
+			Position: &Position{X: 0.25, Y: 0.75}},
+	}, track.Cues)
+
+	buf := bytes.NewBuffer(nil)
+	assert.Nil(t, WriteVTT(buf, track))
+	assert.Equal(t, `WEBVTT
+
+00:00:01.000 --> 00:00:02.000 line:0 align:start
+<i>Hello</i>
+
+00:00:03.000 --> 00:00:04.000 position:25% line:75%
+Positioned
+
+`, buf.String())
+
+	_, err = ReadVTT(strings.NewReader("1\n00:01.000 --> 00:02.000\nno header\n"))
+	assert.NotNil(t, err)
+}
+
+func TestWriteCueText(t *testing.T) {
+	track := &Track{Cues: []*Cue{
+		{Start: time.Second, End: 2 * time.Second, Text: "first\n\n  \nsecond"},
+		{Start: 2 * time.Second, End: 3 * time.Second, Text: "a --> b"},
+		{Start: 3 * time.Second, End: 4 * time.Second, Text: "<i>Tom & Jerry</i> <3 &amp; 1 < 2"},
+	}}
+
+	// a blank line would end the cue and "-->" would be read as a timing line
+	buf := bytes.NewBuffer(nil)
+	assert.Nil(t, WriteSRT(buf, track))
+	assert.Equal(t, "1\n00:00:01,000 --> 00:00:02,000\nfirst\nsecond\n\n"+
+		"2\n00:00:02,000 --> 00:00:03,000\na -> b\n\n"+
+		"3\n00:00:03,000 --> 00:00:04,000\n<i>Tom & Jerry</i> <3 &amp; 1 < 2\n\n", buf.String())
+	read, err := ReadSRT(bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err)
+	assert.Len(t, read.Cues, 3)
+	assert.Equal(t, "first\nsecond", read.Cues[0].Text)
+
+	// "&" and "<" that are not markup are escaped, cue tags and references are kept
+	buf.Reset()
+	assert.Nil(t, WriteVTT(buf, track))
+	assert.Equal(t, "WEBVTT\n\n00:00:01.000 --> 00:00:02.000\nfirst\nsecond\n\n"+
+		"00:00:02.000 --> 00:00:03.000\na -> b\n\n"+
+		"00:00:03.000 --> 00:00:04.000\n<i>Tom &amp; Jerry</i> &lt;3 &amp; 1 &lt; 2\n\n", buf.String())
+	read, err = ReadVTT(bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"first\nsecond", "a -> b", "<i>Tom & Jerry</i> <3 & 1 < 2"},
+		[]string{read.Cues[0].Text, read.Cues[1].Text, read.Cues[2].Text})
+}
+
+func TestASS(t *testing.T) {
+	track := &Track{
+		Styles: []*Style{{Name: "Title", FontName: "Noto Sans CJK SC", FontSize: 64, PrimaryColor: "#FFCC00",
+			OutlineColor: "#000000", BackColor: "#00000080", Bold: true, Outline: 3, Alignment: AlignTopCenter}},
+		Cues: []*Cue{
+			{Start: 1230 * time.Millisecond, End: 2 * time.Second, Text: "第一行\n第二行, with comma", Style: "Title"},
+			{Start: 3 * time.Second, End: 4 * time.Second, Text: "pos", Style: "Title", Alignment: AlignMiddleCenter,
+				Position: &Position{X: 0.5, Y: 0.25}},
+		},
+	}
+	buf := bytes.NewBuffer(nil)
+	assert.Nil(t, WriteASS(buf, track))
+	assert.Contains(t, buf.String(), "PlayResX: 1920\nPlayResY: 1080\n")
+	assert.Contains(t, buf.String(),
+		"Style: Title,Noto Sans CJK SC,64,&H0000CCFF,&H0000CCFF,&H00000000,&H7F000000,-1,0,0,0,100,100,0,0,1,3,0,8,0,0,0,1\n")
+	assert.Contains(t, buf.String(), "Dialogue: 0,0:00:01.23,0:00:02.00,Title,,0,0,0,,第一行\\N第二行, with comma\n")
+	assert.Contains(t, buf.String(), "Dialogue: 0,0:00:03.00,0:00:04.00,Title,,0,0,0,,{\\an5\\pos(960,270)}pos\n")
+
+	parsed, err := ReadASS(bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, 1920, parsed.PlayResX)
+	assert.Equal(t, track.Cues, parsed.Cues)
+	assert.Equal(t, track.Styles[0].PrimaryColor, parsed.Style("Title").PrimaryColor)
+	assert.Equal(t, "#00000080", parsed.Style("Title").BackColor)
+	assert.Equal(t, AlignTopCenter, parsed.Style("Title").Alignment)
+	assert.True(t, parsed.Style("Title").Bold)
+}
+
+func TestForceStyle(t *testing.T) {
+	s := &Style{FontName: "Arial", FontSize: 24, PrimaryColor: "#FFFFFF", Outline: 1, Alignment: AlignTopCenter}
+	forceStyle, err := s.ForceStyle()
+	assert.Nil(t, err)
+	assert.Equal(t, "FontName=Arial,FontSize=24,PrimaryColour=&H00FFFFFF,Outline=1,Alignment=8", forceStyle)
+
+	_, err = (&Style{PrimaryColor: "red"}).ForceStyle()
+	assert.NotNil(t, err)
+}
+
+func TestSplitText(t *testing.T) {
+	assert.Equal(t, []string{"今天天气很好", "我们去公园散步吧！", "Hello world.", "Version 1.2 is out"},
+		SplitText("今天天气很好。我们去公园散步吧！\nHello world. Version 1.2 is out", 0))
+	// long sentences break at commas first, punctuation never starts a line
+	assert.Equal(t, []string{"春眠不觉晓", "处处闻啼鸟", "夜来风雨声", "花落知多少"},
+		SplitText("春眠不觉晓，处处闻啼鸟，夜来风雨声，花落知多少。", 20))
+	assert.Equal(t, []string{"一二三四五六七八九十！"}, SplitText("一二三四五六七八九十！", 20))
+	// wrapped lines are balanced instead of leaving a single word on the last line
+	assert.Equal(t, []string{"The quick brown", "fox jumps over", "the lazy dog."},
+		SplitText("The quick brown fox jumps over the lazy dog.", 20))
+}
+
+func TestLayoutCues(t *testing.T) {
+	cues := LayoutCues([]string{"你好", "Hiya!"}, time.Second, 5*time.Second)
+	assert.Equal(t, []*Cue{
+		{Start: time.Second, End: 3 * time.Second, Text: "你好"},
+		{Start: 3 * time.Second, End: 5 * time.Second, Text: "Hiya!"},
+	}, cues)
+}
+
+func TestTimeCues(t *testing.T) {
+	timings := []WordTiming{
+		{Text: "你", Start: 0, End: 200 * time.Millisecond},
+		{Text: "好", Start: 200 * time.Millisecond, End: 400 * time.Millisecond},
+		{Text: "Hello", Start: time.Second, End: 1500 * time.Millisecond},
+	}
+	assert.Equal(t, []*Cue{
+		{Start: 0, End: 400 * time.Millisecond, Text: "你好"},
+		{Start: time.Second, End: 1500 * time.Millisecond, Text: "Hello!"},
+	}, TimeCues([]string{"你好", "Hello!"}, timings))
+	// timings that do not match the texts fall back to the layout by length
+	assert.Equal(t, LayoutCues([]string{"再见"}, 0, 1500*time.Millisecond), TimeCues([]string{"再见"}, timings))
+}
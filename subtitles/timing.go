@@ -0,0 +1,295 @@
+package subtitles
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DefaultMaxLineWidth the default width of a cue in columns, a CJK character takes two columns.
+const DefaultMaxLineWidth = 42
+
+// WordTiming the time a word is spoken, as reported by a speech synthesizer.  CJK engines usually report every
+// character as a word.
+type WordTiming struct {
+	Text  string        `json:"text"`
+	Start time.Duration `json:"start"`
+	End   time.Duration `json:"end"`
+}
+
+// isWide reports whether r is displayed in two columns.
+func isWide(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		(r >= 0x3000 && r <= 0x303f) || (r >= 0xff00 && r <= 0xffef)
+}
+
+// textWidth the width of s in columns.
+func textWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if isWide(r) {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// isSpoken reports whether r is read aloud, punctuation and spaces are not.
+func isSpoken(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// spokenCount the number of spoken runes in s.
+func spokenCount(s string) int {
+	n := 0
+	for _, r := range s {
+		if isSpoken(r) {
+			n++
+		}
+	}
+	return n
+}
+
+const (
+	sentenceEnds = "。！？!?；;…"
+	clauseEnds   = "，、,：:"
+	// closingMarks may not start a line, they stay with the text before them
+	closingMarks = "，。、！？；：」』”’）》〉,.!?;:)]}\"'"
+	// trailingMarks are dropped from the end of a cue
+	trailingMarks = "，、；：。,;:"
+)
+
+// splitAfter splits s after every rune in marks, closing quotes and brackets that follow stay with the piece.
+// A "." ends a piece when followed by a space, so decimals and abbreviations such as "v1.2" are kept.
+func splitAfter(s, marks string) []string {
+	runes := []rune(s)
+	var pieces []string
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		end := strings.ContainsRune(marks, r)
+		if r == '.' && end {
+			end = i+1 == len(runes) || unicode.IsSpace(runes[i+1])
+		}
+		if !end {
+			continue
+		}
+		for i+1 < len(runes) && (strings.ContainsRune(marks, runes[i+1]) || strings.ContainsRune("」』”’）》〉)\"'", runes[i+1])) {
+			i++
+		}
+		pieces = append(pieces, string(runes[start:i+1]))
+		start = i + 1
+	}
+	if start < len(runes) {
+		pieces = append(pieces, string(runes[start:]))
+	}
+	return pieces
+}
+
+// wrapWords packs the words of s into lines of at most maxWidth columns, CJK text can break between any
+// characters and closing punctuation never starts a line.  The lines are balanced, so the last line is not left
+// with a single word.
+func wrapWords(s string, maxWidth int) []string {
+	tokens := wordTokens(s)
+	lines := packTokens(tokens, maxWidth)
+	if len(lines) > 1 {
+		for width := (textWidth(s) + len(lines) - 1) / len(lines); width < maxWidth; width++ {
+			if balanced := packTokens(tokens, width); len(balanced) == len(lines) {
+				return balanced
+			}
+		}
+	}
+	return lines
+}
+
+// wordTokens splits s into words, single CJK characters and spaces.
+func wordTokens(s string) []string {
+	var tokens []string
+	word := ""
+	for _, r := range s {
+		if isWide(r) || unicode.IsSpace(r) {
+			if word != "" {
+				tokens = append(tokens, word)
+				word = ""
+			}
+			tokens = append(tokens, string(r))
+			continue
+		}
+		word += string(r)
+	}
+	if word != "" {
+		tokens = append(tokens, word)
+	}
+	return tokens
+}
+
+// packTokens fills lines with tokens greedily.
+func packTokens(tokens []string, maxWidth int) []string {
+	var lines []string
+	line := ""
+	for _, token := range tokens {
+		if line == "" && strings.TrimSpace(token) == "" {
+			continue
+		}
+		first, _ := firstRune(token)
+		if line != "" && textWidth(line+token) > maxWidth && !strings.ContainsRune(closingMarks, first) {
+			lines = append(lines, strings.TrimSpace(line))
+			line = strings.TrimLeftFunc(token, unicode.IsSpace)
+			continue
+		}
+		line += token
+	}
+	if strings.TrimSpace(line) != "" {
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	return lines
+}
+
+// firstRune the first rune of s.
+func firstRune(s string) (rune, bool) {
+	for _, r := range s {
+		return r, true
+	}
+	return 0, false
+}
+
+// packLines joins consecutive pieces while the result fits in maxWidth columns, longer pieces are wrapped.
+func packLines(pieces []string, maxWidth int) []string {
+	var lines []string
+	line := ""
+	for _, piece := range pieces {
+		piece = strings.TrimSpace(piece)
+		if piece == "" {
+			continue
+		}
+		joined := piece
+		if line != "" {
+			joined = joinText(line, piece)
+		}
+		if textWidth(joined) <= maxWidth {
+			line = joined
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+		line = ""
+		if textWidth(piece) <= maxWidth {
+			line = piece
+			continue
+		}
+		wrapped := wrapWords(piece, maxWidth)
+		lines = append(lines, wrapped[:len(wrapped)-1]...)
+		line = wrapped[len(wrapped)-1]
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// joinText joins two pieces of text, with a space unless one side is CJK.
+func joinText(a, b string) string {
+	last := []rune(a)[len([]rune(a))-1]
+	first, _ := firstRune(b)
+	if isWide(last) || isWide(first) {
+		return a + b
+	}
+	return a + " " + b
+}
+
+// SplitText splits narration text into cue texts of at most maxWidth columns (DefaultMaxLineWidth when
+// maxWidth <= 0).
+//
+// Every sentence starts a new cue and line breaks in the text are sentence breaks.  A sentence that is too long is
+// split at commas and similar marks, then between words; CJK text breaks between any characters.  Commas and
+// full stops at the end of a cue are dropped, as is usual in subtitles, question and exclamation marks are kept.
+func SplitText(text string, maxWidth int) []string {
+	if maxWidth <= 0 {
+		maxWidth = DefaultMaxLineWidth
+	}
+	var cues []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		paragraph = strings.Join(strings.Fields(paragraph), " ")
+		for _, sentence := range splitAfter(paragraph, sentenceEnds+".") {
+			for _, line := range packLines(splitAfter(sentence, clauseEnds), maxWidth) {
+				line = strings.TrimRightFunc(line, func(r rune) bool {
+					return unicode.IsSpace(r) || strings.ContainsRune(trailingMarks, r)
+				})
+				if line != "" {
+					cues = append(cues, line)
+				}
+			}
+		}
+	}
+	return cues
+}
+
+// textWeight the share of speaking time a cue text takes: the width of its spoken characters.
+func textWeight(text string) int {
+	weight := 0
+	for _, r := range text {
+		if isSpoken(r) {
+			weight += textWidth(string(r))
+		}
+	}
+	if weight == 0 {
+		weight = 1
+	}
+	return weight
+}
+
+// LayoutCues spreads the cue texts over start~end back to back, every cue gets a share of the time proportional
+// to the width of its spoken characters.
+func LayoutCues(texts []string, start, end time.Duration) []*Cue {
+	total := 0
+	for _, text := range texts {
+		total += textWeight(text)
+	}
+	cues := make([]*Cue, 0, len(texts))
+	weight := 0
+	for _, text := range texts {
+		cueStart := start + time.Duration(float64(end-start)*float64(weight)/float64(total))
+		weight += textWeight(text)
+		cueEnd := start + time.Duration(float64(end-start)*float64(weight)/float64(total))
+		cues = append(cues, &Cue{Start: cueStart.Round(time.Millisecond), End: cueEnd.Round(time.Millisecond), Text: text})
+	}
+	return cues
+}
+
+// TimeCues times the cue texts with the word timings of their speech: a cue starts with its first word and ends
+// with its last.  Words are matched by their spoken characters, when the timings do not match the texts the cues
+// are laid out over the span of the timings with LayoutCues.
+func TimeCues(texts []string, timings []WordTiming) []*Cue {
+	if len(timings) == 0 {
+		return nil
+	}
+	// the timing of every spoken character in order
+	var chars []WordTiming
+	for _, word := range timings {
+		for i := spokenCount(word.Text); i > 0; i-- {
+			chars = append(chars, word)
+		}
+	}
+	total := 0
+	for _, text := range texts {
+		total += spokenCount(text)
+	}
+	if total != len(chars) {
+		return LayoutCues(texts, timings[0].Start, timings[len(timings)-1].End)
+	}
+
+	cues := make([]*Cue, 0, len(texts))
+	pos := 0
+	for _, text := range texts {
+		n := spokenCount(text)
+		if n == 0 {
+			continue
+		}
+		cues = append(cues, &Cue{Start: chars[pos].Start, End: chars[pos+n-1].End, Text: text})
+		pos += n
+	}
+	return cues
+}
@@ -0,0 +1,193 @@
+package subtitles
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatVTTTimestamp formats d as "hh:mm:ss.mmm".
+func FormatVTTTimestamp(d time.Duration) string {
+	h, m, s, ms := splitDuration(d)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", h, m, s, ms)
+}
+
+func alignmentOf(row, col int) Alignment {
+	return Alignment(row*3 + col + 1)
+}
+
+// rowCol returns the row (0 bottom, 1 middle, 2 top) and column (0 left, 1 center, 2 right) of an alignment.
+func rowCol(a Alignment) (row, col int) {
+	if a == AlignDefault {
+		a = AlignBottomCenter
+	}
+	return int(a-1) / 3, int(a-1) % 3
+}
+
+func parsePercent(s string) (float64, bool) {
+	if !strings.HasSuffix(s, "%") {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	return v / 100, err == nil
+}
+
+// parseVTTSettings maps the cue settings "line", "position" and "align" to an alignment and a position.
+func parseVTTSettings(c *Cue, settings string) {
+	row, col := -1, -1
+	var x, y float64
+	hasX, hasY := false, false
+	for _, setting := range strings.Fields(settings) {
+		kv := strings.SplitN(setting, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		value := strings.SplitN(kv[1], ",", 2)[0]
+		switch kv[0] {
+		case "line":
+			if v, ok := parsePercent(value); ok {
+				y, hasY = v, true
+				switch {
+				case v < 1.0/3:
+					row = 2
+				case v < 2.0/3:
+					row = 1
+				default:
+					row = 0
+				}
+			} else if n, err := strconv.Atoi(value); err == nil {
+				// positive line numbers count from the top, negative ones from the bottom
+				if n >= 0 {
+					row = 2
+				} else {
+					row = 0
+				}
+			}
+		case "position":
+			x, hasX = parsePercent(value)
+		case "align":
+			switch value {
+			case "start", "left":
+				col = 0
+			case "center", "middle":
+				col = 1
+			case "end", "right":
+				col = 2
+			}
+		}
+	}
+	if hasX && hasY {
+		c.Position = &Position{X: x, Y: y}
+	}
+	if row >= 0 || col >= 0 {
+		if row < 0 {
+			row = 0
+		}
+		if col < 0 {
+			col = 1
+		}
+		c.Alignment = alignmentOf(row, col)
+	}
+}
+
+// ReadVTT parses a WebVTT document.  NOTE, STYLE and REGION blocks are skipped, cue tags are kept in the text and
+// the escapes of "&", "<", ">" and non-breaking spaces are decoded.
+func ReadVTT(r io.Reader) (*Track, error) {
+	blocks, err := readBlocks(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(blocks) == 0 || !strings.HasPrefix(blocks[0][0], "WEBVTT") {
+		return nil, fmt.Errorf("missing WEBVTT header")
+	}
+	t := &Track{}
+	for _, block := range blocks[1:] {
+		if strings.HasPrefix(block[0], "NOTE") || block[0] == "STYLE" || block[0] == "REGION" {
+			continue
+		}
+		// optional cue identifier
+		if !strings.Contains(block[0], "-->") {
+			block = block[1:]
+		}
+		if len(block) == 0 {
+			continue
+		}
+		start, end, settings, err := parseCueTimes(block[0])
+		if err != nil {
+			return nil, err
+		}
+		c := &Cue{Start: start, End: end, Text: vttUnescaper.Replace(strings.Join(block[1:], "\n"))}
+		parseVTTSettings(c, settings)
+		t.Cues = append(t.Cues, c)
+	}
+	return t, nil
+}
+
+func vttSettings(c *Cue) string {
+	var settings []string
+	row, col := rowCol(c.Alignment)
+	if c.Position != nil {
+		settings = append(settings,
+			fmt.Sprintf("position:%s%%", strconv.FormatFloat(c.Position.X*100, 'f', -1, 64)),
+			fmt.Sprintf("line:%s%%", strconv.FormatFloat(c.Position.Y*100, 'f', -1, 64)))
+	} else {
+		switch row {
+		case 2:
+			settings = append(settings, "line:0")
+		case 1:
+			settings = append(settings, "line:50%")
+		}
+	}
+	switch col {
+	case 0:
+		settings = append(settings, "align:start")
+	case 2:
+		settings = append(settings, "align:end")
+	}
+	return strings.Join(settings, " ")
+}
+
+var (
+	// vttEntityRegexp matches the character references allowed in WebVTT cue text
+	vttEntityRegexp = regexp.MustCompile(`^&(?:amp|lt|gt|nbsp|lrm|rlm|#[0-9]+|#x[0-9a-fA-F]+);`)
+	// vttTagRegexp matches the WebVTT cue tags, e.g. "<i>", "</b>", "<v Roger>", "<c.yellow>" and "<00:01.500>"
+	vttTagRegexp = regexp.MustCompile(`^(?:</?(?:i|b|u|c|v|lang|ruby|rt)(?:[ .][^<>\n]*)?>|<(?:\d+:)?\d{2}:\d{2}\.\d{3}>)`)
+	// vttUnescaper decodes the references written by escapeVTTText
+	vttUnescaper = strings.NewReplacer("&lt;", "<", "&gt;", ">", "&nbsp;", "\u00a0", "&amp;", "&")
+)
+
+// escapeVTTText escapes "&" and "<" that do not start a character reference or a cue tag, so cue text is not read
+// as markup while tags such as "<i>" keep working.
+func escapeVTTText(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '&' && vttEntityRegexp.FindStringIndex(text[i:]) == nil:
+			b.WriteString("&amp;")
+		case text[i] == '<' && vttTagRegexp.FindStringIndex(text[i:]) == nil:
+			b.WriteString("&lt;")
+		default:
+			b.WriteByte(text[i])
+		}
+	}
+	return b.String()
+}
+
+// WriteVTT writes the track as a WebVTT document, alignments and positions are written as cue settings.
+// Blank lines in cue text are dropped, "&" and "<" that are not markup are escaped.
+func WriteVTT(w io.Writer, t *Track) error {
+	bw := bufio.NewWriter(w)
+	_, _ = bw.WriteString("WEBVTT\n\n")
+	for _, c := range t.Cues {
+		timing := fmt.Sprintf("%s --> %s", FormatVTTTimestamp(c.Start), FormatVTTTimestamp(c.End))
+		if settings := vttSettings(c); settings != "" {
+			timing += " " + settings
+		}
+		_, _ = fmt.Fprintf(bw, "%s\n%s\n\n", timing, escapeVTTText(cueText(c.Text)))
+	}
+	return bw.Flush()
+}
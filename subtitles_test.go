@@ -0,0 +1,43 @@
+package ffmpeg_go
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/u2takey/ffmpeg-go/subtitles"
+)
+
+func TestBurnSubtitles(t *testing.T) {
+	args := Input("in.mp4").
+		BurnSubtitles("C:/subs/zh.srt", &subtitles.Style{FontName: "Noto Sans CJK SC", FontSize: 28},
+			KwArgs{"fontsdir": "./fonts"}).
+		Output("out.mp4").GetArgs()
+	assert.Equal(t, []string{
+		"-i", "in.mp4",
+		"-filter_complex", `[0]subtitles=filename=C\\:/subs/zh.srt:fontsdir=./fonts:force_style=FontName=Noto Sans CJK SC\,FontSize=28[s0]`,
+		"-map", "[s0]", "out.mp4",
+	}, args)
+
+	args = Input("in.mp4").BurnSubtitles("subs.ass", nil).Output("out.mp4").GetArgs()
+	assert.Equal(t, `[0]ass=filename=subs.ass[s0]`, args[3])
+
+	// fontsdir is a path as well, drive letters and quotes must not end the option
+	args = Input("in.mp4").BurnSubtitles("subs.ass", nil, KwArgs{"fontsdir": "C:/Bob's fonts"}).Output("out.mp4").GetArgs()
+	assert.Equal(t, `[0]ass=filename=subs.ass:fontsdir=C\\:/Bob\\\'s fonts[s0]`, args[3])
+}
+
+func TestMuxSubtitles(t *testing.T) {
+	args := MuxSubtitles([]*Stream{Input("in.mp4")}, "out.mp4", []SubtitleTrack{
+		{File: "en.srt", Language: "eng", Title: "English", Default: true},
+		{File: "zh.vtt", Language: "chi"},
+	}, KwArgs{"c:v": "copy"}).GetArgs()
+	assert.Equal(t, []string{
+		"-i", "in.mp4", "-i", "en.srt", "-i", "zh.vtt",
+		"-map", "0", "-map", "1:s", "-map", "2:s",
+		"-c:s", "mov_text", "-c:v", "copy",
+		"-disposition:s:0", "default", "-disposition:s:1", "0",
+		"-metadata:s:s:0", "language=eng", "-metadata:s:s:0", "title=English",
+		"-metadata:s:s:1", "language=chi",
+		"out.mp4",
+	}, args)
+}
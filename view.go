@@ -1,8 +1,17 @@
 package ffmpeg_go
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
+	"html"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 )
 
 type ViewType string
@@ -12,28 +21,154 @@ const (
 	ViewTypeFlowChart ViewType = "flowChart"
 	// StateDiagram the diagram type for output in stateDiagram style (https://mermaid-js.github.io/mermaid/#/stateDiagram)
 	ViewTypeStateDiagram ViewType = "stateDiagram"
+	// Dot the diagram type for output in graphviz dot language (https://graphviz.org/doc/info/lang.html)
+	ViewTypeDot ViewType = "dot"
+	// HTML a self-contained html page with the graph drawn as inline svg
+	ViewTypeHTML ViewType = "html"
 )
 
-func (s *Stream) View(viewType ViewType) (string, error) {
+// ViewOption customizes the output of Stream.View
+type ViewOption func(o *viewOptions)
+
+type viewOptions struct {
+	// annotations keyed by node hash
+	nodeNotes map[int][]string
+	// annotations keyed by node name (e.g. filter name)
+	nameNotes map[string][]string
+	bench     BenchmarkStats
+}
+
+// WithAnnotation attaches a note (e.g. runtime stats) to the node which produces the given stream.
+func WithAnnotation(s *Stream, note string) ViewOption {
+	return func(o *viewOptions) {
+		o.nodeNotes[s.Node.Hash()] = append(o.nodeNotes[s.Node.Hash()], note)
+	}
+}
+
+// WithFilterAnnotation attaches a note to every node with the given name, e.g. "scale" or "overlay".
+func WithFilterAnnotation(name, note string) ViewOption {
+	return func(o *viewOptions) {
+		o.nameNotes[name] = append(o.nameNotes[name], note)
+	}
+}
+
+// WithBenchmarkStats annotates nodes with timings parsed by ParseBenchmarkAll.
+//
+// ffmpeg only times decoding and encoding, not filters: decode_* tasks are attached to the input with the same
+// file index and encode_* tasks to the output with the same file index, other tasks are ignored.
+func WithBenchmarkStats(stats BenchmarkStats) ViewOption {
+	return func(o *viewOptions) {
+		for k, v := range stats {
+			o.bench[k] += v
+		}
+	}
+}
+
+func (s *Stream) View(viewType ViewType, options ...ViewOption) (string, error) {
 	switch viewType {
 	case ViewTypeFlowChart:
-		return visualizeForMermaidAsFlowChart(s)
+		return visualizeForMermaidAsFlowChart(s, options...)
 	case ViewTypeStateDiagram:
 		return visualizeForMermaidAsStateDiagram(s)
+	case ViewTypeDot:
+		return visualizeForDot(s, options...)
+	case ViewTypeHTML:
+		return visualizeForHTML(s, options...)
 	default:
 		return "", fmt.Errorf("unknown ViewType: %s", viewType)
 	}
 }
 
-func visualizeForMermaidAsStateDiagram(s *Stream) (string, error) {
-	var buf bytes.Buffer
-
+func sortedViewNodes(s *Stream) ([]DagNode, map[int]map[Label][]NodeInfo, error) {
 	nodes := getStreamSpecNodes([]*Stream{s})
 	var dagNodes []DagNode
 	for i := range nodes {
 		dagNodes = append(dagNodes, nodes[i])
 	}
-	sorted, outGoingMap, err := TopSort(dagNodes)
+	return TopSort(dagNodes)
+}
+
+// collectNotes resolves all view options to annotations keyed by node hash.
+func collectNotes(sorted []DagNode, options []ViewOption) map[int][]string {
+	o := &viewOptions{
+		nodeNotes: map[int][]string{},
+		nameNotes: map[string][]string{},
+		bench:     BenchmarkStats{},
+	}
+	for _, option := range options {
+		option(o)
+	}
+	notes := o.nodeNotes
+	inputIndex, outputIndex := 0, 0
+	fileIndex := map[int]string{}
+	for _, n := range sorted {
+		node := n.(*Node)
+		notes[node.Hash()] = append(notes[node.Hash()], o.nameNotes[node.name]...)
+		switch node.nodeType {
+		case "InputNode":
+			fileIndex[node.Hash()] = fmt.Sprintf("decode %d", inputIndex)
+			inputIndex++
+		case "OutputNode":
+			fileIndex[node.Hash()] = fmt.Sprintf("encode %d", outputIndex)
+			outputIndex++
+		}
+	}
+	for _, task := range o.bench.Tasks() {
+		kind, index := task, ""
+		if i := strings.Index(task, " "); i >= 0 {
+			kind, index = task[:i], task[i+1:]
+		}
+		if i := strings.Index(index, "."); i >= 0 {
+			index = index[:i]
+		}
+		if !strings.HasPrefix(kind, "decode_") && !strings.HasPrefix(kind, "encode_") {
+			continue
+		}
+		for _, n := range sorted {
+			node := n.(*Node)
+			if fileIndex[node.Hash()] == fmt.Sprintf("%s %s", kind[:6], index) {
+				notes[node.Hash()] = append(notes[node.Hash()], fmt.Sprintf("%s: %s", task, o.bench[task]))
+			}
+		}
+	}
+	return notes
+}
+
+// viewLabel returns the node name together with its arguments, one item per line.
+func viewLabel(n *Node) []string {
+	lines := []string{n.name}
+	kwargs := n.kwargs.Copy()
+	switch n.nodeType {
+	case "InputNode", "OutputNode":
+		lines[0] = kwargs.PopString("filename")
+	}
+	args := append([]string{}, n.args...)
+	for _, k := range kwargs.SortedKeys() {
+		v := getString(kwargs[k])
+		if v == "" {
+			args = append(args, k)
+		} else {
+			args = append(args, fmt.Sprintf("%s=%s", k, v))
+		}
+	}
+	if len(args) > 0 {
+		lines = append(lines, strings.Join(args, ":"))
+	}
+	return lines
+}
+
+func viewEdgeLabel(label Label, selector Selector) string {
+	ret := string(label)
+	if selector != "" {
+		ret += ":" + string(selector)
+	}
+	return ret
+}
+
+func visualizeForMermaidAsStateDiagram(s *Stream) (string, error) {
+	var buf bytes.Buffer
+
+	sorted, outGoingMap, err := sortedViewNodes(s)
 	if err != nil {
 		return "", err
 	}
@@ -56,23 +191,35 @@ func visualizeForMermaidAsStateDiagram(s *Stream) (string, error) {
 	return buf.String(), nil
 }
 
+// mermaidEscaper replaces the characters that mermaid would read as markup with its entity codes.
+var mermaidEscaper = strings.NewReplacer("#", "#35;", `"`, "#quot;", "&", "#amp;", "<", "#lt;", ">", "#gt;")
+
+// escapeMermaid escapes a line of a quoted mermaid label, annotations are shown as text rather than html.
+func escapeMermaid(text string) string {
+	return mermaidEscaper.Replace(text)
+}
+
 // visualizeForMermaidAsFlowChart outputs a visualization of a FSM in Mermaid format (including highlighting of current state).
-func visualizeForMermaidAsFlowChart(s *Stream) (string, error) {
+func visualizeForMermaidAsFlowChart(s *Stream, options ...ViewOption) (string, error) {
 	var buf bytes.Buffer
 
-	nodes := getStreamSpecNodes([]*Stream{s})
-	var dagNodes []DagNode
-	for i := range nodes {
-		dagNodes = append(dagNodes, nodes[i])
-	}
-	sorted, outGoingMap, err := TopSort(dagNodes)
+	sorted, outGoingMap, err := sortedViewNodes(s)
 	if err != nil {
 		return "", err
 	}
+	notes := collectNotes(sorted, options)
 	buf.WriteString("graph LR\n")
 
 	for _, node := range sorted {
-		buf.WriteString(fmt.Sprintf(`    %d[%s]`, node.Hash(), node.ShortRepr()))
+		if len(notes[node.Hash()]) > 0 {
+			var label []string
+			for _, l := range append([]string{node.ShortRepr()}, notes[node.Hash()]...) {
+				label = append(label, escapeMermaid(l))
+			}
+			buf.WriteString(fmt.Sprintf(`    %d["%s"]`, node.Hash(), strings.Join(label, "<br/>")))
+		} else {
+			buf.WriteString(fmt.Sprintf(`    %d[%s]`, node.Hash(), node.ShortRepr()))
+		}
 		buf.WriteString("\n")
 	}
 	buf.WriteString("\n")
@@ -96,3 +243,221 @@ func visualizeForMermaidAsFlowChart(s *Stream) (string, error) {
 
 	return buf.String(), nil
 }
+
+func escapeDot(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	return strings.ReplaceAll(text, `"`, `\"`)
+}
+
+// visualizeForDot outputs the graph in graphviz dot language, filter nodes are labelled with their arguments.
+func visualizeForDot(s *Stream, options ...ViewOption) (string, error) {
+	var buf bytes.Buffer
+
+	sorted, outGoingMap, err := sortedViewNodes(s)
+	if err != nil {
+		return "", err
+	}
+	notes := collectNotes(sorted, options)
+
+	buf.WriteString("digraph G {\n")
+	buf.WriteString("    rankdir=LR;\n")
+	buf.WriteString("    node [fontname=\"Helvetica\"];\n")
+	for _, n := range sorted {
+		node := n.(*Node)
+		var lines []string
+		for _, l := range append(viewLabel(node), notes[node.Hash()]...) {
+			lines = append(lines, escapeDot(l))
+		}
+		style := ""
+		switch node.nodeType {
+		case "InputNode":
+			style = `shape=box, style="rounded,filled", fillcolor="#dae8fc"`
+		case "OutputNode":
+			style = `shape=box, style="rounded,filled", fillcolor="#d5e8d4"`
+		case "FilterNode":
+			style = `shape=box`
+		default:
+			style = `shape=box, style=dashed`
+		}
+		buf.WriteString(fmt.Sprintf("    n%d [label=\"%s\", %s];\n", uint(node.Hash()), strings.Join(lines, `\n`), style))
+	}
+	for _, node := range sorted {
+		for _, e := range GetOutGoingEdges(node, outGoingMap[node.Hash()]) {
+			label := viewEdgeLabel(e.UpStreamLabel, e.UpStreamSelector)
+			if label == "" {
+				buf.WriteString(fmt.Sprintf("    n%d -> n%d;\n", uint(e.UpStreamNode.Hash()), uint(e.DownStreamNode.Hash())))
+			} else {
+				buf.WriteString(fmt.Sprintf("    n%d -> n%d [label=\"%s\"];\n",
+					uint(e.UpStreamNode.Hash()), uint(e.DownStreamNode.Hash()), escapeDot(label)))
+			}
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.String(), nil
+}
+
+const htmlViewTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>ffmpeg-go graph</title>
+</head>
+<body>
+%s
+<pre>ffmpeg %s</pre>
+</body>
+</html>
+`
+
+// svg layout of the html view, text is drawn in a monospace font so its width can be estimated
+const (
+	svgCharWidth  = 7.5
+	svgLineHeight = 16
+	svgPadding    = 8
+	svgColumnGap  = 80
+	svgRowGap     = 24
+)
+
+type svgBox struct {
+	lines      []string
+	fill, dash string
+	x, y, w, h float64
+}
+
+// visualizeForSVG draws the graph as an svg image from left to right, every node is placed in the column after
+// its furthest upstream node.
+func visualizeForSVG(s *Stream, options ...ViewOption) (string, error) {
+	sorted, outGoingMap, err := sortedViewNodes(s)
+	if err != nil {
+		return "", err
+	}
+	notes := collectNotes(sorted, options)
+	var edges []DagEdge
+	for _, node := range sorted {
+		edges = append(edges, GetOutGoingEdges(node, outGoingMap[node.Hash()])...)
+	}
+	column := map[int]int{}
+	for changed := true; changed; {
+		changed = false
+		for _, e := range edges {
+			if c := column[e.UpStreamNode.Hash()] + 1; c > column[e.DownStreamNode.Hash()] {
+				column[e.DownStreamNode.Hash()] = c
+				changed = true
+			}
+		}
+	}
+
+	boxes := map[int]*svgBox{}
+	var columns [][]*svgBox
+	for _, n := range sorted {
+		node := n.(*Node)
+		box := &svgBox{lines: append(viewLabel(node), notes[node.Hash()]...), fill: "#ffffff"}
+		switch node.nodeType {
+		case "InputNode":
+			box.fill = "#dae8fc"
+		case "OutputNode":
+			box.fill = "#d5e8d4"
+		case "FilterNode":
+		default:
+			box.dash = "4 2"
+		}
+		for _, l := range box.lines {
+			box.w = math.Max(box.w, float64(len([]rune(l)))*svgCharWidth+2*svgPadding)
+		}
+		box.h = float64(len(box.lines)*svgLineHeight + 2*svgPadding)
+		boxes[node.Hash()] = box
+		c := column[node.Hash()]
+		for len(columns) <= c {
+			columns = append(columns, nil)
+		}
+		columns[c] = append(columns[c], box)
+	}
+	width, height, x := 0.0, 0.0, float64(svgRowGap)
+	for _, col := range columns {
+		colWidth, y := 0.0, float64(svgRowGap)
+		for _, box := range col {
+			box.x, box.y = x, y
+			y += box.h + svgRowGap
+			colWidth = math.Max(colWidth, box.w)
+		}
+		height = math.Max(height, y)
+		x += colWidth + svgColumnGap
+		width = x - svgColumnGap + svgRowGap
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" font-family="monospace" font-size="12">`+"\n", width, height))
+	buf.WriteString(`<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="6" markerHeight="6" orient="auto">` +
+		`<path d="M0,0 L10,5 L0,10 z"/></marker></defs>` + "\n")
+	for _, e := range edges {
+		up, down := boxes[e.UpStreamNode.Hash()], boxes[e.DownStreamNode.Hash()]
+		x1, y1, x2, y2 := up.x+up.w, up.y+up.h/2, down.x, down.y+down.h/2
+		buf.WriteString(fmt.Sprintf(`<line x1="%g" y1="%g" x2="%g" y2="%g" stroke="black" marker-end="url(#arrow)"/>`+"\n", x1, y1, x2, y2))
+		if label := viewEdgeLabel(e.UpStreamLabel, e.UpStreamSelector); label != "" {
+			buf.WriteString(fmt.Sprintf(`<text x="%g" y="%g" text-anchor="middle">%s</text>`+"\n",
+				(x1+x2)/2, (y1+y2)/2-4, html.EscapeString(label)))
+		}
+	}
+	for _, n := range sorted {
+		box := boxes[n.Hash()]
+		rect := fmt.Sprintf(`<rect x="%g" y="%g" width="%g" height="%g" rx="6" fill="%s" stroke="black"`, box.x, box.y, box.w, box.h, box.fill)
+		if box.dash != "" {
+			rect += fmt.Sprintf(` stroke-dasharray="%s"`, box.dash)
+		}
+		buf.WriteString(rect + "/>\n")
+		for i, l := range box.lines {
+			buf.WriteString(fmt.Sprintf(`<text x="%g" y="%g">%s</text>`+"\n",
+				box.x+svgPadding, box.y+svgPadding+float64((i+1)*svgLineHeight)-4, html.EscapeString(l)))
+		}
+	}
+	buf.WriteString("</svg>")
+	return buf.String(), nil
+}
+
+// visualizeForHTML outputs a self-contained html page with the graph drawn as inline svg and the compiled command,
+// it loads no scripts and renders offline.
+func visualizeForHTML(s *Stream, options ...ViewOption) (string, error) {
+	graph, err := visualizeForSVG(s, options...)
+	if err != nil {
+		return "", err
+	}
+	command := ""
+	if s.Type == "OutputStream" {
+		command = strings.Join(s.GetArgs(), " ")
+	}
+	return fmt.Sprintf(htmlViewTemplate, graph, html.EscapeString(command)), nil
+}
+
+// BenchmarkStats the accumulated real time per task reported by ffmpeg “-benchmark_all“,
+// keyed by task, e.g. "decode_video 0.0" or "encode_audio 0.1".
+type BenchmarkStats map[string]time.Duration
+
+// Tasks returns the task names in sorted order.
+func (b BenchmarkStats) Tasks() []string {
+	var r []string
+	for k := range b {
+		r = append(r, k)
+	}
+	sort.Strings(r)
+	return r
+}
+
+var benchmarkAllRegexp = regexp.MustCompile(`bench:\s+(\d+) user\s+(\d+) sys\s+(\d+) real (.*?)\s*$`)
+
+// ParseBenchmarkAll parses the stderr of an ffmpeg run with “-benchmark_all“.
+func ParseBenchmarkAll(r io.Reader) (BenchmarkStats, error) {
+	stats := BenchmarkStats{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		m := benchmarkAllRegexp.FindStringSubmatch(scanner.Text())
+		if m == nil || m[4] == "" {
+			continue
+		}
+		realUs, err := strconv.ParseInt(m[3], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		stats[m[4]] += time.Duration(realUs) * time.Microsecond
+	}
+	return stats, scanner.Err()
+}
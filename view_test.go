@@ -0,0 +1,105 @@
+package ffmpeg_go
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestViewDot(t *testing.T) {
+	in := Input("in.mp4")
+	out := in.Video().HFlip().Trim(KwArgs{"start_frame": 10, "end_frame": 20}).Output("out.mp4")
+	dot, err := out.View(ViewTypeDot)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(dot, "digraph G {\n"))
+	assert.Contains(t, dot, `label="in.mp4", shape=box, style="rounded,filled", fillcolor="#dae8fc"`)
+	assert.Contains(t, dot, `label="out.mp4", shape=box, style="rounded,filled", fillcolor="#d5e8d4"`)
+	assert.Contains(t, dot, `label="trim\nend_frame=20:start_frame=10", shape=box`)
+	assert.Contains(t, dot, `[label=":v"]`)
+}
+
+func TestViewAnnotations(t *testing.T) {
+	in := Input("in.mp4")
+	flipped := in.HFlip()
+	out := flipped.Output("out.mp4")
+	dot, err := out.View(ViewTypeDot,
+		WithAnnotation(flipped, "12ms"),
+		WithFilterAnnotation("output", "done"),
+		WithBenchmarkStats(BenchmarkStats{"decode_video 0.0": 3 * time.Millisecond}))
+	assert.Nil(t, err)
+	assert.Contains(t, dot, `label="hflip\n12ms"`)
+	assert.Contains(t, dot, `label="out.mp4\ndone"`)
+	assert.Contains(t, dot, `label="in.mp4\ndecode_video 0.0: 3ms"`)
+
+	chart, err := out.View(ViewTypeFlowChart, WithAnnotation(flipped, "12ms"))
+	assert.Nil(t, err)
+	assert.Contains(t, chart, `["hflip<br/>12ms"]`)
+}
+
+func TestViewHTML(t *testing.T) {
+	out := Input("in.mp4").HFlip().Output("out.mp4")
+	page, err := out.View(ViewTypeHTML)
+	assert.Nil(t, err)
+	// the graph is drawn inline, the page loads nothing from the network
+	assert.NotContains(t, page, "<script")
+	assert.NotContains(t, page, "src=")
+	assert.NotContains(t, page, "https://")
+	assert.Contains(t, page, `<svg xmlns="http://www.w3.org/2000/svg"`)
+	assert.Contains(t, page, `fill="#dae8fc"`)
+	assert.Contains(t, page, ">in.mp4</text>")
+	assert.Contains(t, page, ">hflip</text>")
+	assert.Contains(t, page, ">out.mp4</text>")
+	assert.Equal(t, 2, strings.Count(page, "<line "))
+	assert.Contains(t, page, "-filter_complex [0]hflip[s0]")
+}
+
+func TestViewEscapesAnnotations(t *testing.T) {
+	in := Input("in.mp4")
+	flipped := in.HFlip()
+	out := flipped.Output("out.mp4")
+	note := `<img src=x onerror="alert(1)"> #1 & co`
+	chart, err := out.View(ViewTypeFlowChart, WithAnnotation(flipped, note))
+	assert.Nil(t, err)
+	assert.Contains(t, chart, `["hflip<br/>#lt;img src=x onerror=#quot;alert(1)#quot;#gt; #35;1 #amp; co"]`)
+
+	page, err := out.View(ViewTypeHTML, WithAnnotation(flipped, note))
+	assert.Nil(t, err)
+	assert.NotContains(t, page, "<img")
+	assert.Contains(t, page, "&lt;img src=x")
+}
+
+func TestParseBenchmarkAll(t *testing.T) {
+	log := `frame=    1 fps=0.0 q=0.0 size=       0kB
+bench:      100 user        0 sys     1500 real decode_video 0.0 
+bench:      100 user        0 sys      500 real decode_video 0.0 
+bench:      200 user       10 sys     2000 real encode_video 0.0 
+`
+	stats, err := ParseBenchmarkAll(strings.NewReader(log))
+	assert.Nil(t, err)
+	assert.Equal(t, BenchmarkStats{
+		"decode_video 0.0": 2 * time.Millisecond,
+		"encode_video 0.0": 2 * time.Millisecond,
+	}, stats)
+	assert.Equal(t, []string{"decode_video 0.0", "encode_video 0.0"}, stats.Tasks())
+}
+
+func TestViewBenchmarkAll(t *testing.T) {
+	// lines printed by ffmpeg -benchmark_all, only decoding and encoding are timed
+	log := `bench: 1930 user 0 sys 2052 real decode_video 0.0
+bench: 1012 user 0 sys 1048 real decode_video 1.0
+bench: 115 user 0 sys 120 real decode_audio 0.1
+bench: 8120 user 480 sys 8710 real encode_video 0.0
+`
+	stats, err := ParseBenchmarkAll(strings.NewReader(log))
+	assert.Nil(t, err)
+	main, logo := Input("in.mp4"), Input("logo.png")
+	out := Filter([]*Stream{main.Video(), logo}, "overlay", nil).Output("out.mp4")
+	dot, err := out.View(ViewTypeDot, WithBenchmarkStats(stats))
+	assert.Nil(t, err)
+	assert.Contains(t, dot, `label="in.mp4\ndecode_audio 0.1: 120µs\ndecode_video 0.0: 2.052ms"`)
+	assert.Contains(t, dot, `label="logo.png\ndecode_video 1.0: 1.048ms"`)
+	assert.Contains(t, dot, `label="out.mp4\nencode_video 0.0: 8.71ms"`)
+	assert.Contains(t, dot, `label="overlay", shape=box`)
+}